@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"workflow-code-test/api/services/workflow"
+)
+
+// definition is the file form of a workflow: what the API returns, without the timestamps
+// that would change on every export.
+type definition struct {
+	ID    string              `json:"id"`
+	Name  string              `json:"name"`
+	Nodes []workflow.Node     `json:"nodes"`
+	Edges []workflow.Edge     `json:"edges"`
+	Tests []workflow.TestCase `json:"tests,omitempty"`
+}
+
+// readJSONFile decodes the JSON in path into v, rejecting unknown fields so that typos
+// in hand-edited files are caught.
+func readJSONFile(path string, v any) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// readDefinition reads a workflow definition. Files holding a whole API response,
+// timestamps included, are accepted too.
+func readDefinition(path string) (*workflow.Workflow, error) {
+	var wf workflow.Workflow
+	if err := readJSONFile(path, &wf); err != nil {
+		return nil, err
+	}
+	if wf.Edges == nil {
+		wf.Edges = []workflow.Edge{}
+	}
+	return &wf, nil
+}
+
+// writeDefinition writes wf in the file form, indented for readable diffs.
+func writeDefinition(w io.Writer, wf *workflow.Workflow) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(definition{ID: wf.ID, Name: wf.Name, Nodes: wf.Nodes, Edges: wf.Edges, Tests: wf.Tests})
+}
+
+func runValidate(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	pluginDir := fs.String("plugin-dir", os.Getenv("PLUGIN_DIR"), "directory of executor plugins whose node types are accepted too")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("validate: no definition files given")
+	}
+	registry, err := newRegistry(ctx, registryOptions{pluginDir: *pluginDir})
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, path := range files {
+		wf, err := readDefinition(path)
+		if err == nil {
+			if err = workflow.ValidateDefinition(wf, registry); err != nil {
+				err = fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(out, "FAIL %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "ok   %s\n", path)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d definitions are invalid", failed, len(files))
+	}
+	return nil
+}
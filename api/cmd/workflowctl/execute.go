@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"workflow-code-test/api/services/workflow"
+)
+
+// registryOptions chooses the integrations behind the executors.
+type registryOptions struct {
+	live        bool    // Call the real Open-Meteo APIs
+	temperature float64 // °C reported by the stub weather client when not live
+	pluginDir   string  // Directory of executor plugins; empty registers none
+}
+
+// newRegistry builds the executors the server runs. Unless opts.live is set, weather
+// comes from a stub and cities are geocoded from the bundled gazetteer only, so runs need
+// no network access and give the same path every time.
+func newRegistry(ctx context.Context, opts registryOptions) (workflow.Registry, error) {
+	gazetteer, err := workflow.NewGazetteer()
+	if err != nil {
+		return nil, err
+	}
+	cfg := workflow.DefaultConfig()
+	var weather workflow.WeatherClient = stubWeather{temperature: opts.temperature}
+	var geocoder workflow.Geocoder = gazetteer
+	if opts.live {
+		weather = workflow.NewOpenMeteoClient(cfg.WeatherURL, cfg.WeatherTimeout)
+		geocoder = workflow.ChainGeocoder{gazetteer, workflow.NewOpenMeteoGeocoder(cfg.GeocodingURL, cfg.WeatherTimeout)}
+	}
+
+	registry := workflow.NewRegistry(weather, geocoder, cfg.DelayInlineMax)
+	if opts.pluginDir != "" {
+		plugins, err := workflow.LoadPlugins(ctx, opts.pluginDir)
+		if err != nil {
+			return nil, err
+		}
+		registry.RegisterPlugins(plugins)
+	}
+	return registry, nil
+}
+
+// stubWeather reports the same calm, dry conditions everywhere at a chosen temperature.
+type stubWeather struct {
+	temperature float64
+}
+
+func (s stubWeather) GetWeather(_ context.Context, _, _ float64, forecastDays int) (*workflow.WeatherReport, error) {
+	now := time.Now().UTC().Truncate(time.Hour)
+	report := &workflow.WeatherReport{
+		Current: workflow.WeatherObservation{
+			Time:                now.Format("2006-01-02T15:04"),
+			Temperature:         s.temperature,
+			ApparentTemperature: s.temperature,
+			WindSpeed:           10,
+			Humidity:            50,
+		},
+	}
+	day := now.Truncate(24 * time.Hour)
+	for d := 0; d < forecastDays; d++ {
+		report.Daily = append(report.Daily, workflow.DailyForecast{
+			Date:           day.AddDate(0, 0, d).Format("2006-01-02"),
+			TemperatureMax: s.temperature,
+			TemperatureMin: s.temperature,
+			WindSpeedMax:   10,
+		})
+		for h := 0; h < 24; h++ {
+			report.Hourly = append(report.Hourly, workflow.HourlyForecast{
+				Time:        day.AddDate(0, 0, d).Add(time.Duration(h) * time.Hour).Format("2006-01-02T15:04"),
+				Temperature: s.temperature,
+				WindSpeed:   10,
+			})
+		}
+	}
+	return report, nil
+}
+
+func runExecute(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	inputPath := fs.String("input", "", "JSON file holding the execute request body: formData, condition, unit, mode, mocks")
+	var opts registryOptions
+	fs.BoolVar(&opts.live, "live", false, "call the real Open-Meteo APIs instead of the stub weather client")
+	fs.Float64Var(&opts.temperature, "temperature", 20, "temperature in °C that the stub weather client reports")
+	fs.StringVar(&opts.pluginDir, "plugin-dir", os.Getenv("PLUGIN_DIR"), "directory of executor plugins to register")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return errors.New("run: give exactly one definition file")
+	}
+	if *inputPath == "" {
+		return errors.New("run: -input is required")
+	}
+
+	registry, err := newRegistry(ctx, opts)
+	if err != nil {
+		return err
+	}
+	wf, err := readDefinition(files[0])
+	if err != nil {
+		return err
+	}
+	if err := workflow.ValidateDefinition(wf, registry); err != nil {
+		return fmt.Errorf("%s: %w", files[0], err)
+	}
+	var req workflow.ExecuteRequest
+	if err := readJSONFile(*inputPath, &req); err != nil {
+		return err
+	}
+	if err := workflow.ValidateExecuteRequest(req); err != nil {
+		return fmt.Errorf("%s: %w", *inputPath, err)
+	}
+	if err := workflow.ValidateMocks(wf, req.Mocks); err != nil {
+		return fmt.Errorf("%s: %w", *inputPath, err)
+	}
+
+	// Without an execution store, delays longer than the inline maximum fail the run
+	state := workflow.NewExecutionState(req)
+	results, err := workflow.NewEngine(registry).Execute(ctx, wf, state)
+	if err != nil {
+		return err
+	}
+	results.Metadata = workflow.ExecutionMetadata(state)
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(results); err != nil {
+		return err
+	}
+	if results.Status != "completed" {
+		return fmt.Errorf("execution %s", results.Status)
+	}
+	return nil
+}
+
+func runTests(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	pluginDir := fs.String("plugin-dir", os.Getenv("PLUGIN_DIR"), "directory of executor plugins to register")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("test: no definition files given")
+	}
+	registry, err := newRegistry(ctx, registryOptions{pluginDir: *pluginDir})
+	if err != nil {
+		return err
+	}
+	engine := workflow.NewEngine(registry)
+
+	total, failed := 0, 0
+	for _, path := range files {
+		wf, err := readDefinition(path)
+		if err == nil {
+			if err = workflow.ValidateDefinition(wf, registry); err != nil {
+				err = fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(out, "FAIL %v\n", err)
+			total++
+			failed++
+			continue
+		}
+		if len(wf.Tests) == 0 {
+			fmt.Fprintf(out, "ok   %s (no tests)\n", path)
+			continue
+		}
+
+		report := engine.RunTests(ctx, wf, wf.Tests)
+		total += len(report.Results)
+		failed += report.Failed
+		for _, result := range report.Results {
+			if result.Passed {
+				fmt.Fprintf(out, "ok   %s: %s\n", path, result.Name)
+				continue
+			}
+			fmt.Fprintf(out, "FAIL %s: %s\n", path, result.Name)
+			for _, failure := range result.Failures {
+				fmt.Fprintf(out, "     %s\n", failure)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tests failed", failed, total)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Command workflowctl checks and runs workflow definitions kept in files, copies them to
+// and from a running API or its database, and migrates the database. Definitions are the
+// JSON that GET /api/v1/workflows/{id} returns, without the timestamps, so they can be
+// kept in git and linted in CI.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"workflow-code-test/api/pkg/db"
+	"workflow-code-test/api/pkg/migrate"
+)
+
+const usage = `usage: workflowctl <command> [flags] [arguments]
+
+Commands:
+  validate FILE...             check definitions without running them
+  run FILE -input FILE         execute a definition locally and print its results
+  test FILE...                 run the test cases kept in definitions, as dry runs
+  export ID [-o FILE]          write a stored workflow's definition to FILE or stdout
+  import FILE...               store definitions, replacing workflows with the same id
+` + migrate.Usage + `
+export and import use the API at -api (or WORKFLOW_API_URL) with -api-key (or
+WORKFLOW_API_KEY). Without an API URL they use the database at DATABASE_URL, as
+migrate does. Run "workflowctl COMMAND -h" for a command's flags.
+`
+
+func main() {
+	// Engine and executor logs would interleave with results on stdout
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err := run(ctx, os.Args[1:], os.Stdout)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+// run carries out the command in args, writing its report to out.
+func run(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "validate":
+		return runValidate(ctx, args[1:], out)
+	case "run":
+		return runExecute(ctx, args[1:], out)
+	case "test":
+		return runTests(ctx, args[1:], out)
+	case "export":
+		return runExport(ctx, args[1:], out)
+	case "import":
+		return runImport(ctx, args[1:], out)
+	case "migrate":
+		return runMigrate(ctx, args[1:], out)
+	case "help", "-h", "--help":
+		fmt.Fprint(out, usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], usage)
+	}
+}
+
+// parseArgs parses fs's flags wherever they appear in args, so that
+// "run wf.json -input in.json" works as well as "run -input in.json wf.json".
+// It returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func runMigrate(ctx context.Context, args []string, out io.Writer) error {
+	cmd, err := migrate.ParseCommand(args)
+	if err != nil {
+		return fmt.Errorf("%w\n\n%s", err, usage)
+	}
+
+	pool, err := connectFromEnv(ctx)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	migrator, err := db.NewMigrator(pool)
+	if err != nil {
+		return err
+	}
+	return cmd.Run(ctx, migrator, out)
+}
+
+// connectFromEnv connects to DATABASE_URL with the default pool settings, which suit a
+// short-lived tool.
+func connectFromEnv(ctx context.Context) (*pgxpool.Pool, error) {
+	cfg := db.DefaultConfig()
+	cfg.URI = os.Getenv("DATABASE_URL")
+	if cfg.URI == "" {
+		return nil, errors.New("DATABASE_URL is not set")
+	}
+	return db.Connect(ctx, cfg)
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workflow-code-test/api/services/workflow"
+)
+
+const (
+	sampleFile = "testdata/weather-alert.json"
+	inputFile  = "testdata/input.json"
+	sampleID   = "550e8400-e29b-41d4-a716-446655440000"
+)
+
+func writeFile(t *testing.T, name, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+	return path
+}
+
+func runCLI(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := run(context.Background(), args, &out)
+	return out.String(), err
+}
+
+func TestValidate_ReportsEachFile(t *testing.T) {
+	twoStarts := writeFile(t, "two-starts.json", `{"id": "x", "name": "x", "nodes": [{"id": "a", "type": "start"}, {"id": "b", "type": "start"}], "edges": []}`)
+	unknownType := writeFile(t, "unknown-type.json", `{"id": "x", "name": "x", "nodes": [{"id": "a", "type": "start"}, {"id": "b", "type": "sms"}], "edges": []}`)
+	typo := writeFile(t, "typo.json", `{"id": "x", "name": "x", "node": []}`)
+
+	out, err := runCLI(t, "validate", sampleFile, twoStarts, unknownType, typo)
+
+	require.EqualError(t, err, "3 of 4 definitions are invalid")
+	lines := bytes.Split(bytes.TrimSpace([]byte(out)), []byte("\n"))
+	require.Len(t, lines, 4)
+	assert.Equal(t, "ok   "+sampleFile, string(lines[0]))
+	assert.Equal(t, "FAIL "+twoStarts+": nodes is invalid", string(lines[1]))
+	assert.Equal(t, "FAIL "+unknownType+`: node "b" has unknown type "sms"`, string(lines[2]))
+	assert.Equal(t, "FAIL "+typo+`: json: unknown field "node"`, string(lines[3]))
+}
+
+func TestRun_StubWeatherSelectsTheBranch(t *testing.T) {
+	tests := []struct {
+		temperature string
+		want        []string
+	}{
+		{"30", []string{"start", "form", "weather-api", "condition", "email", "end"}},
+		{"20", []string{"start", "form", "weather-api", "condition", "end"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.temperature, func(t *testing.T) {
+			out, err := runCLI(t, "run", sampleFile, "-input", inputFile, "-temperature", tt.temperature)
+			require.NoError(t, err)
+
+			var results workflow.ExecutionResults
+			require.NoError(t, json.Unmarshal([]byte(out), &results))
+			assert.Equal(t, "completed", results.Status)
+			var path []string
+			for _, step := range results.Steps {
+				path = append(path, step.NodeID)
+			}
+			assert.Equal(t, tt.want, path)
+			assert.Equal(t, "celsius", results.Metadata["unit"])
+		})
+	}
+}
+
+func TestRun_DryRunWithMocks(t *testing.T) {
+	input := writeFile(t, "input.json", `{
+		"formData": {"name": "A", "email": "a@example.com", "city": "Sydney"},
+		"condition": {"operator": "greater_than", "threshold": 25},
+		"mode": "dry_run",
+		"mocks": {"weather-api": {"temperature": 40}}
+	}`)
+
+	out, err := runCLI(t, "run", sampleFile, "-input", input)
+	require.NoError(t, err)
+
+	var results workflow.ExecutionResults
+	require.NoError(t, json.Unmarshal([]byte(out), &results))
+	assert.Equal(t, "dry_run", results.Metadata["mode"])
+	require.Len(t, results.Steps, 6, "the mocked 40° beats the stub's 20°")
+	assert.True(t, results.Steps[2].Mocked)
+
+	unknown := writeFile(t, "unknown.json", `{"formData": {"name": "A", "email": "a@example.com", "city": "Sydney"}, "condition": {"operator": "greater_than", "threshold": 25}, "mocks": {"sms": {}}}`)
+	_, err = runCLI(t, "run", sampleFile, "-input", unknown)
+	assert.EqualError(t, err, unknown+`: mocks: node "sms" not found`)
+}
+
+func TestRun_RejectsBadArguments(t *testing.T) {
+	_, err := runCLI(t, "run", sampleFile)
+	assert.EqualError(t, err, "run: -input is required")
+
+	_, err = runCLI(t, "run", "-input", inputFile)
+	assert.EqualError(t, err, "run: give exactly one definition file")
+
+	badInput := writeFile(t, "input.json", `{"formData": {"name": "A", "email": "a@example.com", "city": "Sydney"}, "condition": {"operator": "hotter"}}`)
+	_, err = runCLI(t, "run", sampleFile, "-input", badInput)
+	assert.EqualError(t, err, badInput+": operator is invalid")
+}
+
+func TestTest_ReportsEachCase(t *testing.T) {
+	out, err := runCLI(t, "test", sampleFile)
+	require.NoError(t, err)
+	assert.Equal(t, "ok   "+sampleFile+": hot day sends an alert\nok   "+sampleFile+": mild day does not\n", out)
+
+	failing := writeFile(t, "failing.json", `{"id": "x", "name": "x",
+		"nodes": [{"id": "start", "type": "start"}, {"id": "form", "type": "form"}, {"id": "end", "type": "end"}],
+		"edges": [{"id": "e1", "source": "start", "target": "form"}, {"id": "e2", "source": "form", "target": "end"}],
+		"tests": [{"name": "skips the form",
+			"input": {"formData": {"name": "A", "email": "a@example.com", "city": "Sydney"}, "condition": {"operator": "greater_than", "threshold": 25}},
+			"expect": {"path": ["start", "end"], "variables": {"temperature": 30}}}]}`)
+	out, err = runCLI(t, "test", failing)
+	assert.EqualError(t, err, "1 of 1 tests failed")
+	assert.Equal(t, "FAIL "+failing+": skips the form\n"+
+		"     path: got start → form → end, want start → end\n"+
+		"     variables.temperature: missing, want 30\n", out)
+}
+
+// fakeAPI serves one workflow the way GET and PUT /api/v1/workflows/{id} do.
+func fakeAPI(t *testing.T, wf []byte) (*httptest.Server, *[]byte) {
+	var put []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "wk_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message": "invalid api key"}`))
+			return
+		}
+		if r.URL.Path != "/api/v1/workflows/"+sampleID {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "workflow not found"}`))
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			w.Write(wf)
+		case http.MethodPut:
+			put, _ = io.ReadAll(r.Body)
+			w.Write(put)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &put
+}
+
+func TestExport_WritesTheDefinitionWithoutTimestamps(t *testing.T) {
+	sample, err := os.ReadFile(sampleFile)
+	require.NoError(t, err)
+	var wf workflow.Workflow
+	require.NoError(t, json.Unmarshal(sample, &wf))
+	resp, err := json.Marshal(wf)
+	require.NoError(t, err)
+	srv, _ := fakeAPI(t, resp)
+	target := filepath.Join(t.TempDir(), "exported.json")
+
+	_, err = runCLI(t, "export", sampleID, "-api", srv.URL, "-api-key", "wk_test", "-o", target)
+	require.NoError(t, err)
+
+	exported, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, string(sample), string(exported), "export and the file form round-trip")
+
+	_, err = runCLI(t, "export", "00000000-0000-0000-0000-000000000000", "-api", srv.URL, "-api-key", "wk_test")
+	assert.EqualError(t, err, "GET /workflows/00000000-0000-0000-0000-000000000000: 404 workflow not found")
+}
+
+func TestImport_PutsEachDefinition(t *testing.T) {
+	srv, put := fakeAPI(t, nil)
+
+	out, err := runCLI(t, "import", sampleFile, "-api", srv.URL, "-api-key", "wk_test")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Imported "+sampleID+" from "+sampleFile+"\n", out)
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(*put, &body))
+	assert.Equal(t, "Weather Alert Workflow", body["name"])
+	assert.Len(t, body["nodes"], 6)
+
+	_, err = runCLI(t, "import", sampleFile, "-api", srv.URL, "-api-key", "wrong")
+	assert.EqualError(t, err, sampleFile+": PUT /workflows/"+sampleID+": 401 invalid api key")
+}
+
+func TestImport_ChecksEveryFileFirst(t *testing.T) {
+	srv, put := fakeAPI(t, nil)
+	noID := writeFile(t, "no-id.json", `{"name": "x", "nodes": [{"id": "a", "type": "start"}]}`)
+
+	_, err := runCLI(t, "import", sampleFile, noID, "-api", srv.URL, "-api-key", "wk_test")
+
+	assert.EqualError(t, err, noID+": id must be a UUID")
+	assert.Nil(t, *put, "nothing is imported when any file is invalid")
+}
+
+func TestCommand_Unknown(t *testing.T) {
+	_, err := runCLI(t, "deploy")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown command "deploy"`)
+
+	out, err := runCLI(t, "help")
+	require.NoError(t, err)
+	assert.Contains(t, out, "migrate status")
+}
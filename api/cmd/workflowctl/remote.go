@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"workflow-code-test/api/pkg/auth"
+	"workflow-code-test/api/services/workflow"
+)
+
+// store reads and writes workflow definitions kept by a running API or its database.
+type store interface {
+	Get(ctx context.Context, id string) (*workflow.Workflow, error)
+	Put(ctx context.Context, wf *workflow.Workflow) error
+	Close()
+}
+
+// storeFlags are the flags that choose the store export and import use.
+type storeFlags struct {
+	apiURL string
+	apiKey string
+	tenant string
+}
+
+func (f *storeFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.apiURL, "api", os.Getenv("WORKFLOW_API_URL"), "base URL of a running API, such as http://localhost:8080; without it DATABASE_URL is used")
+	fs.StringVar(&f.apiKey, "api-key", os.Getenv("WORKFLOW_API_KEY"), "API key sent to -api")
+	fs.StringVar(&f.tenant, "tenant", auth.DefaultTenant, "tenant whose workflows are read and written when using the database")
+}
+
+func (f *storeFlags) open(ctx context.Context) (store, error) {
+	if f.apiURL != "" {
+		return &apiStore{
+			baseURL: strings.TrimSuffix(f.apiURL, "/") + "/api/v1",
+			apiKey:  f.apiKey,
+			client:  &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	}
+	pool, err := connectFromEnv(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &dbStore{pool: pool, repo: workflow.NewRepository(pool), tenant: f.tenant}, nil
+}
+
+// apiStore talks to the workflow endpoints of a running API. The API only replaces
+// workflows that already exist.
+type apiStore struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+func (s *apiStore) Get(ctx context.Context, id string) (*workflow.Workflow, error) {
+	var wf workflow.Workflow
+	if err := s.do(ctx, http.MethodGet, "/workflows/"+url.PathEscape(id), nil, &wf); err != nil {
+		return nil, err
+	}
+	return &wf, nil
+}
+
+func (s *apiStore) Put(ctx context.Context, wf *workflow.Workflow) error {
+	// The API keeps a workflow's tests when they are omitted, but the file is the whole definition
+	tests := wf.Tests
+	if tests == nil {
+		tests = []workflow.TestCase{}
+	}
+	body := map[string]any{"name": wf.Name, "nodes": wf.Nodes, "edges": wf.Edges, "tests": tests}
+	return s.do(ctx, http.MethodPut, "/workflows/"+url.PathEscape(wf.ID), body, nil)
+}
+
+func (s *apiStore) Close() {}
+
+// do sends body as JSON and decodes a successful response into out, when both are set.
+// Failed requests report the API's error message.
+func (s *apiStore) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("X-API-Key", s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return fmt.Errorf("%s %s: %d %s", method, path, resp.StatusCode, apiErr.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// dbStore reads and writes one tenant's workflows in the database, creating workflows
+// that do not exist yet.
+type dbStore struct {
+	pool   *pgxpool.Pool
+	repo   *workflow.Repository
+	tenant string
+}
+
+func (s *dbStore) Get(ctx context.Context, id string) (*workflow.Workflow, error) {
+	wf, err := s.repo.Get(ctx, s.tenant, id)
+	if err != nil {
+		return nil, err
+	}
+	if wf == nil {
+		return nil, fmt.Errorf("workflow %s not found in tenant %q", id, s.tenant)
+	}
+	return wf, nil
+}
+
+func (s *dbStore) Put(ctx context.Context, wf *workflow.Workflow) error {
+	wf.TenantID = s.tenant
+	ok, err := s.repo.Import(ctx, wf)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("workflow %s belongs to another tenant", wf.ID)
+	}
+	return nil
+}
+
+func (s *dbStore) Close() {
+	s.pool.Close()
+}
+
+func runExport(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	var sf storeFlags
+	sf.register(fs)
+	output := fs.String("o", "", "file to write the definition to; stdout if empty")
+	ids, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(ids) != 1 {
+		return errors.New("export: give exactly one workflow id")
+	}
+
+	st, err := sf.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	wf, err := st.Get(ctx, ids[0])
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return writeDefinition(out, wf)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := writeDefinition(f, wf); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func runImport(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	var sf storeFlags
+	sf.register(fs)
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("import: no definition files given")
+	}
+
+	// Check every file first so that one bad definition stores nothing
+	workflows := make([]*workflow.Workflow, len(files))
+	for i, path := range files {
+		wf, err := readDefinition(path)
+		if err != nil {
+			return err
+		}
+		if _, err := uuid.Parse(wf.ID); err != nil {
+			return fmt.Errorf("%s: id must be a UUID", path)
+		}
+		// Plugin node types may be unknown here, so only the graph is checked
+		if err := workflow.ValidateDefinition(wf, nil); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		workflows[i] = wf
+	}
+
+	st, err := sf.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	for i, wf := range workflows {
+		if err := st.Put(ctx, wf); err != nil {
+			return fmt.Errorf("%s: %w", files[i], err)
+		}
+		fmt.Fprintf(out, "Imported %s from %s\n", wf.ID, files[i])
+	}
+	return nil
+}
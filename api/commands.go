@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"workflow-code-test/api/pkg/db"
+	"workflow-code-test/api/pkg/migrate"
+	"workflow-code-test/api/services/workflow"
+)
+
+const commandUsage = `usage: api [command]
+
+Without a command the API server starts. Commands connect to the database configured
+by DATABASE_URL or CONFIG_FILE and exit.
+
+Commands:
+` + migrate.Usage + `  seed               insert the sample weather workflow if it is missing
+`
+
+// runCommand runs a database management command, writing its report to out.
+func runCommand(ctx context.Context, args []string, out io.Writer) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(ctx, args[1:], out)
+	case "seed":
+		pool, err := connectFromEnv(ctx)
+		if err != nil {
+			return err
+		}
+		defer pool.Close()
+		if err := workflow.NewRepository(pool).Seed(ctx); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Seeded the sample workflow")
+		return nil
+	case "help", "-h", "--help":
+		fmt.Fprint(out, commandUsage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], commandUsage)
+	}
+}
+
+func runMigrate(ctx context.Context, args []string, out io.Writer) error {
+	cmd, err := migrate.ParseCommand(args)
+	if err != nil {
+		return fmt.Errorf("%w\n\n%s", err, commandUsage)
+	}
+
+	pool, err := connectFromEnv(ctx)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	migrator, err := db.NewMigrator(pool)
+	if err != nil {
+		return err
+	}
+	return cmd.Run(ctx, migrator, out)
+}
+
+func connectFromEnv(ctx context.Context) (*pgxpool.Pool, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return db.Connect(ctx, cfg.Database)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"workflow-code-test/api/pkg/db"
+	"workflow-code-test/api/pkg/resilience"
+	"workflow-code-test/api/services/workflow"
+)
+
+// config holds the server, database and weather provider settings. Each value comes from
+// the defaults, then the YAML file named by CONFIG_FILE, then the environment, each
+// overriding the one before.
+type config struct {
+	Server   serverConfig  `yaml:"server"`
+	Database db.Config     `yaml:"database"`
+	Weather  weatherConfig `yaml:"weather"`
+}
+
+type serverConfig struct {
+	ListenAddr         string        `yaml:"listenAddr"`
+	CORSOrigins        []string      `yaml:"corsOrigins"`        // Browser origins allowed to call the API; "*" allows any
+	ShutdownDrainDelay time.Duration `yaml:"shutdownDrainDelay"` // How long readiness fails before the server stops accepting connections
+}
+
+type weatherConfig struct {
+	URL             string                     `yaml:"url"`
+	GeocodingURL    string                     `yaml:"geocodingUrl"`
+	Timeout         time.Duration              `yaml:"timeout"`
+	GeocoderOffline bool                       `yaml:"geocoderOffline"`
+	GeocodeCacheTTL time.Duration              `yaml:"geocodeCacheTTL"`
+	Breaker         resilience.BreakerConfig   `yaml:"breaker"`
+	RateLimit       resilience.RateLimitConfig `yaml:"rateLimit"`
+}
+
+func defaultConfig() config {
+	wf := workflow.DefaultConfig()
+	return config{
+		Server: serverConfig{
+			ListenAddr:  ":8080",
+			CORSOrigins: []string{"http://localhost:3003"}, // The frontend's dev server
+		},
+		Database: db.DefaultConfig(),
+		Weather: weatherConfig{
+			URL:             wf.WeatherURL,
+			GeocodingURL:    wf.GeocodingURL,
+			Timeout:         wf.WeatherTimeout,
+			GeocoderOffline: wf.GeocoderOffline,
+			GeocodeCacheTTL: wf.GeocodeCacheTTL,
+			Breaker:         wf.WeatherBreaker,
+			RateLimit:       wf.WeatherRateLimit,
+		},
+	}
+}
+
+// loadConfig reads the settings and checks them, reporting every invalid one at once.
+func loadConfig() (config, error) {
+	cfg := defaultConfig()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return cfg, err
+		}
+	}
+	if err := cfg.readEnv(); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.validate()
+}
+
+// readFile overlays the settings in a YAML file. Keys it leaves out keep their current
+// values; unknown keys are rejected so that typos do not go unnoticed.
+func (c *config) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// envSetting binds an environment variable to the setting it overrides.
+type envSetting struct {
+	name string
+	set  func(v string) error
+}
+
+func (c *config) envSettings() []envSetting {
+	return []envSetting{
+		{"LISTEN_ADDR", setString(&c.Server.ListenAddr)},
+		{"CORS_ORIGINS", setList(&c.Server.CORSOrigins)},
+		{"SHUTDOWN_DRAIN_DELAY", setDuration(&c.Server.ShutdownDrainDelay)},
+
+		{"DATABASE_URL", setString(&c.Database.URI)},
+		{"DB_MAX_OPEN_CONNS", setInt(&c.Database.MaxOpenConns)},
+		{"DB_MAX_IDLE_CONNS", setInt(&c.Database.MaxIdleConns)},
+		{"DB_CONN_MAX_LIFETIME", setDuration(&c.Database.ConnMaxLifetime)},
+		{"DB_QUERY_TIMEOUT", setDuration(&c.Database.QueryTimeout)},
+
+		{"WEATHER_URL", setString(&c.Weather.URL)},
+		{"WEATHER_GEOCODING_URL", setString(&c.Weather.GeocodingURL)},
+		{"WEATHER_TIMEOUT", setDuration(&c.Weather.Timeout)},
+		{"WEATHER_GEOCODER_OFFLINE", setBool(&c.Weather.GeocoderOffline)},
+		{"WEATHER_GEOCODE_CACHE_TTL", setDuration(&c.Weather.GeocodeCacheTTL)},
+		{"WEATHER_BREAKER_FAILURE_THRESHOLD", setInt(&c.Weather.Breaker.FailureThreshold)},
+		{"WEATHER_BREAKER_OPEN_TIMEOUT", setDuration(&c.Weather.Breaker.OpenTimeout)},
+		{"WEATHER_RATE_LIMIT_RPS", setFloat(&c.Weather.RateLimit.RequestsPerSecond)},
+		{"WEATHER_RATE_LIMIT_BURST", setInt(&c.Weather.RateLimit.Burst)},
+		{"WEATHER_RATE_LIMIT_MAX_WAIT", setDuration(&c.Weather.RateLimit.MaxWait)},
+	}
+}
+
+// readEnv overlays the settings whose environment variables are set and not empty.
+func (c *config) readEnv() error {
+	var errs []error
+	for _, s := range c.envSettings() {
+		v := os.Getenv(s.name)
+		if v == "" {
+			continue
+		}
+		if err := s.set(v); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func setString(p *string) func(string) error {
+	return func(v string) error { *p = v; return nil }
+}
+
+// setList splits a comma-separated value, ignoring blank entries.
+func setList(p *[]string) func(string) error {
+	return func(v string) error {
+		*p = nil
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*p = append(*p, item)
+			}
+		}
+		return nil
+	}
+}
+
+func setInt(p *int) func(string) error {
+	return func(v string) (err error) { *p, err = strconv.Atoi(v); return err }
+}
+
+func setFloat(p *float64) func(string) error {
+	return func(v string) (err error) { *p, err = strconv.ParseFloat(v, 64); return err }
+}
+
+func setBool(p *bool) func(string) error {
+	return func(v string) (err error) { *p, err = strconv.ParseBool(v); return err }
+}
+
+func setDuration(p *time.Duration) func(string) error {
+	return func(v string) (err error) { *p, err = time.ParseDuration(v); return err }
+}
+
+// validate reports every setting that is missing or out of range, named by its YAML path.
+func (c config) validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	_, _, err := net.SplitHostPort(c.Server.ListenAddr)
+	check(err == nil, "server.listenAddr: %q is not a host:port address", c.Server.ListenAddr)
+	for _, origin := range c.Server.CORSOrigins {
+		check(origin == "*" || isOrigin(origin), "server.corsOrigins: %q is not an origin like https://example.com", origin)
+	}
+	check(c.Server.ShutdownDrainDelay >= 0, "server.shutdownDrainDelay: must not be negative")
+
+	check(c.Database.URI != "", "database.url: is required; set DATABASE_URL")
+	check(c.Database.MaxOpenConns >= 1, "database.maxOpenConns: must be at least 1")
+	check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.maxIdleConns: must be between 0 and database.maxOpenConns")
+	check(c.Database.ConnMaxLifetime >= 0, "database.connMaxLifetime: must not be negative")
+	check(c.Database.QueryTimeout >= 0, "database.queryTimeout: must not be negative")
+
+	check(isHTTPURL(c.Weather.URL), "weather.url: %q is not an http(s) URL", c.Weather.URL)
+	check(isHTTPURL(c.Weather.GeocodingURL), "weather.geocodingUrl: %q is not an http(s) URL", c.Weather.GeocodingURL)
+	check(c.Weather.Timeout > 0, "weather.timeout: must be positive")
+	check(c.Weather.GeocodeCacheTTL >= 0, "weather.geocodeCacheTTL: must not be negative")
+	check(c.Weather.Breaker.FailureThreshold >= 1, "weather.breaker.failureThreshold: must be at least 1")
+	check(c.Weather.Breaker.OpenTimeout > 0, "weather.breaker.openTimeout: must be positive")
+	check(c.Weather.Breaker.HalfOpenMaxCalls >= 1, "weather.breaker.halfOpenMaxCalls: must be at least 1")
+	check(c.Weather.Breaker.SuccessThreshold >= 1, "weather.breaker.successThreshold: must be at least 1")
+	check(c.Weather.RateLimit.RequestsPerSecond > 0, "weather.rateLimit.requestsPerSecond: must be positive")
+	check(c.Weather.RateLimit.Burst >= 1, "weather.rateLimit.burst: must be at least 1")
+	check(c.Weather.RateLimit.MaxWait >= 0, "weather.rateLimit.maxWait: must not be negative")
+
+	return errors.Join(errs...)
+}
+
+// applyTo copies the weather provider settings into the workflow service's config.
+func (w weatherConfig) applyTo(cfg *workflow.Config) {
+	cfg.WeatherURL = w.URL
+	cfg.GeocodingURL = w.GeocodingURL
+	cfg.WeatherTimeout = w.Timeout
+	cfg.GeocoderOffline = w.GeocoderOffline
+	cfg.GeocodeCacheTTL = w.GeocodeCacheTTL
+	cfg.WeatherBreaker = w.Breaker
+	cfg.WeatherRateLimit = w.RateLimit
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// isOrigin reports whether s is a scheme and host with no path, as browsers send in the
+// Origin header.
+func isOrigin(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && isHTTPURL(s) && u.Path == "" && u.RawQuery == "" && u.User == nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workflow-code-test/api/services/workflow"
+)
+
+func writeConfigFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+	return path
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://localhost/workflow")
+
+	cfg, err := loadConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, ":8080", cfg.Server.ListenAddr)
+	assert.Equal(t, []string{"http://localhost:3003"}, cfg.Server.CORSOrigins)
+	assert.Equal(t, "postgres://localhost/workflow", cfg.Database.URI)
+	assert.Equal(t, 10, cfg.Database.MaxOpenConns)
+	assert.Equal(t, 30*time.Second, cfg.Database.QueryTimeout)
+	assert.Equal(t, workflow.DefaultWeatherURL, cfg.Weather.URL)
+	assert.Equal(t, 10*time.Second, cfg.Weather.Timeout)
+}
+
+func TestLoadConfig_FileThenEnv(t *testing.T) {
+	t.Setenv("CONFIG_FILE", writeConfigFile(t, `
+server:
+  listenAddr: ":9090"
+  corsOrigins: ["https://app.example.com"]
+database:
+  url: postgres://file/workflow
+  maxOpenConns: 20
+  queryTimeout: 5s
+weather:
+  url: http://weather.internal/v1/forecast
+  rateLimit:
+    burst: 50
+`))
+	t.Setenv("DB_MAX_OPEN_CONNS", "40")
+	t.Setenv("CORS_ORIGINS", "https://a.example.com, https://b.example.com,")
+
+	cfg, err := loadConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, ":9090", cfg.Server.ListenAddr)
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, cfg.Server.CORSOrigins, "the environment overrides the file")
+	assert.Equal(t, "postgres://file/workflow", cfg.Database.URI)
+	assert.Equal(t, 40, cfg.Database.MaxOpenConns)
+	assert.Equal(t, 2, cfg.Database.MaxIdleConns, "keys the file leaves out keep their defaults")
+	assert.Equal(t, 5*time.Second, cfg.Database.QueryTimeout)
+	assert.Equal(t, "http://weather.internal/v1/forecast", cfg.Weather.URL)
+	assert.Equal(t, 50, cfg.Weather.RateLimit.Burst)
+	assert.Equal(t, 10.0, cfg.Weather.RateLimit.RequestsPerSecond)
+}
+
+func TestLoadConfig_ExampleFileHoldsTheDefaults(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "config.example.yaml")
+	t.Setenv("DATABASE_URL", "postgres://localhost/workflow")
+
+	cfg, err := loadConfig()
+	require.NoError(t, err)
+
+	want := defaultConfig()
+	want.Database.URI = "postgres://localhost/workflow"
+	assert.Equal(t, want, cfg)
+}
+
+func TestLoadConfig_RejectsUnknownKeys(t *testing.T) {
+	t.Setenv("CONFIG_FILE", writeConfigFile(t, "database:\n  maxOpenCons: 5\n"))
+
+	_, err := loadConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field maxOpenCons not found")
+}
+
+func TestLoadConfig_RejectsUnparsableEnv(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://localhost/workflow")
+	t.Setenv("DB_QUERY_TIMEOUT", "30")
+
+	_, err := loadConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DB_QUERY_TIMEOUT")
+}
+
+func TestConfig_ValidateReportsEverySetting(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Server.ListenAddr = "8080"
+	cfg.Server.CORSOrigins = []string{"*", "https://ok.example.com", "localhost:3003", "https://example.com/app"}
+	cfg.Database.MaxOpenConns = 2
+	cfg.Database.MaxIdleConns = 5
+	cfg.Weather.URL = "ftp://weather"
+	cfg.Weather.Timeout = 0
+
+	err := cfg.validate()
+
+	require.Error(t, err)
+	assert.Equal(t, `server.listenAddr: "8080" is not a host:port address
+server.corsOrigins: "localhost:3003" is not an origin like https://example.com
+server.corsOrigins: "https://example.com/app" is not an origin like https://example.com
+database.url: is required; set DATABASE_URL
+database.maxIdleConns: must be between 0 and database.maxOpenConns
+weather.url: "ftp://weather" is not an http(s) URL
+weather.timeout: must be positive`, err.Error())
+}
+
+func TestWeatherConfig_ApplyTo(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Weather.GeocodingURL = "http://geo.internal/search"
+	cfg.Weather.GeocoderOffline = true
+
+	wf := workflow.DefaultConfig()
+	cfg.Weather.applyTo(&wf)
+
+	assert.Equal(t, "http://geo.internal/search", wf.GeocodingURL)
+	assert.True(t, wf.GeocoderOffline)
+	assert.Equal(t, cfg.Weather.RateLimit, wf.WeatherRateLimit)
+}
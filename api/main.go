@@ -2,52 +2,166 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Schedules may name any IANA timezone; the container image has no zoneinfo
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
 
+	"workflow-code-test/api/pkg/auth"
 	"workflow-code-test/api/pkg/db"
+	"workflow-code-test/api/pkg/health"
+	"workflow-code-test/api/pkg/logging"
+	"workflow-code-test/api/pkg/metrics"
+	"workflow-code-test/api/pkg/openapi"
+	"workflow-code-test/api/pkg/secrets"
+	"workflow-code-test/api/pkg/tracing"
 	"workflow-code-test/api/services/workflow"
 )
 
 func main() {
 	ctx := context.Background()
+	startedAt := time.Now()
 	logHandler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 	})
 	slog.SetDefault(slog.New(logHandler))
 
-	dbURL, ok := os.LookupEnv("DATABASE_URL")
-	if !ok {
-		slog.Error("DATABASE_URL is not set")
+	// "migrate" and "seed" manage the database and exit instead of serving
+	if len(os.Args) > 1 {
+		if err := runCommand(ctx, os.Args[1:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		return
+	}
+
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = "workflow-api"
+	}
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{ServiceName: serviceName, Exporter: os.Getenv("OTEL_TRACES_EXPORTER")})
+	if err != nil {
+		slog.Error("Failed to set up tracing", "error", err)
 		return
 	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+	}()
 
-	pool, err := db.Connect(ctx, dbURL)
+	pool, err := db.Connect(ctx, cfg.Database)
 	if err != nil {
 		slog.Error("Failed to connect to database", "error", err)
 		return
 	}
 	defer pool.Close()
 
-	// Initialize database schema and seed data
-	if err := workflow.InitDB(ctx, pool); err != nil {
-		slog.Error("Failed to initialize database", "error", err)
+	migrator, err := db.NewMigrator(pool)
+	if err != nil {
+		slog.Error("Failed to load migrations", "error", err)
+		return
+	}
+	// Replicas starting together take turns; later ones find nothing to apply
+	if os.Getenv("MIGRATE_ON_START") != "false" {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			slog.Error("Failed to migrate database", "error", err)
+			return
+		}
+		for _, m := range applied {
+			slog.Info("Applied migration", "version", m.Version, "name", m.Name)
+		}
+	}
+	if os.Getenv("SEED_SAMPLE_DATA") == "true" {
+		if err := workflow.NewRepository(pool).Seed(ctx); err != nil {
+			slog.Error("Failed to seed sample data", "error", err)
+			return
+		}
+	}
+
+	keyStore := auth.NewPostgresKeyStore(pool)
+	bindingStore := auth.NewPostgresBindingStore(pool)
+	if key := os.Getenv("AUTH_BOOTSTRAP_KEY"); key != "" {
+		if err := auth.Bootstrap(ctx, keyStore, bindingStore, key, "bootstrap"); err != nil {
+			slog.Error("Failed to create bootstrap api key", "error", err)
+			return
+		}
+	}
+
+	authConfig, err := authConfigFromEnv()
+	if err != nil {
+		slog.Error("Invalid auth configuration", "error", err)
+		return
+	}
+	authenticator, err := auth.NewAuthenticator(keyStore, authConfig)
+	if err != nil {
+		slog.Error("Failed to create authenticator", "error", err)
 		return
 	}
+	if authConfig.Disabled {
+		slog.Warn("Authentication is disabled; do not expose this server beyond localhost")
+	}
+
+	metricsRegistry := metrics.NewRegistry()
+	metricsRegistry.MustRegister(metrics.NewPoolCollector(pool))
 
 	// setup router
 	mainRouter := mux.NewRouter()
+	mainRouter.Use(tracing.Middleware, logging.Middleware, metrics.NewHTTP(metricsRegistry).Middleware)
+	mainRouter.Handle(metrics.Path, metrics.Handler(metricsRegistry)).Methods("GET")
+	checker := health.NewChecker(2 * time.Second)
+	checker.LoadRoutes(mainRouter)
 
 	apiRouter := mainRouter.PathPrefix("/api/v1").Subrouter()
+	apiRouter.Use(authenticator.Middleware)
+	openapi.LoadRoutes(apiRouter)
+	authorizer := auth.NewAuthorizer(bindingStore)
+	auth.NewKeyHandler(keyStore, bindingStore, authorizer).LoadRoutes(apiRouter)
+	auth.NewBindingHandler(bindingStore, authorizer).LoadRoutes(apiRouter)
+
+	// Without a key, the secrets endpoints are absent and nodes referencing secrets fail
+	var secretResolver workflow.SecretResolver
+	secretsKey, err := secrets.LoadKey(os.Getenv("SECRETS_KEY"), os.Getenv("SECRETS_KEY_FILE"))
+	if err != nil {
+		slog.Error("Invalid secrets key", "error", err)
+		return
+	}
+	if secretsKey == nil {
+		slog.Warn("SECRETS_KEY is not set; secrets are disabled")
+	} else {
+		secretCipher, err := secrets.NewCipher(secretsKey)
+		if err != nil {
+			slog.Error("Invalid secrets key", "error", err)
+			return
+		}
+		secretManager := secrets.NewManager(secrets.NewPostgresStore(pool), secretCipher)
+		secrets.NewHandler(secretManager, authorizer).LoadRoutes(apiRouter)
+		secretResolver = secretManager
+	}
+
+	workflowConfig := workflow.DefaultConfig()
+	cfg.Weather.applyTo(&workflowConfig)
+	workflowConfig.PluginDir = os.Getenv("PLUGIN_DIR")
+	workflowConfig.Metrics = metricsRegistry
 
-	workflowService, err := workflow.NewService(pool)
+	workflowService, err := workflow.NewService(ctx, pool, workflowConfig, authorizer, secretResolver)
 	if err != nil {
 		slog.Error("Failed to create workflow service", "error", err)
 		return
@@ -55,23 +169,43 @@ func main() {
 
 	workflowService.LoadRoutes(apiRouter)
 
+	schedulerCtx, stopScheduler := context.WithCancel(ctx)
+	defer stopScheduler()
+	scheduler := workflow.NewScheduler(workflowService, workflow.NewAdvisoryLock(pool), workflowConfig.SchedulerInterval)
+	go scheduler.Run(schedulerCtx)
+	timer := workflow.NewTimerService(workflowService, workflowConfig.TimerInterval)
+	go timer.Run(schedulerCtx)
+
+	checker.Add("postgres", pool.Ping)
+	checker.Add("schema", migrator.Check)
+	checker.Add("scheduler", scheduler.Check)
+	checker.Add("timer", timer.Check)
+
+	info := health.Info{
+		Build:     health.ReadBuild(),
+		StartedAt: startedAt.UTC(),
+		Config:    debugConfig(cfg, pool, authConfig, workflowConfig, secretResolver != nil),
+		NodeTypes: workflowService.NodeTypes(),
+	}
+	mainRouter.Handle(health.InfoPath, authenticator.Middleware(authorizer.Require(auth.PermAdmin)(health.InfoHandler(info)))).Methods("GET")
+
 	corsHandler := handlers.CORS(
-		// Frontend URL
-		handlers.AllowedOrigins([]string{"http://localhost:3003"}),
+		handlers.AllowedOrigins(cfg.Server.CORSOrigins),
 		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),
+		handlers.AllowedHeaders([]string{"Content-Type", "Authorization", "Idempotency-Key", "traceparent", "tracestate", logging.RequestIDHeader}),
+		handlers.ExposedHeaders([]string{logging.RequestIDHeader}),
 		handlers.AllowCredentials(),
 	)(mainRouter)
 
 	srv := &http.Server{
-		Addr:    ":8080",
+		Addr:    cfg.Server.ListenAddr,
 		Handler: corsHandler,
 	}
 
 	serverErrors := make(chan error, 1)
 
 	go func() {
-		slog.Info("Starting server on :8080")
+		slog.Info("Starting server", "addr", cfg.Server.ListenAddr, "version", info.Build.Version)
 		serverErrors <- srv.ListenAndServe()
 	}()
 
@@ -84,6 +218,10 @@ func main() {
 
 	case sig := <-shutdown:
 		slog.Info("Shutdown signal received", "signal", sig)
+		// Fail readiness first so load balancers stop sending new requests
+		checker.Drain()
+		time.Sleep(cfg.Server.ShutdownDrainDelay)
+		stopScheduler()
 
 		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
@@ -94,3 +232,73 @@ func main() {
 		}
 	}
 }
+
+// debugConfig summarises the settings in effect for /debug/info. It leaves out every
+// credential: the database password, keys and JWT material.
+func debugConfig(cfg config, pool *pgxpool.Pool, authConfig auth.Config, workflowConfig workflow.Config, secretsEnabled bool) map[string]any {
+	conn := pool.Config().ConnConfig
+	exporter := os.Getenv("OTEL_TRACES_EXPORTER")
+	if exporter == "" {
+		exporter = tracing.ExporterNone
+	}
+	return map[string]any{
+		"listenAddr":  cfg.Server.ListenAddr,
+		"corsOrigins": cfg.Server.CORSOrigins,
+		"database": map[string]any{
+			"host":            conn.Host,
+			"port":            conn.Port,
+			"database":        conn.Database,
+			"user":            conn.User,
+			"maxConns":        pool.Config().MaxConns,
+			"minConns":        pool.Config().MinConns,
+			"connMaxLifetime": pool.Config().MaxConnLifetime.String(),
+			"queryTimeout":    cfg.Database.QueryTimeout.String(),
+		},
+		"weather": map[string]any{
+			"url":          workflowConfig.WeatherURL,
+			"geocodingUrl": workflowConfig.GeocodingURL,
+			"timeout":      workflowConfig.WeatherTimeout.String(),
+		},
+		"auth": map[string]any{
+			"disabled": authConfig.Disabled,
+			"jwt":      authConfig.JWT != nil,
+		},
+		"secretsEnabled":     secretsEnabled,
+		"tracesExporter":     exporter,
+		"pluginDir":          workflowConfig.PluginDir,
+		"geocoderOffline":    workflowConfig.GeocoderOffline,
+		"geocodeCacheTTL":    workflowConfig.GeocodeCacheTTL.String(),
+		"schedulerInterval":  workflowConfig.SchedulerInterval.String(),
+		"timerInterval":      workflowConfig.TimerInterval.String(),
+		"delayInlineMax":     workflowConfig.DelayInlineMax.String(),
+		"idempotencyTTL":     workflowConfig.IdempotencyTTL.String(),
+		"shutdownDrainDelay": cfg.Server.ShutdownDrainDelay.String(),
+	}
+}
+
+// authConfigFromEnv reads the authentication settings. Webhooks authenticate with
+// their own signatures and stay public.
+func authConfigFromEnv() (auth.Config, error) {
+	cfg := auth.Config{
+		Disabled:    os.Getenv("AUTH_DISABLED") == "true",
+		PublicPaths: []string{"/api/v1/hooks/", "/api/v1" + openapi.Path},
+	}
+
+	jwtConfig := auth.JWTConfig{
+		JWKSURL:  os.Getenv("AUTH_JWKS_URL"),
+		Issuer:   os.Getenv("AUTH_JWT_ISSUER"),
+		Audience: os.Getenv("AUTH_JWT_AUDIENCE"),
+		Leeway:   30 * time.Second,
+	}
+	if path := os.Getenv("AUTH_JWT_PUBLIC_KEY_FILE"); path != "" {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("read jwt public key: %w", err)
+		}
+		jwtConfig.PublicKeyPEM = pem
+	}
+	if jwtConfig.JWKSURL != "" || len(jwtConfig.PublicKeyPEM) > 0 {
+		cfg.JWT = &jwtConfig
+	}
+	return cfg, nil
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix starts every API key, so keys are recognisable in logs and secret scanners.
+const APIKeyPrefix = "wfk_"
+
+const (
+	keyIDLength     = 8  // Random bytes in the public lookup ID, hex encoded
+	keySecretLength = 32 // Random bytes in the secret part
+)
+
+// APIKey is a stored API key. Only a SHA-256 hash of the key is kept; the plaintext
+// is returned once, when the key is created.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // Public part of the key, e.g. "wfk_1a2b3c4d5e6f7a8b"
+	Tenant     string     `json:"tenant"`
+	Hash       string     `json:"-"`
+	CreatedBy  string     `json:"createdBy,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// KeyStore persists API keys. Listing and revoking are limited to one tenant's keys.
+type KeyStore interface {
+	CreateKey(ctx context.Context, key *APIKey) error
+	ListKeys(ctx context.Context, tenant string) ([]APIKey, error)
+	// FindKeyByPrefix returns the key with the given public prefix. Returns nil, nil if not found.
+	FindKeyByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	// RevokeKey marks a key revoked. Returns false if it does not exist in tenant.
+	RevokeKey(ctx context.Context, tenant, id string, at time.Time) (bool, error)
+	TouchKey(ctx context.Context, id string, at time.Time) error
+}
+
+// GenerateAPIKey returns a new plaintext key of the form "wfk_<id>_<secret>" and its public prefix.
+func GenerateAPIKey() (plaintext, prefix string, err error) {
+	id := make([]byte, keyIDLength)
+	secret := make([]byte, keySecretLength)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", fmt.Errorf("generate key id: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("generate key secret: %w", err)
+	}
+	prefix = APIKeyPrefix + hex.EncodeToString(id)
+	return prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), prefix, nil
+}
+
+// HashAPIKey returns the hex SHA-256 of a plaintext key. Keys carry 256 bits of
+// randomness, so a fast hash is sufficient.
+func HashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyPrefix extracts the public prefix from a plaintext key.
+func apiKeyPrefix(plaintext string) (string, bool) {
+	n := len(APIKeyPrefix) + 2*keyIDLength
+	if !strings.HasPrefix(plaintext, APIKeyPrefix) || len(plaintext) <= n+1 || plaintext[n] != '_' {
+		return "", false
+	}
+	return plaintext[:n], true
+}
@@ -0,0 +1,304 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"workflow-code-test/api/pkg/logging"
+)
+
+// minBootstrapSecret is the shortest secret part accepted for an operator-supplied key.
+const minBootstrapSecret = 32
+
+// KeyHandler serves the API key management endpoints. They require the admin role and
+// manage the caller's tenant's keys.
+type KeyHandler struct {
+	keys     KeyStore
+	bindings BindingStore
+	authz    *Authorizer
+}
+
+// NewKeyHandler creates the key management handlers.
+func NewKeyHandler(keys KeyStore, bindings BindingStore, authz *Authorizer) *KeyHandler {
+	return &KeyHandler{keys: keys, bindings: bindings, authz: authz}
+}
+
+// createKeyRequest is the JSON body for creating an API key. Roles are granted to the
+// key globally; per-workflow roles are added with role bindings. Tenant defaults to the
+// caller's; only DefaultTenant admins may issue keys for another tenant.
+type createKeyRequest struct {
+	Name   string   `json:"name"`
+	Tenant string   `json:"tenant"`
+	Roles  []string `json:"roles"`
+}
+
+// createKeyResponse returns a new key. Key holds the plaintext and is never shown again.
+type createKeyResponse struct {
+	APIKey
+	Subject string `json:"subject"`
+	Roles   []Role `json:"roles"`
+	Key     string `json:"key"`
+}
+
+// LoadRoutes registers the key endpoints under /api-keys.
+func (h *KeyHandler) LoadRoutes(parentRouter *mux.Router) {
+	router := parentRouter.PathPrefix("/api-keys").Subrouter()
+	router.Use(h.authz.Require(PermAdmin))
+	router.HandleFunc("", h.HandleListKeys).Methods("GET")
+	router.HandleFunc("", h.HandleCreateKey).Methods("POST")
+	router.HandleFunc("/{keyId}", h.HandleRevokeKey).Methods("DELETE")
+}
+
+// HandleListKeys returns all keys without their hashes.
+func (h *KeyHandler) HandleListKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.keys.ListKeys(r.Context(), Tenant(r.Context()))
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to list api keys", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"apiKeys": keys})
+}
+
+// HandleCreateKey issues a new key and returns its plaintext once.
+func (h *KeyHandler) HandleCreateKey(w http.ResponseWriter, r *http.Request) {
+	var req createKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	roles := make([]Role, 0, len(req.Roles))
+	for _, r := range req.Roles {
+		role, ok := ParseRole(r)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "role is invalid")
+			return
+		}
+		roles = append(roles, role)
+	}
+
+	callerTenant := Tenant(r.Context())
+	tenant := strings.TrimSpace(req.Tenant)
+	if tenant == "" {
+		tenant = callerTenant
+	}
+	if !ValidTenant(tenant) {
+		writeError(w, http.StatusBadRequest, "tenant is invalid")
+		return
+	}
+	if tenant != callerTenant && callerTenant != DefaultTenant {
+		writeError(w, http.StatusForbidden, "keys for other tenants can only be created by default tenant admins")
+		return
+	}
+
+	createdBy := ""
+	if p, ok := FromContext(r.Context()); ok {
+		createdBy = p.Subject
+	}
+	key, plaintext, err := CreateKey(r.Context(), h.keys, req.Name, tenant, createdBy)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to create api key", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	subject := KeySubject(key.ID)
+	granted := make([]string, 0, len(roles))
+	for _, role := range roles {
+		binding := &RoleBinding{ID: uuid.New().String(), Tenant: tenant, Subject: subject, Role: role}
+		if err := h.bindings.CreateBinding(r.Context(), binding); err != nil {
+			logging.FromContext(r.Context()).Error("Failed to grant api key role", "keyId", key.ID, "error", err)
+			h.abandonKey(context.WithoutCancel(r.Context()), key, granted)
+			writeError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		granted = append(granted, binding.ID)
+	}
+	logging.FromContext(r.Context()).Info("Created api key", "keyId", key.ID, "name", key.Name, "tenant", tenant, "roles", roles, "createdBy", createdBy)
+	writeJSON(w, http.StatusCreated, createKeyResponse{APIKey: *key, Subject: subject, Roles: roles, Key: plaintext})
+}
+
+// abandonKey revokes a key whose roles could not all be granted, and removes the role
+// bindings that were, so that no usable key is left with fewer roles than asked for.
+func (h *KeyHandler) abandonKey(ctx context.Context, key *APIKey, bindingIDs []string) {
+	if _, err := h.keys.RevokeKey(ctx, key.Tenant, key.ID, time.Now()); err != nil {
+		logging.FromContext(ctx).Error("Failed to revoke partially created api key", "keyId", key.ID, "error", err)
+	}
+	for _, id := range bindingIDs {
+		if _, err := h.bindings.DeleteBinding(ctx, key.Tenant, id); err != nil {
+			logging.FromContext(ctx).Error("Failed to remove partially created api key role", "keyId", key.ID, "bindingId", id, "error", err)
+		}
+	}
+}
+
+// HandleRevokeKey revokes a key. Requests using it are rejected immediately.
+func (h *KeyHandler) HandleRevokeKey(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["keyId"]
+	if _, err := uuid.Parse(id); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid key id")
+		return
+	}
+
+	found, err := h.keys.RevokeKey(r.Context(), Tenant(r.Context()), id, time.Now())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to revoke api key", "keyId", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "api key not found")
+		return
+	}
+	logging.FromContext(r.Context()).Info("Revoked api key", "keyId", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateKey generates and stores a new key for tenant, returning it with its plaintext.
+func CreateKey(ctx context.Context, keys KeyStore, name, tenant, createdBy string) (*APIKey, string, error) {
+	plaintext, prefix, err := GenerateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+	key := &APIKey{ID: uuid.New().String(), Name: name, Prefix: prefix, Hash: HashAPIKey(plaintext), Tenant: tenant, CreatedBy: createdBy}
+	if err := keys.CreateKey(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, plaintext, nil
+}
+
+// Bootstrap stores plaintext as a DefaultTenant admin key named name unless it already
+// exists, so that the first administrator can authenticate before any key has been issued.
+func Bootstrap(ctx context.Context, keys KeyStore, bindings BindingStore, plaintext, name string) error {
+	prefix, ok := apiKeyPrefix(plaintext)
+	if !ok || len(plaintext)-len(prefix)-1 < minBootstrapSecret {
+		return fmt.Errorf("bootstrap key must have the form %s<16 hex chars>_<at least %d secret chars>", APIKeyPrefix, minBootstrapSecret)
+	}
+	existing, err := keys.FindKeyByPrefix(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+	key := &APIKey{ID: uuid.New().String(), Name: name, Prefix: prefix, Hash: HashAPIKey(plaintext), Tenant: DefaultTenant, CreatedBy: "bootstrap"}
+	if err := keys.CreateKey(ctx, key); err != nil {
+		return err
+	}
+	if err := bindings.CreateBinding(ctx, &RoleBinding{ID: uuid.New().String(), Tenant: DefaultTenant, Subject: KeySubject(key.ID), Role: RoleAdmin}); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("Created bootstrap api key", "keyId", key.ID)
+	return nil
+}
+
+// BindingHandler serves the role binding management endpoints. They require the admin role
+// and manage the caller's tenant's bindings.
+type BindingHandler struct {
+	bindings BindingStore
+	authz    *Authorizer
+}
+
+// NewBindingHandler creates the role binding handlers.
+func NewBindingHandler(bindings BindingStore, authz *Authorizer) *BindingHandler {
+	return &BindingHandler{bindings: bindings, authz: authz}
+}
+
+// bindingRequest is the JSON body for creating a role binding.
+type bindingRequest struct {
+	Subject    string `json:"subject"`
+	Role       string `json:"role"`
+	WorkflowID string `json:"workflowId"` // Empty grants the role on every workflow
+}
+
+// LoadRoutes registers the binding endpoints under /role-bindings.
+func (h *BindingHandler) LoadRoutes(parentRouter *mux.Router) {
+	router := parentRouter.PathPrefix("/role-bindings").Subrouter()
+	router.Use(h.authz.Require(PermAdmin))
+	router.HandleFunc("", h.HandleListBindings).Methods("GET")
+	router.HandleFunc("", h.HandleCreateBinding).Methods("POST")
+	router.HandleFunc("/{bindingId}", h.HandleDeleteBinding).Methods("DELETE")
+}
+
+// HandleListBindings returns role bindings, optionally filtered by ?subject=.
+func (h *BindingHandler) HandleListBindings(w http.ResponseWriter, r *http.Request) {
+	bindings, err := h.bindings.ListBindings(r.Context(), Tenant(r.Context()), r.URL.Query().Get("subject"))
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to list role bindings", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"roleBindings": bindings})
+}
+
+// HandleCreateBinding grants a role to a subject.
+func (h *BindingHandler) HandleCreateBinding(w http.ResponseWriter, r *http.Request) {
+	var req bindingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Subject = strings.TrimSpace(req.Subject)
+	if req.Subject == "" {
+		writeError(w, http.StatusBadRequest, "subject is required")
+		return
+	}
+	role, ok := ParseRole(req.Role)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "role is invalid")
+		return
+	}
+	if req.WorkflowID != "" {
+		if _, err := uuid.Parse(req.WorkflowID); err != nil {
+			writeError(w, http.StatusBadRequest, "workflowId is invalid")
+			return
+		}
+	}
+
+	b := &RoleBinding{ID: uuid.New().String(), Tenant: Tenant(r.Context()), Subject: req.Subject, Role: role, WorkflowID: req.WorkflowID}
+	if err := h.bindings.CreateBinding(r.Context(), b); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to create role binding", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	logging.FromContext(r.Context()).Info("Granted role", "subject", b.Subject, "role", b.Role, "workflowId", b.WorkflowID)
+	writeJSON(w, http.StatusCreated, b)
+}
+
+// HandleDeleteBinding removes a role binding.
+func (h *BindingHandler) HandleDeleteBinding(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["bindingId"]
+	if _, err := uuid.Parse(id); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid binding id")
+		return
+	}
+
+	found, err := h.bindings.DeleteBinding(r.Context(), Tenant(r.Context()), id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to delete role binding", "bindingId", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "role binding not found")
+		return
+	}
+	logging.FromContext(r.Context()).Info("Removed role binding", "bindingId", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	jwksRefreshInterval = time.Hour
+	// jwksMinRefresh is the least time between fetch attempts, failed or not, so tokens
+	// with made-up "kid" headers cannot hammer the JWKS endpoint, nor can any token
+	// while it is down.
+	jwksMinRefresh = time.Minute
+)
+
+// ErrUnknownKey is returned when no JWKS key matches a token's "kid".
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// JWKS fetches and caches the public keys published at a JSON Web Key Set URL.
+// Keys are refreshed hourly, and early when a token names a key ID not yet seen.
+// Fetches happen outside the lock and concurrent callers share one, so a slow JWKS
+// endpoint only delays requests that cannot be verified without it. Attempts are at
+// least jwksMinRefresh apart, whether or not they succeed.
+type JWKS struct {
+	url    string
+	client *http.Client
+	now    func() time.Time
+
+	mu          sync.Mutex // Guards the fields below
+	keys        map[string]any
+	fetchedAt   time.Time  // Of the keys
+	lastAttempt time.Time  // Of the latest fetch, whether or not it succeeded
+	lastErr     error      // Of the latest fetch
+	inFlight    *jwksFetch // The fetch under way, if any
+}
+
+// jwksFetch is one fetch of the key set. keys and err are set before done is closed.
+type jwksFetch struct {
+	done chan struct{}
+	keys map[string]any
+	err  error
+}
+
+// NewJWKS creates a key set for url. Keys are fetched on first use.
+func NewJWKS(url string) *JWKS {
+	return &JWKS{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}
+}
+
+// Key returns the public key with the given ID. An empty kid matches the only key in
+// a single-key set. A key already held is returned straight away, refreshing the set in
+// the background if it is stale; otherwise Key waits for a refresh.
+func (j *JWKS) Key(ctx context.Context, kid string) (any, error) {
+	j.mu.Lock()
+	keys, lastErr := j.keys, j.lastErr
+	key, known := lookupKey(keys, kid)
+	var fetch *jwksFetch
+	if !known || j.now().Sub(j.fetchedAt) >= jwksRefreshInterval {
+		fetch = j.startFetch(ctx)
+	}
+	j.mu.Unlock()
+
+	if known {
+		return key, nil
+	}
+	if fetch == nil {
+		if keys == nil && lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, ErrUnknownKey
+	}
+
+	select {
+	case <-fetch.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if fetch.err != nil {
+		if keys == nil {
+			return nil, fetch.err
+		}
+		return nil, ErrUnknownKey // Keep serving the keys we have
+	}
+	if key, ok := lookupKey(fetch.keys, kid); ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// startFetch returns the fetch under way or, if none is and jwksMinRefresh has passed
+// since the last attempt, starts one. It returns nil otherwise. j.mu must be held.
+// The fetch outlives ctx so that callers giving up do not waste it.
+func (j *JWKS) startFetch(ctx context.Context) *jwksFetch {
+	if j.inFlight != nil {
+		return j.inFlight
+	}
+	now := j.now()
+	if now.Sub(j.lastAttempt) < jwksMinRefresh {
+		return nil
+	}
+	fetch := &jwksFetch{done: make(chan struct{})}
+	j.inFlight, j.lastAttempt = fetch, now
+
+	go func() {
+		keys, err := j.fetch(context.WithoutCancel(ctx))
+		j.mu.Lock()
+		fetch.keys, fetch.err = keys, err
+		j.lastErr = err
+		if err == nil {
+			j.keys, j.fetchedAt = keys, now
+		}
+		j.inFlight = nil
+		j.mu.Unlock()
+		close(fetch.done)
+	}()
+	return fetch
+}
+
+// lookupKey finds kid in keys. An empty kid matches the only key in a single-key set.
+func lookupKey(keys map[string]any, kid string) (any, bool) {
+	if key, ok := keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+func (j *JWKS) fetch(ctx context.Context) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create jwks request: %w", err)
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue // Skip key types we cannot use rather than failing the whole set
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+// jsonWebKey is a public key in RFC 7517 form.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, fmt.Errorf("rsa exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, fmt.Errorf("invalid ec key coordinates")
+		}
+		// Validates that the point is on the curve
+		return ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// asymmetricMethods are the signing algorithms accepted for bearer tokens. Shared-secret
+// (HS*) tokens are rejected so a public key can never be used as an HMAC secret.
+var asymmetricMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// JWTConfig selects how bearer tokens are verified. Exactly one of JWKSURL and
+// PublicKeyPEM should be set.
+type JWTConfig struct {
+	JWKSURL      string // URL of a JSON Web Key Set, e.g. an identity provider's jwks_uri
+	PublicKeyPEM []byte // Static PEM-encoded RSA, ECDSA or Ed25519 public key
+	Issuer       string // Required "iss" claim, if set
+	Audience     string // Required "aud" claim, if set
+	Leeway       time.Duration
+}
+
+// JWTVerifier validates bearer tokens and maps their claims to a Principal.
+type JWTVerifier struct {
+	key    func(ctx context.Context, kid string) (any, error)
+	parser *jwt.Parser
+}
+
+// NewJWTVerifier creates a verifier from cfg.
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	var key func(ctx context.Context, kid string) (any, error)
+	switch {
+	case cfg.JWKSURL != "" && len(cfg.PublicKeyPEM) > 0:
+		return nil, errors.New("set either a JWKS URL or a public key, not both")
+	case cfg.JWKSURL != "":
+		key = NewJWKS(cfg.JWKSURL).Key
+	case len(cfg.PublicKeyPEM) > 0:
+		pub, err := parsePublicKeyPEM(cfg.PublicKeyPEM)
+		if err != nil {
+			return nil, err
+		}
+		key = func(context.Context, string) (any, error) { return pub, nil }
+	default:
+		return nil, errors.New("a JWKS URL or public key is required")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(asymmetricMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	return &JWTVerifier{key: key, parser: jwt.NewParser(opts...)}, nil
+}
+
+// tokenClaims are the claims read from a bearer token. Unknown role names are ignored;
+// tokens without a tenant claim belong to DefaultTenant.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Name   string   `json:"name,omitempty"`
+	Email  string   `json:"email,omitempty"`
+	Roles  []string `json:"roles,omitempty"`
+	Tenant string   `json:"tenant,omitempty"`
+}
+
+// Verify checks the token's signature and claims and returns its principal.
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	var claims tokenClaims
+	_, err := v.parser.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+	tenant := claims.Tenant
+	if tenant == "" {
+		tenant = DefaultTenant
+	} else if !ValidTenant(tenant) {
+		return nil, errors.New("token has an invalid tenant")
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.Email
+	}
+	if name == "" {
+		name = claims.Subject
+	}
+	var roles []Role
+	for _, r := range claims.Roles {
+		if role, ok := ParseRole(r); ok {
+			roles = append(roles, role)
+		}
+	}
+	return &Principal{Subject: claims.Subject, Name: name, Method: MethodJWT, Tenant: tenant, Roles: roles}, nil
+}
+
+func parsePublicKeyPEM(data []byte) (any, error) {
+	if !strings.Contains(string(data), "PUBLIC KEY") {
+		return nil, errors.New("public key must be PEM encoded")
+	}
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported public key")
+}
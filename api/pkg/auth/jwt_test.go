@@ -0,0 +1,273 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func claims(sub string, exp time.Duration) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   sub,
+		"name":  "Dana Ops",
+		"iss":   "https://id.example.com",
+		"aud":   "workflow-api",
+		"exp":   time.Now().Add(exp).Unix(),
+		"roles": []string{"runner", "superuser"},
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, c jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, c)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+func TestJWTVerifier_StaticKey(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	require.NoError(t, err)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	v, err := NewJWTVerifier(JWTConfig{PublicKeyPEM: pemKey, Issuer: "https://id.example.com", Audience: "workflow-api"})
+	require.NoError(t, err)
+
+	p, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, priv, "", claims("user-42", time.Hour)))
+	require.NoError(t, err)
+	assert.Equal(t, "user-42", p.Subject)
+	assert.Equal(t, "Dana Ops", p.Name)
+	assert.Equal(t, MethodJWT, p.Method)
+	assert.Equal(t, []Role{RoleRunner}, p.Roles)
+	assert.Equal(t, DefaultTenant, p.Tenant)
+
+	tenanted := claims("user-42", time.Hour)
+	tenanted["tenant"] = "payments"
+	p, err = v.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, priv, "", tenanted))
+	require.NoError(t, err)
+	assert.Equal(t, "payments", p.Tenant)
+
+	badTenant := claims("user-42", time.Hour)
+	badTenant["tenant"] = "Payments Team"
+	_, err = v.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, priv, "", badTenant))
+	assert.Error(t, err)
+
+	expired := sign(t, jwt.SigningMethodRS256, priv, "", claims("user-42", -time.Hour))
+	_, err = v.Verify(context.Background(), expired)
+	assert.Error(t, err)
+
+	wrongIssuer := claims("user-42", time.Hour)
+	wrongIssuer["iss"] = "https://evil.example.com"
+	_, err = v.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, priv, "", wrongIssuer))
+	assert.Error(t, err)
+
+	noExpiry := claims("user-42", time.Hour)
+	delete(noExpiry, "exp")
+	_, err = v.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, priv, "", noExpiry))
+	assert.Error(t, err)
+
+	// A token signed with the public key as an HMAC secret must not verify
+	_, err = v.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, pemKey, "", claims("user-42", time.Hour)))
+	assert.Error(t, err)
+}
+
+func TestJWTVerifier_JWKS(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var fetches atomic.Int32
+	var rotated atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		ecPub, _ := priv.PublicKey.ECDH()
+		point := ecPub.Bytes() // 0x04 || X || Y
+		keys := []map[string]string{{
+			"kty": "EC", "kid": "ec-1", "use": "sig", "crv": "P-256",
+			"x": base64.RawURLEncoding.EncodeToString(point[1:33]),
+			"y": base64.RawURLEncoding.EncodeToString(point[33:]),
+		}}
+		if rotated.Load() {
+			keys = append(keys, map[string]string{
+				"kty": "RSA", "kid": "rsa-2",
+				"n": base64.RawURLEncoding.EncodeToString(rsaPriv.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaPriv.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	}))
+	defer srv.Close()
+
+	v, err := NewJWTVerifier(JWTConfig{JWKSURL: srv.URL})
+	require.NoError(t, err)
+	jwks := NewJWKS(srv.URL)
+	now := time.Now()
+	jwks.now = func() time.Time { return now }
+	v.key = jwks.Key
+
+	p, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodES256, priv, "ec-1", claims("user-7", time.Hour)))
+	require.NoError(t, err)
+	assert.Equal(t, "user-7", p.Subject)
+
+	// Unknown key IDs do not refetch more than once a minute
+	rotated.Store(true)
+	rsaToken := sign(t, jwt.SigningMethodRS256, rsaPriv, "rsa-2", claims("user-7", time.Hour))
+	_, err = v.Verify(context.Background(), rsaToken)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// After a minute the rotated key is picked up
+	now = now.Add(2 * time.Minute)
+	_, err = v.Verify(context.Background(), rsaToken)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestJWKS_SlowRefreshDoesNotBlockKnownKeys(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecPub, _ := priv.PublicKey.ECDH()
+	point := ecPub.Bytes() // 0x04 || X || Y
+	jwk := func(kid string) map[string]string {
+		return map[string]string{
+			"kty": "EC", "kid": kid, "crv": "P-256",
+			"x": base64.RawURLEncoding.EncodeToString(point[1:33]),
+			"y": base64.RawURLEncoding.EncodeToString(point[33:]),
+		}
+	}
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys := []map[string]string{jwk("ec-1")}
+		if fetches.Add(1) > 1 {
+			<-release
+			keys = append(keys, jwk("ec-2"))
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	jwks := NewJWKS(srv.URL)
+	start := time.Now()
+	jwks.now = func() time.Time { return start }
+	_, err = jwks.Key(context.Background(), "ec-1")
+	require.NoError(t, err)
+
+	// Once stale, a known key is served while the set refreshes in the background
+	jwks.now = func() time.Time { return start.Add(2 * time.Hour) }
+	_, err = jwks.Key(context.Background(), "ec-1")
+	require.NoError(t, err)
+
+	// An unknown key waits for that refresh rather than starting another
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = jwks.Key(ctx, "ec-2")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release <- struct{}{}
+	_, err = jwks.Key(context.Background(), "ec-2")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestJWKS_FailingEndpointIsFetchedOncePerInterval(t *testing.T) {
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	jwks := NewJWKS(srv.URL)
+	now := time.Now()
+	jwks.now = func() time.Time { return now }
+
+	for _, kid := range []string{"", "made-up-1", "made-up-2"} {
+		_, err := jwks.Key(context.Background(), kid)
+		assert.EqualError(t, err, "fetch jwks: status 503")
+	}
+	assert.Equal(t, int32(1), fetches.Load())
+
+	now = now.Add(2 * time.Minute)
+	jwks.Key(context.Background(), "made-up-3")
+	jwks.Key(context.Background(), "made-up-4")
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestJWKS_StaleKeysAreRefetchedOncePerIntervalWhileFailing(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecPub, _ := priv.PublicKey.ECDH()
+	point := ecPub.Bytes() // 0x04 || X || Y
+
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "EC", "kid": "ec-1", "crv": "P-256",
+			"x": base64.RawURLEncoding.EncodeToString(point[1:33]),
+			"y": base64.RawURLEncoding.EncodeToString(point[33:]),
+		}}})
+	}))
+	defer srv.Close()
+
+	jwks := NewJWKS(srv.URL)
+	start := time.Now()
+	jwks.now = func() time.Time { return start }
+	_, err = jwks.Key(context.Background(), "ec-1")
+	require.NoError(t, err)
+
+	// Stale keys keep being served; the failed background refresh is not retried at once
+	stale := start.Add(2 * time.Hour)
+	jwks.now = func() time.Time { return stale }
+	_, err = jwks.Key(context.Background(), "ec-1")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		jwks.mu.Lock()
+		defer jwks.mu.Unlock()
+		return jwks.lastErr != nil
+	}, time.Second, 5*time.Millisecond)
+	for range 3 {
+		_, err = jwks.Key(context.Background(), "ec-1")
+		require.NoError(t, err)
+		_, err = jwks.Key(context.Background(), "made-up")
+		assert.ErrorIs(t, err, ErrUnknownKey)
+	}
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestNewJWTVerifier_Config(t *testing.T) {
+	_, err := NewJWTVerifier(JWTConfig{})
+	assert.Error(t, err)
+	_, err = NewJWTVerifier(JWTConfig{JWKSURL: "https://id.example.com/jwks", PublicKeyPEM: []byte("x")})
+	assert.Error(t, err)
+	_, err = NewJWTVerifier(JWTConfig{PublicKeyPEM: []byte("not pem")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"workflow-code-test/api/pkg/logging"
+)
+
+// touchInterval limits how often a key's last-used time is written.
+const touchInterval = time.Minute
+
+var (
+	// ErrNoCredentials is returned when a request carries no API key or bearer token.
+	ErrNoCredentials = errors.New("authentication required")
+	// ErrInvalidCredentials is returned for unknown, revoked or malformed credentials.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Config configures an Authenticator.
+type Config struct {
+	// Disabled lets every request through as an anonymous admin. For local development only.
+	Disabled bool
+	// JWT enables bearer token authentication; nil accepts API keys only.
+	JWT *JWTConfig
+	// PublicPaths are URL path prefixes served without authentication, such as
+	// webhook endpoints that verify their own signatures.
+	PublicPaths []string
+}
+
+// Authenticator identifies callers from an API key or a JWT bearer token.
+type Authenticator struct {
+	keys     KeyStore
+	jwt      *JWTVerifier
+	disabled bool
+	public   []string
+	now      func() time.Time
+}
+
+// NewAuthenticator creates an Authenticator that looks API keys up in keys.
+func NewAuthenticator(keys KeyStore, cfg Config) (*Authenticator, error) {
+	a := &Authenticator{keys: keys, disabled: cfg.Disabled, public: cfg.PublicPaths, now: time.Now}
+	if cfg.JWT != nil {
+		v, err := NewJWTVerifier(*cfg.JWT)
+		if err != nil {
+			return nil, err
+		}
+		a.jwt = v
+	}
+	return a, nil
+}
+
+// Middleware rejects unauthenticated requests with 401 and attaches the caller's
+// Principal to the request context. Credentials are read from "Authorization: Bearer"
+// or "X-API-Key".
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.isPublic(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		p, err := a.Authenticate(r)
+		if err != nil {
+			if !errors.Is(err, ErrNoCredentials) && !errors.Is(err, ErrInvalidCredentials) {
+				logging.FromContext(r.Context()).Error("Authentication failed", "error", err)
+				writeError(w, http.StatusInternalServerError, "internal server error")
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="workflow-api"`)
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+	})
+}
+
+// Authenticate resolves the request's credentials to a principal.
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if a.disabled {
+		return &Principal{Subject: "anonymous", Name: "anonymous", Method: MethodDisabled, Tenant: DefaultTenant, Roles: []Role{RoleAdmin}}, nil
+	}
+
+	credential := r.Header.Get("X-API-Key")
+	if credential == "" {
+		if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+			credential = strings.TrimSpace(token)
+		}
+	}
+	if credential == "" {
+		return nil, ErrNoCredentials
+	}
+
+	if strings.HasPrefix(credential, APIKeyPrefix) {
+		return a.authenticateKey(r.Context(), credential)
+	}
+	if a.jwt == nil {
+		return nil, ErrInvalidCredentials
+	}
+	p, err := a.jwt.Verify(r.Context(), credential)
+	if err != nil {
+		logging.FromContext(r.Context()).Debug("Rejected bearer token", "error", err)
+		return nil, ErrInvalidCredentials
+	}
+	return p, nil
+}
+
+func (a *Authenticator) authenticateKey(ctx context.Context, plaintext string) (*Principal, error) {
+	prefix, ok := apiKeyPrefix(plaintext)
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	key, err := a.keys.FindKeyByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || key.RevokedAt != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if subtle.ConstantTimeCompare([]byte(HashAPIKey(plaintext)), []byte(key.Hash)) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := a.now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= touchInterval {
+		if err := a.keys.TouchKey(ctx, key.ID, now); err != nil {
+			logging.FromContext(ctx).Warn("Failed to record api key use", "keyId", key.ID, "error", err)
+		}
+	}
+	return &Principal{Subject: KeySubject(key.ID), Name: key.Name, Method: MethodAPIKey, KeyID: key.ID, Tenant: key.Tenant}, nil
+}
+
+func (a *Authenticator) isPublic(path string) bool {
+	for _, prefix := range a.public {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
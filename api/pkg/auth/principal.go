@@ -0,0 +1,42 @@
+// Package auth identifies API callers from hashed API keys or JWT bearer tokens, and
+// decides what each caller may do within its tenant.
+package auth
+
+import "context"
+
+// Method is how a principal authenticated.
+type Method string
+
+const (
+	MethodAPIKey   Method = "api_key"
+	MethodJWT      Method = "jwt"
+	MethodDisabled Method = "disabled" // Authentication is turned off for local development
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string `json:"subject"` // Stable caller ID: "key:<id>" for API keys, the JWT "sub" claim otherwise
+	Name    string `json:"name"`
+	Method  Method `json:"method"`
+	KeyID   string `json:"keyId,omitempty"` // Set for API keys
+	Tenant  string `json:"tenant"`          // Tenant whose workflows the caller sees; see DefaultTenant
+	Roles   []Role `json:"roles,omitempty"` // Global roles carried by the credential itself, e.g. a JWT "roles" claim
+}
+
+type contextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal attached by the middleware, if any.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(*Principal)
+	return p, ok
+}
+
+// KeySubject is the Principal.Subject of requests made with the API key id.
+func KeySubject(id string) string {
+	return "key:" + id
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"workflow-code-test/api/pkg/logging"
+)
+
+// Role is a named set of permissions.
+type Role string
+
+const (
+	RoleViewer Role = "viewer" // Read workflows and executions
+	RoleEditor Role = "editor" // Viewer, plus modify workflow definitions
+	RoleRunner Role = "runner" // Viewer, plus execute, test and schedule workflows
+	RoleAdmin  Role = "admin"  // Everything, including API keys and role bindings
+)
+
+// Permission is an action checked by handlers.
+type Permission string
+
+const (
+	PermView  Permission = "view"
+	PermEdit  Permission = "edit"
+	PermRun   Permission = "run"
+	PermAdmin Permission = "admin"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleViewer: {PermView},
+	RoleEditor: {PermView, PermEdit},
+	RoleRunner: {PermView, PermRun},
+	RoleAdmin:  {PermView, PermEdit, PermRun, PermAdmin},
+}
+
+// ParseRole reports whether s names a role.
+func ParseRole(s string) (Role, bool) {
+	_, ok := rolePermissions[Role(s)]
+	return Role(s), ok
+}
+
+// Grants reports whether the role includes perm.
+func (r Role) Grants(perm Permission) bool {
+	return slices.Contains(rolePermissions[r], perm)
+}
+
+// RoleBinding grants a role to a subject, either globally or, when WorkflowID is set,
+// on a single workflow.
+type RoleBinding struct {
+	ID         string    `json:"id"`
+	Subject    string    `json:"subject"` // Principal.Subject, e.g. "key:<id>" or a JWT "sub"
+	Tenant     string    `json:"tenant"`
+	Role       Role      `json:"role"`
+	WorkflowID string    `json:"workflowId,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// BindingStore persists role bindings. Each binding belongs to a tenant and only
+// applies to principals of that tenant.
+type BindingStore interface {
+	ListBindings(ctx context.Context, tenant, subject string) ([]RoleBinding, error) // All of tenant's bindings when subject is empty
+	CreateBinding(ctx context.Context, b *RoleBinding) error
+	DeleteBinding(ctx context.Context, tenant, id string) (bool, error)
+}
+
+// Authorizer decides whether a principal may perform an action.
+type Authorizer struct {
+	bindings BindingStore
+}
+
+// NewAuthorizer creates an authorizer that reads role bindings from bindings.
+func NewAuthorizer(bindings BindingStore) *Authorizer {
+	return &Authorizer{bindings: bindings}
+}
+
+// Allowed reports whether p holds perm globally or, if workflowID is non-empty, on that
+// workflow. Roles come from the principal's credential and from its tenant's bindings.
+func (a *Authorizer) Allowed(ctx context.Context, p *Principal, perm Permission, workflowID string) (bool, error) {
+	if p == nil {
+		return false, nil
+	}
+	for _, role := range p.Roles {
+		if role.Grants(perm) {
+			return true, nil
+		}
+	}
+
+	bindings, err := a.bindings.ListBindings(ctx, tenantOf(p), p.Subject)
+	if err != nil {
+		return false, err
+	}
+	for _, b := range bindings {
+		if (b.WorkflowID == "" || b.WorkflowID == workflowID) && b.Role.Grants(perm) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Require returns middleware that rejects requests without perm, granted globally,
+// with 403 Forbidden.
+func (a *Authorizer) Require(perm Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if a.Check(w, r, perm, "") {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// Check authorizes the request's principal for perm, writing a 401, 403 or 500 response
+// and returning false if it is not allowed.
+func (a *Authorizer) Check(w http.ResponseWriter, r *http.Request, perm Permission, workflowID string) bool {
+	p, ok := FromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, ErrNoCredentials.Error())
+		return false
+	}
+	allowed, err := a.Allowed(r.Context(), p, perm, workflowID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to check permissions", "subject", p.Subject, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return false
+	}
+	if !allowed {
+		logging.FromContext(r.Context()).Info("Permission denied", "subject", p.Subject, "permission", perm, "workflowId", workflowID)
+		writeError(w, http.StatusForbidden, fmt.Sprintf("%s permission required", perm))
+		return false
+	}
+	return true
+}
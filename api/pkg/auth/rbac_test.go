@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const weatherWorkflowID = "550e8400-e29b-41d4-a716-446655440000"
+
+func TestAuthorizer_Allowed(t *testing.T) {
+	bindings := &memBindingStore{}
+	bindings.grant("contractor", RoleRunner, weatherWorkflowID)
+	bindings.grant("author", RoleEditor, "")
+	authz := NewAuthorizer(bindings)
+
+	tests := []struct {
+		name       string
+		principal  *Principal
+		perm       Permission
+		workflowID string
+		want       bool
+	}{
+		{"contractor runs weather workflow", &Principal{Subject: "contractor"}, PermRun, weatherWorkflowID, true},
+		{"contractor views weather workflow", &Principal{Subject: "contractor"}, PermView, weatherWorkflowID, true},
+		{"contractor cannot edit weather workflow", &Principal{Subject: "contractor"}, PermEdit, weatherWorkflowID, false},
+		{"contractor cannot run other workflows", &Principal{Subject: "contractor"}, PermRun, "other", false},
+		{"editor edits any workflow", &Principal{Subject: "author"}, PermEdit, "other", true},
+		{"editor cannot run", &Principal{Subject: "author"}, PermRun, weatherWorkflowID, false},
+		{"editor is not admin", &Principal{Subject: "author"}, PermAdmin, "", false},
+		{"credential roles count", &Principal{Subject: "sso-user", Roles: []Role{RoleViewer}}, PermView, weatherWorkflowID, true},
+		{"admin can do anything", &Principal{Subject: "root", Roles: []Role{RoleAdmin}}, PermAdmin, "", true},
+		{"no roles", &Principal{Subject: "stranger"}, PermView, weatherWorkflowID, false},
+		{"no principal", nil, PermView, weatherWorkflowID, false},
+		{"bindings are per tenant", &Principal{Subject: "author", Tenant: "payments"}, PermEdit, "other", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := authz.Allowed(context.Background(), tt.principal, tt.perm, tt.workflowID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAuthorizer_CheckWritesForbidden(t *testing.T) {
+	authz := NewAuthorizer(&memBindingStore{})
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(WithPrincipal(req.Context(), &Principal{Subject: "stranger"}))
+	w := httptest.NewRecorder()
+
+	assert.False(t, authz.Check(w, req, PermEdit, weatherWorkflowID))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var result map[string]string
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.Equal(t, "edit permission required", result["message"])
+}
+
+func TestBindingHandlers(t *testing.T) {
+	keys := &memKeyStore{}
+	bindings := &memBindingStore{}
+	adminKey, admin, err := CreateKey(context.Background(), keys, "admin", DefaultTenant, "")
+	require.NoError(t, err)
+	bindings.grant(KeySubject(adminKey.ID), RoleAdmin, "")
+	router := newTestRouter(t, keys, bindings, Config{})
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/role-bindings", bytes.NewReader([]byte(body)))
+		req.Header.Set("X-API-Key", admin)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"subject": "contractor", "role": "runner", "workflowId": "` + weatherWorkflowID + `"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created RoleBinding
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	assert.Equal(t, RoleRunner, created.Role)
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"subject": "contractor", "role": "owner"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"role": "viewer"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"subject": "contractor", "role": "viewer", "workflowId": "weather"}`).Code)
+
+	w = get(router, "/api/v1/role-bindings?subject=contractor", map[string]string{"X-API-Key": admin})
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct{ RoleBindings []RoleBinding }
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	assert.Len(t, list.RoleBindings, 1)
+
+	req := httptest.NewRequest("DELETE", "/api/v1/role-bindings/"+created.ID, nil)
+	req.Header.Set("X-API-Key", admin)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestKeyHandlers_TenantScoped(t *testing.T) {
+	keys := &memKeyStore{}
+	bindings := &memBindingStore{}
+	operatorKey, operator, err := CreateKey(context.Background(), keys, "operator", DefaultTenant, "")
+	require.NoError(t, err)
+	bindings.grant(KeySubject(operatorKey.ID), RoleAdmin, "")
+	router := newTestRouter(t, keys, bindings, Config{})
+
+	post := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/api-keys", bytes.NewReader([]byte(body)))
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// The operator issues the first admin key of a new tenant
+	w := post(operator, `{"name": "payments-admin", "tenant": "payments", "roles": ["admin"]}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created createKeyResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	assert.Equal(t, "payments", created.Tenant)
+	paymentsAdmin := created.Key
+
+	w = get(router, "/api/v1/whoami", map[string]string{"X-API-Key": paymentsAdmin})
+	var p Principal
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&p))
+	assert.Equal(t, "payments", p.Tenant)
+
+	// Tenant admins see and manage only their own tenant
+	w = get(router, "/api/v1/api-keys", map[string]string{"X-API-Key": paymentsAdmin})
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct{ APIKeys []APIKey }
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	require.Len(t, list.APIKeys, 1)
+	assert.Equal(t, "payments-admin", list.APIKeys[0].Name)
+
+	assert.Equal(t, http.StatusForbidden, post(paymentsAdmin, `{"name": "x", "tenant": "default"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(operator, `{"name": "x", "tenant": "Not A Tenant"}`).Code)
+
+	req := httptest.NewRequest("DELETE", "/api/v1/api-keys/"+operatorKey.ID, nil)
+	req.Header.Set("X-API-Key", paymentsAdmin)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
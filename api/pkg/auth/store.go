@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresKeyStore is a KeyStore backed by the api_keys table.
+type PostgresKeyStore struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresKeyStore creates a key store on the given connection pool.
+func NewPostgresKeyStore(pool *pgxpool.Pool) *PostgresKeyStore {
+	return &PostgresKeyStore{db: pool}
+}
+
+const keyColumns = `id, name, prefix, hash, tenant_id, created_by, created_at, last_used_at, revoked_at`
+
+func scanKey(row pgx.Row) (*APIKey, error) {
+	var k APIKey
+	if err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.Hash, &k.Tenant, &k.CreatedBy, &k.CreatedAt, &k.LastUsedAt, &k.RevokedAt); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// CreateKey inserts a key and fills in its creation time.
+func (s *PostgresKeyStore) CreateKey(ctx context.Context, key *APIKey) error {
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO api_keys (id, name, prefix, hash, tenant_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at
+	`, key.ID, key.Name, key.Prefix, key.Hash, key.Tenant, key.CreatedBy).Scan(&key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create api key: %w", err)
+	}
+	return nil
+}
+
+// ListKeys returns every key of tenant, including revoked ones, newest first.
+func (s *PostgresKeyStore) ListKeys(ctx context.Context, tenant string) ([]APIKey, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+keyColumns+` FROM api_keys WHERE tenant_id = $1 ORDER BY created_at DESC`, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		k, err := scanKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan api key: %w", err)
+		}
+		keys = append(keys, *k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// FindKeyByPrefix returns the key with the given public prefix. Returns nil, nil if not found.
+func (s *PostgresKeyStore) FindKeyByPrefix(ctx context.Context, prefix string) (*APIKey, error) {
+	k, err := scanKey(s.db.QueryRow(ctx, `SELECT `+keyColumns+` FROM api_keys WHERE prefix = $1`, prefix))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find api key: %w", err)
+	}
+	return k, nil
+}
+
+// RevokeKey marks a key revoked. Revoking an already revoked key keeps the original time.
+// Returns false if the key does not exist in tenant.
+func (s *PostgresKeyStore) RevokeKey(ctx context.Context, tenant, id string, at time.Time) (bool, error) {
+	tag, err := s.db.Exec(ctx, `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $3) WHERE id = $1 AND tenant_id = $2`, id, tenant, at)
+	if err != nil {
+		return false, fmt.Errorf("revoke api key: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// TouchKey records that a key was used.
+func (s *PostgresKeyStore) TouchKey(ctx context.Context, id string, at time.Time) error {
+	_, err := s.db.Exec(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, at)
+	if err != nil {
+		return fmt.Errorf("touch api key: %w", err)
+	}
+	return nil
+}
+
+// PostgresBindingStore is a BindingStore backed by the role_bindings table.
+type PostgresBindingStore struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresBindingStore creates a binding store on the given connection pool.
+func NewPostgresBindingStore(pool *pgxpool.Pool) *PostgresBindingStore {
+	return &PostgresBindingStore{db: pool}
+}
+
+// ListBindings returns tenant's bindings of subject, or all of tenant's bindings if
+// subject is empty.
+func (s *PostgresBindingStore) ListBindings(ctx context.Context, tenant, subject string) ([]RoleBinding, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, tenant_id, subject, role, COALESCE(workflow_id::text, ''), created_at FROM role_bindings
+		WHERE tenant_id = $1 AND ($2 = '' OR subject = $2)
+		ORDER BY created_at
+	`, tenant, subject)
+	if err != nil {
+		return nil, fmt.Errorf("list role bindings: %w", err)
+	}
+	defer rows.Close()
+
+	bindings := []RoleBinding{}
+	for rows.Next() {
+		var b RoleBinding
+		var role string
+		if err := rows.Scan(&b.ID, &b.Tenant, &b.Subject, &role, &b.WorkflowID, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan role binding: %w", err)
+		}
+		b.Role = Role(role)
+		bindings = append(bindings, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list role bindings: %w", err)
+	}
+	return bindings, nil
+}
+
+// CreateBinding inserts a binding and fills in its creation time.
+func (s *PostgresBindingStore) CreateBinding(ctx context.Context, b *RoleBinding) error {
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO role_bindings (id, tenant_id, subject, role, workflow_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid)
+		RETURNING created_at
+	`, b.ID, b.Tenant, b.Subject, string(b.Role), b.WorkflowID).Scan(&b.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create role binding: %w", err)
+	}
+	return nil
+}
+
+// DeleteBinding removes a binding. Returns false if it did not exist in tenant.
+func (s *PostgresBindingStore) DeleteBinding(ctx context.Context, tenant, id string) (bool, error) {
+	tag, err := s.db.Exec(ctx, `DELETE FROM role_bindings WHERE id = $1 AND tenant_id = $2`, id, tenant)
+	if err != nil {
+		return false, fmt.Errorf("delete role binding: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
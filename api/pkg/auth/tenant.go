@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"context"
+	"regexp"
+)
+
+// DefaultTenant owns data created before tenants were introduced and callers whose
+// credential names no tenant. Its admins operate the deployment and may issue API keys
+// for other tenants.
+const DefaultTenant = "default"
+
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// ValidTenant reports whether s is a well-formed tenant ID: lower-case letters, digits
+// and hyphens, at most 63 characters.
+func ValidTenant(s string) bool {
+	return tenantPattern.MatchString(s)
+}
+
+// Tenant returns the tenant of the request's principal, or DefaultTenant if the request
+// is unauthenticated.
+func Tenant(ctx context.Context) string {
+	p, _ := FromContext(ctx)
+	return tenantOf(p)
+}
+
+// tenantOf returns p's tenant, treating a missing principal or tenant as DefaultTenant.
+func tenantOf(p *Principal) string {
+	if p == nil || p.Tenant == "" {
+		return DefaultTenant
+	}
+	return p.Tenant
+}
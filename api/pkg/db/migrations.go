@@ -0,0 +1,34 @@
+package db
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"workflow-code-test/api/pkg/migrate"
+)
+
+// migrationFiles holds the schema, one NNNN_description.{up,down}.sql pair per change.
+// Add a new pair for every schema change; never edit one that has been released.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrations returns the schema migrations embedded in the binary, in order.
+func Migrations() ([]migrate.Migration, error) {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return migrate.Load(sub)
+}
+
+// NewMigrator returns a migrator that applies the embedded migrations to pool.
+func NewMigrator(pool *pgxpool.Pool) (*migrate.Migrator, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(pool, migrations), nil
+}
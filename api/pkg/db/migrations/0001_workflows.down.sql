@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS workflow_executions;
+DROP TABLE IF EXISTS workflow_schedules;
+DROP TABLE IF EXISTS workflows;
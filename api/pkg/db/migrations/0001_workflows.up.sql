@@ -0,0 +1,61 @@
+-- Workflows, their schedules, suspended executions and idempotency keys. Statements are
+-- written to be no-ops on databases created before migrations were introduced, so they
+-- adopt this version without changes. Rows created before tenants belong to 'default'.
+CREATE TABLE IF NOT EXISTS workflows (
+	id         UUID PRIMARY KEY,
+	tenant_id  TEXT NOT NULL DEFAULT 'default',
+	name       TEXT NOT NULL DEFAULT '',
+	nodes      JSONB NOT NULL DEFAULT '[]',
+	edges      JSONB NOT NULL DEFAULT '[]',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+ALTER TABLE workflows ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS workflows_nodes_gin ON workflows USING GIN (nodes jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS workflows_tenant ON workflows (tenant_id);
+
+CREATE TABLE IF NOT EXISTS workflow_schedules (
+	id          UUID PRIMARY KEY,
+	tenant_id   TEXT NOT NULL DEFAULT 'default',
+	workflow_id UUID NOT NULL REFERENCES workflows (id) ON DELETE CASCADE,
+	cron        TEXT NOT NULL,
+	timezone    TEXT NOT NULL DEFAULT 'UTC',
+	input       JSONB NOT NULL DEFAULT '{}',
+	enabled     BOOLEAN NOT NULL DEFAULT TRUE,
+	next_run_at TIMESTAMPTZ,
+	last_run_at TIMESTAMPTZ,
+	last_status TEXT NOT NULL DEFAULT '',
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+ALTER TABLE workflow_schedules ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS workflow_schedules_due ON workflow_schedules (next_run_at) WHERE enabled;
+
+CREATE TABLE IF NOT EXISTS workflow_executions (
+	id             UUID PRIMARY KEY,
+	tenant_id      TEXT NOT NULL DEFAULT 'default',
+	workflow_id    UUID NOT NULL REFERENCES workflows (id) ON DELETE CASCADE,
+	status         TEXT NOT NULL,
+	resume_node_id TEXT NOT NULL DEFAULT '',
+	resume_at      TIMESTAMPTZ,
+	lease_until    TIMESTAMPTZ,
+	state          JSONB NOT NULL DEFAULT '{}',
+	results        JSONB NOT NULL DEFAULT '{}',
+	created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+ALTER TABLE workflow_executions ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS workflow_executions_due ON workflow_executions (resume_at) WHERE status = 'suspended';
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	scope        TEXT NOT NULL,
+	key          TEXT NOT NULL,
+	request_hash TEXT NOT NULL,
+	status_code  INTEGER NOT NULL DEFAULT 0,
+	response     BYTEA,
+	locked_until TIMESTAMPTZ,
+	expires_at   TIMESTAMPTZ NOT NULL,
+	created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (scope, key)
+);
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires ON idempotency_keys (expires_at);
@@ -0,0 +1,26 @@
+-- API keys and role bindings. Only a hash of each key is stored. A NULL workflow_id
+-- grants the role on every workflow of the binding's tenant.
+CREATE TABLE IF NOT EXISTS api_keys (
+	id           UUID PRIMARY KEY,
+	name         TEXT NOT NULL,
+	prefix       TEXT NOT NULL UNIQUE,
+	hash         TEXT NOT NULL,
+	tenant_id    TEXT NOT NULL DEFAULT 'default',
+	created_by   TEXT NOT NULL DEFAULT '',
+	created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	last_used_at TIMESTAMPTZ,
+	revoked_at   TIMESTAMPTZ
+);
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+CREATE TABLE IF NOT EXISTS role_bindings (
+	id          UUID PRIMARY KEY,
+	tenant_id   TEXT NOT NULL DEFAULT 'default',
+	subject     TEXT NOT NULL,
+	role        TEXT NOT NULL,
+	workflow_id UUID,
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+ALTER TABLE role_bindings ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+DROP INDEX IF EXISTS role_bindings_subject;
+CREATE INDEX IF NOT EXISTS role_bindings_tenant_subject ON role_bindings (tenant_id, subject);
@@ -0,0 +1,11 @@
+-- Encrypted secrets. ciphertext is sealed with the SECRETS_KEY and bound to the
+-- secret's tenant and name.
+CREATE TABLE IF NOT EXISTS secrets (
+	tenant_id  TEXT NOT NULL,
+	name       TEXT NOT NULL,
+	ciphertext BYTEA NOT NULL,
+	updated_by TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (tenant_id, name)
+);
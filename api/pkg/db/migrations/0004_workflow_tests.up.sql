@@ -0,0 +1,2 @@
+-- Test cases that workflow authors keep with each workflow. See TestCase.
+ALTER TABLE workflows ADD COLUMN IF NOT EXISTS tests JSONB NOT NULL DEFAULT '[]';
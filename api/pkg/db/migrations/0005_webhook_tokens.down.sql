@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS workflows_webhook_token;
+ALTER TABLE workflows DROP COLUMN IF EXISTS webhook_token;
@@ -0,0 +1,18 @@
+-- The token of each workflow's webhook trigger, kept unique so that a token always
+-- names one workflow. Where existing workflows share a token, only the oldest keeps it.
+ALTER TABLE workflows ADD COLUMN IF NOT EXISTS webhook_token TEXT;
+
+UPDATE workflows w SET webhook_token = t.token
+FROM (
+	SELECT DISTINCT ON (token) id, token
+	FROM (
+		SELECT id, created_at,
+			jsonb_path_query_first(nodes, '$[*] ? (@.type == "start").data.metadata.trigger ? (@.type == "webhook").token') #>> '{}' AS token
+		FROM workflows
+	) triggers
+	WHERE token <> ''
+	ORDER BY token, created_at, id
+) t
+WHERE w.id = t.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS workflows_webhook_token ON workflows (webhook_token);
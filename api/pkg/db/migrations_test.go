@@ -0,0 +1,18 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations_AreEmbeddedAndNumberedInSequence(t *testing.T) {
+	migrations, err := Migrations()
+	require.NoError(t, err)
+
+	require.NotEmpty(t, migrations)
+	for i, m := range migrations {
+		assert.Equal(t, int64(i+1), m.Version, "migration %s should be numbered %d", m.Name, i+1)
+	}
+}
@@ -3,26 +3,64 @@ package db
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"workflow-code-test/api/pkg/tracing"
 )
 
+// Config holds the connection and pool settings for Connect.
 type Config struct {
-	URI             string
-	MaxOpenConns    int
-	MaxIdleConns    int
-	ConnMaxLifetime time.Duration
-	QueryTimeout    time.Duration
+	URI             string        `yaml:"url"`
+	MaxOpenConns    int           `yaml:"maxOpenConns"`    // Most connections the pool opens at once
+	MaxIdleConns    int           `yaml:"maxIdleConns"`    // Connections kept open while the pool is idle
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime"` // Age at which a connection is closed and replaced
+	QueryTimeout    time.Duration `yaml:"queryTimeout"`    // Longest any statement may run; 0 means no limit
+}
+
+// DefaultConfig returns pool settings suited to a single API replica. The URI is left
+// empty for the caller to fill in.
+func DefaultConfig() Config {
+	return Config{
+		MaxOpenConns:    10,
+		MaxIdleConns:    2,
+		ConnMaxLifetime: time.Hour,
+		QueryTimeout:    30 * time.Second,
+	}
 }
 
-func Connect(ctx context.Context, connStr string) (*pgxpool.Pool, error) {
-	pool, err := pgxpool.New(ctx, connStr)
+// Connect opens a connection pool sized by cfg and checks that the database is reachable.
+// The query timeout is enforced by Postgres as the sessions' statement_timeout, so it
+// applies to every query without each caller setting a deadline.
+// Queries made with a traced context get a span each.
+func Connect(ctx context.Context, cfg Config) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.URI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database url: %w", err)
+	}
+	if cfg.MaxOpenConns > 0 {
+		poolConfig.MaxConns = int32(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		poolConfig.MinConns = int32(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.ConnMaxLifetime
+	}
+	if cfg.QueryTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.QueryTimeout.Milliseconds(), 10)
+	}
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create pgx pool: %w", err)
 	}
 
 	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnect_AppliesPoolSettingsAndQueryTimeout(t *testing.T) {
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("DATABASE_URL not set, skipping connection tests")
+	}
+	ctx := context.Background()
+
+	cfg := DefaultConfig()
+	cfg.URI = dbURL
+	cfg.MaxOpenConns = 3
+	cfg.QueryTimeout = 100 * time.Millisecond
+	pool, err := Connect(ctx, cfg)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	assert.Equal(t, int32(3), pool.Config().MaxConns)
+	assert.Equal(t, int32(2), pool.Config().MinConns)
+	var timeout string
+	require.NoError(t, pool.QueryRow(ctx, `SHOW statement_timeout`).Scan(&timeout))
+	assert.Equal(t, "100ms", timeout)
+	_, err = pool.Exec(ctx, `SELECT pg_sleep(1)`)
+	assert.ErrorContains(t, err, "statement timeout")
+}
@@ -0,0 +1,134 @@
+// Package health serves liveness and readiness probes and a build and configuration
+// summary for operators.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// LivenessPath answers as long as the process can serve HTTP.
+	LivenessPath = "/healthz"
+	// ReadinessPath answers 200 only while every readiness check passes.
+	ReadinessPath = "/readyz"
+)
+
+// Check reports whether one dependency is ready; a nil error means it is.
+type Check func(ctx context.Context) error
+
+// CheckResult is the outcome of one readiness check.
+type CheckResult struct {
+	Status string `json:"status"` // "ok" or "failed"
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the body of a readiness response.
+type Report struct {
+	Status string                 `json:"status"` // "ready", "not ready" or "draining"
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks. Once Drain is called it reports not ready, so that
+// load balancers stop routing to a server that is shutting down.
+type Checker struct {
+	timeout  time.Duration
+	draining atomic.Bool
+
+	mu     sync.RWMutex
+	checks []namedCheck
+}
+
+// NewChecker creates a checker whose checks each have timeout to respond.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a readiness check under name.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Drain makes readiness fail from now on.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// LoadRoutes registers the liveness and readiness probes. They need no credentials.
+func (c *Checker) LoadRoutes(router *mux.Router) {
+	router.HandleFunc(LivenessPath, c.HandleLiveness).Methods("GET")
+	router.HandleFunc(ReadinessPath, c.HandleReadiness).Methods("GET")
+}
+
+// HandleLiveness reports that the process is up. It checks no dependencies, so a database
+// outage does not get the server restarted.
+func (c *Checker) HandleLiveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// HandleReadiness runs every check concurrently and answers 503 if any fails or the server
+// is draining.
+func (c *Checker) HandleReadiness(w http.ResponseWriter, r *http.Request) {
+	report := c.Run(r.Context())
+	status := http.StatusOK
+	if report.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+// Run executes the readiness checks and summarises them.
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = CheckResult{Status: "ok"}
+			if err := nc.check(ctx); err != nil {
+				results[i] = CheckResult{Status: "failed", Error: err.Error()}
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: "ready", Checks: make(map[string]CheckResult, len(checks))}
+	for i, nc := range checks {
+		report.Checks[nc.name] = results[i]
+		if results[i].Status != "ok" {
+			report.Status = "not ready"
+		}
+	}
+	if c.draining.Load() {
+		report.Status = "draining"
+	}
+	return report
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func probe(t *testing.T, c *Checker, path string) (int, Report) {
+	t.Helper()
+	router := mux.NewRouter()
+	c.LoadRoutes(router)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	var report Report
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	return w.Code, report
+}
+
+func ok(context.Context) error { return nil }
+
+func TestLiveness_IgnoresChecks(t *testing.T) {
+	c := NewChecker(time.Second)
+	c.Add("postgres", func(context.Context) error { return errors.New("connection refused") })
+
+	code, report := probe(t, c, LivenessPath)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", report.Status)
+}
+
+func TestReadiness_Ready(t *testing.T) {
+	c := NewChecker(time.Second)
+	c.Add("postgres", ok)
+	c.Add("scheduler", ok)
+
+	code, report := probe(t, c, ReadinessPath)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", report.Status)
+	assert.Equal(t, map[string]CheckResult{
+		"postgres":  {Status: "ok"},
+		"scheduler": {Status: "ok"},
+	}, report.Checks)
+}
+
+func TestReadiness_FailingCheck(t *testing.T) {
+	c := NewChecker(time.Second)
+	c.Add("postgres", ok)
+	c.Add("schema", func(context.Context) error { return errors.New("missing tables: workflows") })
+
+	code, report := probe(t, c, ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not ready", report.Status)
+	assert.Equal(t, CheckResult{Status: "failed", Error: "missing tables: workflows"}, report.Checks["schema"])
+	assert.Equal(t, "ok", report.Checks["postgres"].Status)
+}
+
+func TestReadiness_SlowCheckTimesOut(t *testing.T) {
+	c := NewChecker(20 * time.Millisecond)
+	c.Add("postgres", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	code, report := probe(t, c, ReadinessPath)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "context deadline exceeded", report.Checks["postgres"].Error)
+}
+
+func TestReadiness_FailsWhileDraining(t *testing.T) {
+	c := NewChecker(time.Second)
+	c.Add("postgres", ok)
+	c.Drain()
+
+	code, report := probe(t, c, ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "draining", report.Status)
+
+	code, _ = probe(t, c, LivenessPath)
+	assert.Equal(t, http.StatusOK, code, "a draining server is still alive")
+}
+
+func TestInfoHandler(t *testing.T) {
+	startedAt := time.Now().Add(-90 * time.Second).UTC()
+	handler := InfoHandler(Info{
+		Build:     ReadBuild(),
+		StartedAt: startedAt,
+		Config:    map[string]any{"schedulerInterval": "15s"},
+		NodeTypes: []string{"condition", "start"},
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", InfoPath, nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var info Info
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&info))
+	assert.Equal(t, "dev", info.Build.Version)
+	assert.NotEmpty(t, info.Build.GoVersion)
+	assert.Equal(t, "1m30s", info.Uptime)
+	assert.True(t, startedAt.Equal(info.StartedAt))
+	assert.Equal(t, "15s", info.Config["schedulerInterval"])
+	assert.Equal(t, []string{"condition", "start"}, info.NodeTypes)
+}
@@ -0,0 +1,60 @@
+package health
+
+import (
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// InfoPath serves Info. It reveals deployment details, so mount it behind admin auth.
+const InfoPath = "/debug/info"
+
+// Version is the release this binary was built from, set at build time with
+// -ldflags "-X workflow-code-test/api/pkg/health.Version=v1.2.3".
+var Version = "dev"
+
+// Build identifies the running binary.
+type Build struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"` // VCS commit, when built from a checkout
+	Modified  bool   `json:"modified,omitempty"` // The checkout had uncommitted changes
+	GoVersion string `json:"goVersion"`
+}
+
+// ReadBuild describes the running binary from Version and the embedded build information.
+func ReadBuild() Build {
+	b := Build{Version: Version}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b
+	}
+	b.GoVersion = info.GoVersion
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			b.Revision = s.Value
+		case "vcs.modified":
+			b.Modified = s.Value == "true"
+		}
+	}
+	return b
+}
+
+// Info is the body of GET /debug/info.
+type Info struct {
+	Build     Build          `json:"build"`
+	StartedAt time.Time      `json:"startedAt"`
+	Uptime    string         `json:"uptime"`
+	Config    map[string]any `json:"config"`    // Settings in effect, without credentials
+	NodeTypes []string       `json:"nodeTypes"` // Node types the executor registry can run
+}
+
+// InfoHandler serves info, filling in the uptime on each request. config must not hold
+// credentials or keys.
+func InfoHandler(info Info) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := info
+		resp.Uptime = time.Since(info.StartedAt).Round(time.Second).String()
+		writeJSON(w, http.StatusOK, resp)
+	})
+}
@@ -0,0 +1,71 @@
+// Package logging carries a request-scoped slog.Logger in the context, so that every
+// log line written while handling a request, or running an execution it started, can
+// be correlated by request, workflow and execution ID.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"regexp"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request ID. Incoming values are reused so IDs assigned by a
+// proxy or caller follow the request; otherwise one is generated. It is always echoed in
+// the response.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern limits reused request IDs to safe, reasonably short tokens.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type loggerKey struct{}
+type requestIDKey struct{}
+
+// FromContext returns the logger stored in ctx, or slog.Default() if there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// WithLogger returns a copy of ctx carrying l.
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// With returns a copy of ctx whose logger adds args, as slog.Logger.With does, to every
+// line. Add each key once along a call chain; slog does not deduplicate them.
+func With(ctx context.Context, args ...any) context.Context {
+	return WithLogger(ctx, FromContext(ctx).With(args...))
+}
+
+// RequestID returns the ID of the request ctx belongs to, or "" outside a request.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Middleware assigns each request an ID, echoes it in the X-Request-ID response header,
+// and stores a logger carrying it, and the trace ID when the request is traced, in the
+// request context. Install it after tracing so that the span exists.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = uuid.New().String()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		args := []any{"requestId", id}
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			args = append(args, "traceId", sc.TraceID().String())
+		}
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		ctx = WithLogger(ctx, FromContext(ctx).With(args...))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
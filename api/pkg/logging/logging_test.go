@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve runs one request through Middleware with a JSON logger writing to buf as the
+// base logger, and a handler that logs a line through the request's logger.
+func serve(t *testing.T, buf *bytes.Buffer, requestID string) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+	base := slog.New(slog.NewJSONHandler(buf, nil))
+	var seen string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+		FromContext(r.Context()).Info("handled")
+	}))
+
+	req := httptest.NewRequest("GET", "/api/v1/workflows", nil)
+	req = req.WithContext(WithLogger(req.Context(), base))
+	if requestID != "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec, seen
+}
+
+func lastLine(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var line map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &line))
+	return line
+}
+
+func TestMiddleware_GeneratesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	rec, seen := serve(t, &buf, "")
+
+	id := rec.Header().Get(RequestIDHeader)
+	_, err := uuid.Parse(id)
+	require.NoError(t, err)
+	assert.Equal(t, id, seen)
+	assert.Equal(t, id, lastLine(t, &buf)["requestId"])
+}
+
+func TestMiddleware_ReusesIncomingRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	rec, seen := serve(t, &buf, "edge-7f3a:42")
+
+	assert.Equal(t, "edge-7f3a:42", rec.Header().Get(RequestIDHeader))
+	assert.Equal(t, "edge-7f3a:42", seen)
+	assert.Equal(t, "edge-7f3a:42", lastLine(t, &buf)["requestId"])
+}
+
+func TestMiddleware_ReplacesInvalidRequestID(t *testing.T) {
+	for _, id := range []string{"has spaces", "line\nbreak", strings.Repeat("a", 129)} {
+		var buf bytes.Buffer
+		rec, _ := serve(t, &buf, id)
+
+		got := rec.Header().Get(RequestIDHeader)
+		assert.NotEqual(t, id, got)
+		_, err := uuid.Parse(got)
+		assert.NoError(t, err)
+	}
+}
+
+func TestWith_AddsFieldsToContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithLogger(context.Background(), slog.New(slog.NewJSONHandler(&buf, nil)))
+	ctx = With(ctx, "workflowId", "wf-1")
+	ctx = With(ctx, "executionId", "exec-1")
+
+	FromContext(ctx).Info("step")
+	line := lastLine(t, &buf)
+	assert.Equal(t, "wf-1", line["workflowId"])
+	assert.Equal(t, "exec-1", line["executionId"])
+}
+
+func TestFromContext_DefaultsToDefaultLogger(t *testing.T) {
+	assert.Same(t, slog.Default(), FromContext(context.Background()))
+	assert.Empty(t, RequestID(context.Background()))
+}
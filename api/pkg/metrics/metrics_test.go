@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_RecordsRouteTemplates(t *testing.T) {
+	reg := NewRegistry()
+	m := NewHTTP(reg)
+	router := mux.NewRouter()
+	router.Use(m.Middleware)
+	router.Handle(Path, Handler(reg))
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/workflows/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("{}"))
+	})
+
+	for _, path := range []string{"/api/v1/workflows/a", "/api/v1/workflows/b", "/api/v1/workflows/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues("GET", "/api/v1/workflows/{id}", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("GET", "/api/v1/workflows/{id}", "404")))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", Path, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `http_request_duration_seconds_count{method="GET",route="/api/v1/workflows/{id}"} 3`)
+	assert.Contains(t, w.Body.String(), "go_goroutines")
+}
+
+func TestPoolCollector(t *testing.T) {
+	// The pool connects lazily, so no database is needed
+	cfg, err := pgxpool.ParseConfig("postgres://localhost:1/none")
+	require.NoError(t, err)
+	cfg.MaxConns = 7
+	pool, err := pgxpool.NewWithConfig(context.Background(), cfg)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	c := NewPoolCollector(pool)
+	assert.Equal(t, 9, testutil.CollectAndCount(c))
+
+	err = testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP pgxpool_max_conns Maximum size of the pool.
+# TYPE pgxpool_max_conns gauge
+pgxpool_max_conns 7
+`), "pgxpool_max_conns")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,85 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Usage describes the commands ParseCommand accepts, for inclusion in a tool's help.
+const Usage = `  migrate up         apply every pending migration
+  migrate down [N]   revert the last N applied migrations (default 1)
+  migrate status     list migrations and when each was applied
+`
+
+// Command is a parsed "up", "down [N]" or "status" command line.
+type Command struct {
+	Name  string // "up", "down" or "status"
+	Steps int    // Migrations "down" reverts
+}
+
+// ParseCommand parses the arguments that follow "migrate".
+func ParseCommand(args []string) (Command, error) {
+	if len(args) == 0 {
+		return Command{}, fmt.Errorf("migrate: missing command")
+	}
+	cmd := Command{Name: args[0], Steps: 1}
+	switch {
+	case cmd.Name != "up" && cmd.Name != "down" && cmd.Name != "status":
+		return Command{}, fmt.Errorf("unknown migrate command %q", cmd.Name)
+	case cmd.Name == "down" && len(args) == 2:
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return Command{}, fmt.Errorf("migrate down: %q is not a positive number of migrations", args[1])
+		}
+		cmd.Steps = n
+	case len(args) != 1:
+		return Command{}, fmt.Errorf("migrate %s: too many arguments", cmd.Name)
+	}
+	return cmd, nil
+}
+
+// Run carries out the command with m, reporting each migration applied or reverted, or
+// the status table, to out.
+func (c Command) Run(ctx context.Context, m *Migrator, out io.Writer) error {
+	switch c.Name {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			fmt.Fprintf(out, "Applied %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "No pending migrations")
+		}
+		return err
+	case "down":
+		reverted, err := m.Down(ctx, c.Steps)
+		for _, mig := range reverted {
+			fmt.Fprintf(out, "Reverted %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Fprintln(out, "No applied migrations")
+		}
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q", c.Name)
+	}
+}
@@ -0,0 +1,42 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want Command
+	}{
+		{[]string{"up"}, Command{Name: "up", Steps: 1}},
+		{[]string{"down"}, Command{Name: "down", Steps: 1}},
+		{[]string{"down", "3"}, Command{Name: "down", Steps: 3}},
+		{[]string{"status"}, Command{Name: "status", Steps: 1}},
+	}
+	for _, tt := range tests {
+		cmd, err := ParseCommand(tt.args)
+		require.NoError(t, err, tt.args)
+		assert.Equal(t, tt.want, cmd, tt.args)
+	}
+}
+
+func TestParseCommand_RejectsBadArguments(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "migrate: missing command"},
+		{[]string{"sideways"}, `unknown migrate command "sideways"`},
+		{[]string{"down", "0"}, `migrate down: "0" is not a positive number of migrations`},
+		{[]string{"down", "x"}, `migrate down: "x" is not a positive number of migrations`},
+		{[]string{"up", "2"}, "migrate up: too many arguments"},
+	}
+	for _, tt := range tests {
+		_, err := ParseCommand(tt.args)
+		assert.EqualError(t, err, tt.want, tt.args)
+	}
+}
@@ -0,0 +1,175 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func file(body string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(body)}
+}
+
+var testFS = fstest.MapFS{
+	"0002_notes_title.up.sql":   file(`ALTER TABLE notes ADD COLUMN title TEXT NOT NULL DEFAULT ''`),
+	"0002_notes_title.down.sql": file(`ALTER TABLE notes DROP COLUMN title`),
+	"0001_notes.up.sql":         file(`CREATE TABLE notes (id INT PRIMARY KEY); CREATE INDEX notes_id ON notes (id)`),
+	"0001_notes.down.sql":       file(`DROP TABLE notes`),
+	"README.md":                 file(`ignored`),
+}
+
+func TestLoad_SortsAndPairsFiles(t *testing.T) {
+	migrations, err := Load(testFS)
+	require.NoError(t, err)
+
+	require.Len(t, migrations, 2)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "notes", migrations[0].Name)
+	assert.Contains(t, migrations[0].Up, "CREATE TABLE notes")
+	assert.Equal(t, "DROP TABLE notes", migrations[0].Down)
+	assert.Equal(t, int64(2), migrations[1].Version)
+	assert.Equal(t, "notes_title", migrations[1].Name)
+}
+
+func TestLoad_RejectsInvalidSets(t *testing.T) {
+	tests := []struct {
+		name string
+		fs   fstest.MapFS
+		want string
+	}{
+		{"missing down", fstest.MapFS{"0001_a.up.sql": file("SELECT 1")}, "migration 1_a needs non-empty up and down files"},
+		{"empty up", fstest.MapFS{"0001_a.up.sql": file(" \n"), "0001_a.down.sql": file("SELECT 1")}, "migration 1_a needs non-empty up and down files"},
+		{"bad name", fstest.MapFS{"1-add notes.sql": file("SELECT 1")}, "migration 1-add notes.sql: name must look like 0001_description.up.sql"},
+		{"zero version", fstest.MapFS{"0000_a.up.sql": file("SELECT 1"), "0000_a.down.sql": file("SELECT 1")}, "migration 0000_a.down.sql: invalid version"},
+		{"two names", fstest.MapFS{"0001_a.up.sql": file("SELECT 1"), "0001_b.down.sql": file("SELECT 1")}, "migration 1 has two names: a and b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.fs)
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+// testPool connects to DATABASE_URL with a fresh schema first on the search path, so
+// tests neither see nor disturb the real schema_migrations table.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("DATABASE_URL not set, skipping migration tests")
+	}
+	ctx := context.Background()
+
+	admin, err := pgxpool.New(ctx, dbURL)
+	require.NoError(t, err)
+	t.Cleanup(admin.Close)
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	_, err = admin.Exec(ctx, "CREATE SCHEMA "+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE") })
+
+	cfg, err := pgxpool.ParseConfig(dbURL)
+	require.NoError(t, err)
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	migrations, err := Load(testFS)
+	require.NoError(t, err)
+	m := New(pool, migrations)
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.Nil(t, statuses[0].AppliedAt)
+	assert.EqualError(t, m.Check(ctx), "pending migrations: 1, 2")
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+	require.NoError(t, m.Check(ctx))
+	_, err = pool.Exec(ctx, `INSERT INTO notes (id, title) VALUES (1, 'hello')`)
+	require.NoError(t, err)
+
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied, "a second run applies nothing")
+
+	reverted, err := m.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, int64(2), reverted[0].Version)
+	statuses, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[1].AppliedAt)
+	assert.EqualError(t, m.Check(ctx), "pending migrations: 2")
+
+	reverted, err = m.Down(ctx, 5)
+	require.NoError(t, err)
+	assert.Len(t, reverted, 1)
+}
+
+func TestMigrator_FailedMigrationIsRolledBack(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	migrations, err := Load(fstest.MapFS{
+		"0001_ok.up.sql":      file(`CREATE TABLE ok (id INT)`),
+		"0001_ok.down.sql":    file(`DROP TABLE ok`),
+		"0002_bad.up.sql":     file(`CREATE TABLE half (id INT); SELECT * FROM no_such_table`),
+		"0002_bad.down.sql":   file(`DROP TABLE half`),
+		"0003_never.up.sql":   file(`CREATE TABLE never (id INT)`),
+		"0003_never.down.sql": file(`DROP TABLE never`),
+	})
+	require.NoError(t, err)
+
+	applied, err := New(pool, migrations).Up(ctx)
+	require.ErrorContains(t, err, "apply migration 2_bad")
+	require.Len(t, applied, 1)
+
+	var half *string
+	require.NoError(t, pool.QueryRow(ctx, `SELECT to_regclass('half')::text`).Scan(&half))
+	assert.Nil(t, half, "the failed migration's statements are rolled back")
+	assert.EqualError(t, New(pool, migrations).Check(ctx), "pending migrations: 2, 3")
+}
+
+func TestMigrator_ConcurrentUpAppliesOnce(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	migrations, err := Load(testFS)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	counts := make([]int, 4)
+	for i := range counts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			applied, err := New(pool, migrations).Up(ctx)
+			assert.NoError(t, err)
+			counts[i] = len(applied)
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	assert.Equal(t, 2, total, "each migration is applied by exactly one migrator")
+}
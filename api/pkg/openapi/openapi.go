@@ -0,0 +1,34 @@
+// Package openapi serves the OpenAPI 3 document describing the HTTP API, from which
+// typed clients can be generated. The document is maintained by hand next to the
+// handlers; its tests fail when the two drift apart.
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Path is where the document is served, relative to the API prefix.
+const Path = "/openapi.json"
+
+//go:embed openapi.json
+var document []byte
+
+// Document returns the OpenAPI document as JSON.
+func Document() []byte {
+	return document
+}
+
+// LoadRoutes registers the document endpoint on the given router.
+func LoadRoutes(parentRouter *mux.Router) {
+	parentRouter.HandleFunc(Path, HandleDocument).Methods("GET")
+}
+
+// HandleDocument serves the OpenAPI document.
+func HandleDocument(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(document)
+}
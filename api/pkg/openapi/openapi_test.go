@@ -0,0 +1,201 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workflow-code-test/api/pkg/auth"
+	"workflow-code-test/api/pkg/openapi"
+	"workflow-code-test/api/pkg/resilience"
+	"workflow-code-test/api/pkg/secrets"
+	"workflow-code-test/api/services/workflow"
+)
+
+type document struct {
+	OpenAPI    string                                `json:"openapi"`
+	Servers    []struct{ URL string }                `json:"servers"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+			Required   []string                   `json:"required"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadDocument(t *testing.T) document {
+	t.Helper()
+	var doc document
+	require.NoError(t, json.Unmarshal(openapi.Document(), &doc))
+	return doc
+}
+
+// apiRouter registers every API handler the way main.go does.
+func apiRouter() *mux.Router {
+	router := mux.NewRouter().PathPrefix("/api/v1").Subrouter()
+	openapi.LoadRoutes(router)
+	auth.NewKeyHandler(nil, nil, nil).LoadRoutes(router)
+	auth.NewBindingHandler(nil, nil).LoadRoutes(router)
+	secrets.NewHandler(nil, nil).LoadRoutes(router)
+	(&workflow.Service{}).LoadRoutes(router)
+	return router
+}
+
+func TestDocument_CoversEveryRoute(t *testing.T) {
+	doc := loadDocument(t)
+	require.True(t, strings.HasPrefix(doc.OpenAPI, "3."))
+	require.Len(t, doc.Servers, 1)
+	prefix := doc.Servers[0].URL
+
+	var routes []string
+	err := apiRouter().Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil // Subrouter prefixes have no methods
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		for _, m := range methods {
+			routes = append(routes, m+" "+strings.TrimPrefix(path, prefix))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	var documented []string
+	for path, item := range doc.Paths {
+		for method := range item {
+			if method != "parameters" {
+				documented = append(documented, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+	sort.Strings(routes)
+	sort.Strings(documented)
+	assert.Equal(t, routes, documented, "routes and openapi.json paths differ")
+}
+
+func TestDocument_SchemasMatchModels(t *testing.T) {
+	doc := loadDocument(t)
+	models := map[string]any{
+		"Workflow":           workflow.Workflow{},
+		"Node":               workflow.Node{},
+		"Position":           workflow.Position{},
+		"NodeData":           workflow.NodeData{},
+		"Edge":               workflow.Edge{},
+		"ExecuteRequest":     workflow.ExecuteRequest{},
+		"ConditionInput":     workflow.ConditionInput{},
+		"ExecutionResults":   workflow.ExecutionResults{},
+		"ExecutionStep":      workflow.ExecutionStep{},
+		"StepLogEntry":       workflow.StepLogEntry{},
+		"Schedule":           workflow.Schedule{},
+		"TestCase":           workflow.TestCase{},
+		"TestExpectation":    workflow.TestExpectation{},
+		"TestResult":         workflow.TestResult{},
+		"TestReport":         workflow.TestReport{},
+		"NodeType":           workflow.NodeTypeDescriptor{},
+		"VariableDescriptor": workflow.VariableDescriptor{},
+		"HandleDescriptor":   workflow.HandleDescriptor{},
+		"Diagnostics":        workflow.DiagnosticsResponse{},
+		"BreakerSnapshot":    resilience.BreakerSnapshot{},
+		"APIKey":             auth.APIKey{},
+		"RoleBinding":        auth.RoleBinding{},
+		"Secret":             secrets.Secret{},
+	}
+	for name, model := range models {
+		t.Run(name, func(t *testing.T) {
+			schema, ok := doc.Components.Schemas[name]
+			require.True(t, ok, "schema %s is missing", name)
+
+			fields := jsonFields(reflect.TypeOf(model))
+			var documented []string
+			for prop := range schema.Properties {
+				documented = append(documented, prop)
+			}
+			var names []string
+			for field := range fields {
+				names = append(names, field)
+			}
+			assert.ElementsMatch(t, names, documented, "properties differ from the Go type's JSON fields")
+			for _, req := range schema.Required {
+				assert.False(t, fields[req], "required property %s is omitempty", req)
+			}
+		})
+	}
+}
+
+// jsonFields returns the JSON names of a struct's fields, mapped to whether they are omitempty.
+func jsonFields(typ reflect.Type) map[string]bool {
+	fields := make(map[string]bool)
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			for k, v := range jsonFields(f.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = strings.Contains(opts, "omitempty")
+	}
+	return fields
+}
+
+func TestDocument_ReferencesResolve(t *testing.T) {
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(openapi.Document(), &raw))
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, item := range v {
+				if ref, ok := item.(string); ok && k == "$ref" {
+					path := strings.Split(strings.TrimPrefix(ref, "#/"), "/")
+					var target any = raw
+					for _, seg := range path {
+						m, _ := target.(map[string]any)
+						target = m[seg]
+					}
+					assert.NotNil(t, target, "unresolved $ref %s", ref)
+					continue
+				}
+				walk(item)
+			}
+		case []any:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(raw)
+}
+
+func TestHandleDocument(t *testing.T) {
+	router := mux.NewRouter()
+	openapi.LoadRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", openapi.Path, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(openapi.Document()), w.Body.String())
+}
@@ -0,0 +1,205 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when a call is rejected because the breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// State is the current position of a circuit breaker.
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig holds the thresholds that drive state transitions.
+type BreakerConfig struct {
+	FailureThreshold int           `yaml:"failureThreshold"` // Consecutive failures that trip the breaker open
+	OpenTimeout      time.Duration `yaml:"openTimeout"`      // How long to stay open before probing in half-open
+	HalfOpenMaxCalls int           `yaml:"halfOpenMaxCalls"` // Concurrent probe calls allowed while half-open
+	SuccessThreshold int           `yaml:"successThreshold"` // Consecutive probe successes needed to close again
+}
+
+// DefaultBreakerConfig returns thresholds suitable for a single public HTTP API.
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+	}
+}
+
+// BreakerSnapshot is a point-in-time view of a breaker for diagnostics.
+type BreakerSnapshot struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+}
+
+// Breaker is a closed/open/half-open circuit breaker. It is safe for concurrent use.
+type Breaker struct {
+	name string
+	cfg  BreakerConfig
+	now  func() time.Time
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	successes int
+	inFlight  int
+	openedAt  time.Time
+	lastError string
+}
+
+// NewBreaker creates a closed breaker. Zero-valued config fields fall back to defaults.
+func NewBreaker(name string, cfg BreakerConfig) *Breaker {
+	def := DefaultBreakerConfig()
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = def.FailureThreshold
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = def.OpenTimeout
+	}
+	if cfg.HalfOpenMaxCalls <= 0 {
+		cfg.HalfOpenMaxCalls = def.HalfOpenMaxCalls
+	}
+	if cfg.SuccessThreshold <= 0 {
+		cfg.SuccessThreshold = def.SuccessThreshold
+	}
+	return &Breaker{name: name, cfg: cfg, now: time.Now}
+}
+
+// Name returns the name the breaker was created with.
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Do runs fn if the breaker admits the call and records its outcome.
+// Errors caused by the caller's own context being cancelled are not counted as failures,
+// and neither are panics, which are passed on once the call's slot is freed.
+func (b *Breaker) Do(ctx context.Context, fn func(context.Context) error) error {
+	if err := b.acquire(); err != nil {
+		return err
+	}
+	defer b.release()
+
+	err := fn(ctx)
+	if err == nil || ctx.Err() == nil {
+		b.record(err)
+	}
+	return err
+}
+
+// State returns the current state, moving open to half-open if the timeout has elapsed.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	return b.state
+}
+
+// Snapshot returns the breaker's current state for diagnostics endpoints.
+func (b *Breaker) Snapshot() BreakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+
+	snap := BreakerSnapshot{
+		Name:                b.name,
+		State:               b.state.String(),
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt.UTC()
+		snap.OpenedAt = &openedAt
+	}
+	return snap
+}
+
+func (b *Breaker) acquire() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+
+	switch b.state {
+	case StateOpen:
+		return ErrCircuitOpen
+	case StateHalfOpen:
+		if b.inFlight >= b.cfg.HalfOpenMaxCalls {
+			return ErrCircuitOpen
+		}
+	}
+	b.inFlight++
+	return nil
+}
+
+func (b *Breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.inFlight--
+}
+
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err != nil {
+		b.lastError = err.Error()
+		b.successes = 0
+		b.failures++
+		if b.state == StateHalfOpen || b.failures >= b.cfg.FailureThreshold {
+			b.trip()
+		}
+		return
+	}
+
+	b.failures = 0
+	if b.state == StateHalfOpen {
+		b.successes++
+		if b.successes >= b.cfg.SuccessThreshold {
+			b.state = StateClosed
+			b.successes = 0
+			b.lastError = ""
+		}
+	}
+}
+
+// advance moves an open breaker to half-open once the open timeout has elapsed.
+// Callers must hold b.mu.
+func (b *Breaker) advance() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.state = StateHalfOpen
+		b.successes = 0
+	}
+}
+
+// trip opens the breaker. Callers must hold b.mu.
+func (b *Breaker) trip() {
+	b.state = StateOpen
+	b.openedAt = b.now()
+	b.successes = 0
+}
@@ -0,0 +1,121 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errBoom = errors.New("boom")
+
+func fail(context.Context) error    { return errBoom }
+func succeed(context.Context) error { return nil }
+
+func newTestBreaker(clock *time.Time) *Breaker {
+	b := NewBreaker("test", BreakerConfig{FailureThreshold: 3, OpenTimeout: 10 * time.Second})
+	b.now = func() time.Time { return *clock }
+	return b
+}
+
+func TestBreaker_OpensAfterThreshold(t *testing.T) {
+	clock := time.Now()
+	b := newTestBreaker(&clock)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		assert.ErrorIs(t, b.Do(ctx, fail), errBoom)
+	}
+	assert.Equal(t, StateClosed, b.State())
+
+	assert.ErrorIs(t, b.Do(ctx, fail), errBoom)
+	assert.Equal(t, StateOpen, b.State())
+
+	called := false
+	err := b.Do(ctx, func(context.Context) error { called = true; return nil })
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.False(t, called, "open breaker must not invoke fn")
+}
+
+func TestBreaker_SuccessResetsFailureCount(t *testing.T) {
+	clock := time.Now()
+	b := newTestBreaker(&clock)
+	ctx := context.Background()
+
+	b.Do(ctx, fail)
+	b.Do(ctx, fail)
+	require.NoError(t, b.Do(ctx, succeed))
+	b.Do(ctx, fail)
+	b.Do(ctx, fail)
+
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_HalfOpenRecovers(t *testing.T) {
+	clock := time.Now()
+	b := newTestBreaker(&clock)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		b.Do(ctx, fail)
+	}
+	clock = clock.Add(10 * time.Second)
+	assert.Equal(t, StateHalfOpen, b.State())
+
+	require.NoError(t, b.Do(ctx, succeed))
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_HalfOpenFailureReopens(t *testing.T) {
+	clock := time.Now()
+	b := newTestBreaker(&clock)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		b.Do(ctx, fail)
+	}
+	clock = clock.Add(10 * time.Second)
+
+	assert.ErrorIs(t, b.Do(ctx, fail), errBoom)
+	assert.Equal(t, StateOpen, b.State())
+
+	snap := b.Snapshot()
+	assert.Equal(t, "open", snap.State)
+	assert.Equal(t, "boom", snap.LastError)
+	assert.NotNil(t, snap.OpenedAt)
+}
+
+func TestBreaker_PanicFreesHalfOpenSlot(t *testing.T) {
+	clock := time.Now()
+	b := newTestBreaker(&clock)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		b.Do(ctx, fail)
+	}
+	clock = clock.Add(10 * time.Second)
+
+	assert.PanicsWithValue(t, "probe exploded", func() {
+		b.Do(ctx, func(context.Context) error { panic("probe exploded") })
+	})
+	assert.Equal(t, StateHalfOpen, b.State())
+
+	require.NoError(t, b.Do(ctx, succeed), "the next probe is admitted")
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_CallerCancellationNotCounted(t *testing.T) {
+	clock := time.Now()
+	b := newTestBreaker(&clock)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for i := 0; i < 5; i++ {
+		b.Do(ctx, func(ctx context.Context) error { return ctx.Err() })
+	}
+
+	assert.Equal(t, StateClosed, b.State())
+}
@@ -0,0 +1,30 @@
+// Package resilience provides circuit breaking and rate limiting for outbound calls.
+package resilience
+
+import "context"
+
+// Guard applies a rate limiter and a circuit breaker to calls against one dependency.
+// Rate limiting happens first so that rejected calls never count against the breaker.
+type Guard struct {
+	Limiter *RateLimiter
+	Breaker *Breaker
+}
+
+// NewGuard creates a Guard with its own limiter and breaker.
+func NewGuard(name string, breaker BreakerConfig, limit RateLimitConfig) *Guard {
+	return &Guard{
+		Limiter: NewRateLimiter(limit),
+		Breaker: NewBreaker(name, breaker),
+	}
+}
+
+// Do runs fn under the guard's rate limit and circuit breaker.
+func (g *Guard) Do(ctx context.Context, fn func(context.Context) error) error {
+	if g.Breaker.State() == StateOpen {
+		return ErrCircuitOpen
+	}
+	if err := g.Limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return g.Breaker.Do(ctx, fn)
+}
@@ -0,0 +1,108 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when a call cannot obtain a token before its wait budget runs out.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimitConfig configures a token bucket.
+type RateLimitConfig struct {
+	RequestsPerSecond float64       `yaml:"requestsPerSecond"` // Steady-state refill rate
+	Burst             int           `yaml:"burst"`             // Bucket capacity
+	MaxWait           time.Duration `yaml:"maxWait"`           // Longest a caller will queue for a token; 0 means fail immediately
+}
+
+// DefaultRateLimitConfig returns a conservative limit for a free public API.
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		RequestsPerSecond: 10,
+		Burst:             20,
+		MaxWait:           2 * time.Second,
+	}
+}
+
+// RateLimiter is a token-bucket rate limiter. It is safe for concurrent use.
+type RateLimiter struct {
+	cfg RateLimitConfig
+	now func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter whose bucket starts full.
+// Zero-valued rate or burst fall back to defaults.
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	def := DefaultRateLimitConfig()
+	if cfg.RequestsPerSecond <= 0 {
+		cfg.RequestsPerSecond = def.RequestsPerSecond
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = def.Burst
+	}
+	l := &RateLimiter{cfg: cfg, now: time.Now, tokens: float64(cfg.Burst)}
+	l.last = l.now()
+	return l
+}
+
+// Wait takes a token, queueing for up to MaxWait if the bucket is empty.
+// It returns ErrRateLimited if no token would be available in time, or the context error
+// if ctx is done first.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	delay, ok := l.reserve()
+	if !ok {
+		return ErrRateLimited
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token, possibly going into debt, and reports how long the caller
+// must wait before using it. It refuses if the wait would exceed MaxWait.
+func (l *RateLimiter) reserve() (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.tokens += now.Sub(l.last).Seconds() * l.cfg.RequestsPerSecond
+	if l.tokens > float64(l.cfg.Burst) {
+		l.tokens = float64(l.cfg.Burst)
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0, true
+	}
+
+	delay := time.Duration((1 - l.tokens) / l.cfg.RequestsPerSecond * float64(time.Second))
+	if delay > l.cfg.MaxWait {
+		return 0, false
+	}
+	l.tokens--
+	return delay, true
+}
+
+// cancel returns a reserved token to the bucket.
+func (l *RateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens++
+}
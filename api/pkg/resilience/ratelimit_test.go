@@ -0,0 +1,43 @@
+package resilience
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_BurstThenRejects(t *testing.T) {
+	clock := time.Now()
+	l := NewRateLimiter(RateLimitConfig{RequestsPerSecond: 1, Burst: 2})
+	l.now = func() time.Time { return clock }
+	l.last = clock
+	ctx := context.Background()
+
+	require.NoError(t, l.Wait(ctx))
+	require.NoError(t, l.Wait(ctx))
+	assert.ErrorIs(t, l.Wait(ctx), ErrRateLimited)
+
+	clock = clock.Add(time.Second)
+	assert.NoError(t, l.Wait(ctx))
+}
+
+func TestRateLimiter_WaitsWithinBudget(t *testing.T) {
+	l := NewRateLimiter(RateLimitConfig{RequestsPerSecond: 100, Burst: 1, MaxWait: time.Second})
+	ctx := context.Background()
+
+	require.NoError(t, l.Wait(ctx))
+	start := time.Now()
+	require.NoError(t, l.Wait(ctx))
+	assert.GreaterOrEqual(t, time.Since(start), 5*time.Millisecond)
+}
+
+func TestGuard_OpenBreakerSkipsLimiter(t *testing.T) {
+	g := NewGuard("test", BreakerConfig{FailureThreshold: 1}, RateLimitConfig{RequestsPerSecond: 1, Burst: 1})
+	ctx := context.Background()
+
+	assert.ErrorIs(t, g.Do(ctx, fail), errBoom)
+	assert.ErrorIs(t, g.Do(ctx, succeed), ErrCircuitOpen)
+}
@@ -0,0 +1,94 @@
+// Package secrets stores credentials used by workflow nodes, encrypted at rest with
+// AES-256-GCM, and resolves them by name at execution time.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeySize is the length of the encryption key: AES-256.
+const KeySize = 32
+
+// Cipher encrypts secret values with AES-GCM. Each value is sealed with a fresh random
+// nonce and bound to additional data, so ciphertext cannot be moved between secrets.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a cipher from a KeySize-byte key.
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("secrets key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Seal encrypts plaintext and returns the nonce followed by the ciphertext.
+func (c *Cipher) Seal(plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Open decrypts data produced by Seal with the same additional data.
+func (c *Cipher) Open(data, additionalData []byte) ([]byte, error) {
+	n := c.aead.NonceSize()
+	if len(data) < n {
+		return nil, errors.New("ciphertext too short")
+	}
+	plaintext, err := c.aead.Open(nil, data[:n], data[n:], additionalData)
+	if err != nil {
+		return nil, errors.New("decrypt secret: wrong key or corrupted ciphertext")
+	}
+	return plaintext, nil
+}
+
+// ParseKey decodes a KeySize-byte key given as base64 or hex, e.g. the output of
+// "openssl rand -base64 32".
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("secrets key must be %d bytes encoded as base64 or hex", KeySize)
+}
+
+// LoadKey returns the key given directly in value or, if value is empty, read from the
+// file at path. It returns nil, nil if neither is set.
+func LoadKey(value, path string) ([]byte, error) {
+	if value != "" && path != "" {
+		return nil, errors.New("set either a secrets key or a key file, not both")
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read secrets key file: %w", err)
+		}
+		value = string(data)
+	}
+	if value == "" {
+		return nil, nil
+	}
+	return ParseKey(value)
+}
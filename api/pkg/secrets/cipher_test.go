@@ -0,0 +1,76 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testKey = bytes.Repeat([]byte{7}, KeySize)
+
+func TestCipher_RoundTrip(t *testing.T) {
+	c, err := NewCipher(testKey)
+	require.NoError(t, err)
+
+	sealed, err := c.Seal([]byte("hunter2"), []byte("default\x00SMTP_PASSWORD"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "hunter2")
+
+	again, err := c.Seal([]byte("hunter2"), []byte("default\x00SMTP_PASSWORD"))
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again, "each seal uses a fresh nonce")
+
+	plaintext, err := c.Open(sealed, []byte("default\x00SMTP_PASSWORD"))
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", string(plaintext))
+}
+
+func TestCipher_OpenRejects(t *testing.T) {
+	c, err := NewCipher(testKey)
+	require.NoError(t, err)
+	sealed, err := c.Seal([]byte("hunter2"), []byte("default\x00A"))
+	require.NoError(t, err)
+
+	_, err = c.Open(sealed, []byte("default\x00B"))
+	assert.Error(t, err, "ciphertext moved to another secret")
+
+	other, err := NewCipher(bytes.Repeat([]byte{8}, KeySize))
+	require.NoError(t, err)
+	_, err = other.Open(sealed, []byte("default\x00A"))
+	assert.Error(t, err, "wrong key")
+
+	_, err = c.Open(sealed[:4], []byte("default\x00A"))
+	assert.Error(t, err, "truncated")
+
+	_, err = NewCipher(testKey[:16])
+	assert.Error(t, err)
+}
+
+func TestLoadKey(t *testing.T) {
+	b64 := base64.StdEncoding.EncodeToString(testKey)
+	path := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(path, []byte(hex.EncodeToString(testKey)+"\n"), 0o600))
+
+	key, err := LoadKey(b64, "")
+	require.NoError(t, err)
+	assert.Equal(t, testKey, key)
+
+	key, err = LoadKey("", path)
+	require.NoError(t, err)
+	assert.Equal(t, testKey, key)
+
+	key, err = LoadKey("", "")
+	require.NoError(t, err)
+	assert.Nil(t, key)
+
+	_, err = LoadKey(b64, path)
+	assert.Error(t, err)
+	_, err = LoadKey("too-short", "")
+	assert.Error(t, err)
+}
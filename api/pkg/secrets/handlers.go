@@ -0,0 +1,120 @@
+package secrets
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"workflow-code-test/api/pkg/auth"
+	"workflow-code-test/api/pkg/logging"
+)
+
+// Handler serves the secret management endpoints for the caller's tenant. Responses never
+// include secret values; they can only be written and referenced from workflow nodes.
+type Handler struct {
+	manager *Manager
+	authz   *auth.Authorizer
+}
+
+// NewHandler creates the secret management handlers.
+func NewHandler(manager *Manager, authz *auth.Authorizer) *Handler {
+	return &Handler{manager: manager, authz: authz}
+}
+
+// putSecretRequest is the JSON body for creating or replacing a secret.
+type putSecretRequest struct {
+	Value *string `json:"value"`
+}
+
+// LoadRoutes registers the secret endpoints under /secrets. Listing names needs the view
+// permission; writing and deleting secrets needs admin.
+func (h *Handler) LoadRoutes(parentRouter *mux.Router) {
+	router := parentRouter.PathPrefix("/secrets").Subrouter()
+	router.Handle("", h.authz.Require(auth.PermView)(http.HandlerFunc(h.HandleListSecrets))).Methods("GET")
+	router.Handle("/{name}", h.authz.Require(auth.PermAdmin)(http.HandlerFunc(h.HandlePutSecret))).Methods("PUT")
+	router.Handle("/{name}", h.authz.Require(auth.PermAdmin)(http.HandlerFunc(h.HandleDeleteSecret))).Methods("DELETE")
+}
+
+// HandleListSecrets returns the names and timestamps of the tenant's secrets.
+func (h *Handler) HandleListSecrets(w http.ResponseWriter, r *http.Request) {
+	list, err := h.manager.List(r.Context(), auth.Tenant(r.Context()))
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to list secrets", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"secrets": list})
+}
+
+// HandlePutSecret creates or replaces a secret. The response describes it without its value.
+func (h *Handler) HandlePutSecret(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if !ValidName(name) {
+		writeError(w, http.StatusBadRequest, "invalid secret name")
+		return
+	}
+	var req putSecretRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2*MaxValueSize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Value == nil || *req.Value == "" {
+		writeError(w, http.StatusBadRequest, "value is required")
+		return
+	}
+	if len(*req.Value) > MaxValueSize {
+		writeError(w, http.StatusBadRequest, "value is too large")
+		return
+	}
+
+	updatedBy := ""
+	if p, ok := auth.FromContext(r.Context()); ok {
+		updatedBy = p.Subject
+	}
+	sec, created, err := h.manager.Put(r.Context(), auth.Tenant(r.Context()), name, *req.Value, updatedBy)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to store secret", "name", name, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	logging.FromContext(r.Context()).Info("Stored secret", "name", name, "created", created, "updatedBy", updatedBy)
+	writeJSON(w, status, sec)
+}
+
+// HandleDeleteSecret removes a secret. Workflows still referencing it fail at the step that
+// uses it.
+func (h *Handler) HandleDeleteSecret(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if !ValidName(name) {
+		writeError(w, http.StatusBadRequest, "invalid secret name")
+		return
+	}
+
+	found, err := h.manager.Delete(r.Context(), auth.Tenant(r.Context()), name)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to delete secret", "name", name, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "secret not found")
+		return
+	}
+	logging.FromContext(r.Context()).Info("Deleted secret", "name", name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
@@ -0,0 +1,150 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workflow-code-test/api/pkg/auth"
+)
+
+// memStore implements Store in memory for testing.
+type memStore struct {
+	rows map[string][]byte
+	meta map[string]Secret
+}
+
+func newMemStore() *memStore {
+	return &memStore{rows: map[string][]byte{}, meta: map[string]Secret{}}
+}
+
+func (m *memStore) PutSecret(_ context.Context, tenant string, s *Secret, ciphertext []byte) (bool, error) {
+	k := tenant + "/" + s.Name
+	existing, found := m.meta[k]
+	now := time.Now()
+	s.CreatedAt, s.UpdatedAt = now, now
+	if found {
+		s.CreatedAt = existing.CreatedAt
+	}
+	m.rows[k] = ciphertext
+	m.meta[k] = *s
+	return !found, nil
+}
+
+func (m *memStore) ListSecrets(_ context.Context, tenant string) ([]Secret, error) {
+	list := []Secret{}
+	for k, s := range m.meta {
+		if strings.HasPrefix(k, tenant+"/") {
+			list = append(list, s)
+		}
+	}
+	return list, nil
+}
+
+func (m *memStore) GetCiphertext(_ context.Context, tenant, name string) ([]byte, error) {
+	return m.rows[tenant+"/"+name], nil
+}
+
+func (m *memStore) DeleteSecret(_ context.Context, tenant, name string) (bool, error) {
+	k := tenant + "/" + name
+	_, found := m.rows[k]
+	delete(m.rows, k)
+	delete(m.meta, k)
+	return found, nil
+}
+
+// noBindings is an auth.BindingStore without bindings; test principals carry their roles.
+type noBindings struct{}
+
+func (noBindings) ListBindings(context.Context, string, string) ([]auth.RoleBinding, error) {
+	return nil, nil
+}
+func (noBindings) CreateBinding(context.Context, *auth.RoleBinding) error { return nil }
+func (noBindings) DeleteBinding(context.Context, string, string) (bool, error) {
+	return false, nil
+}
+
+func newTestManager(t *testing.T) (*Manager, *memStore) {
+	t.Helper()
+	c, err := NewCipher(testKey)
+	require.NoError(t, err)
+	store := newMemStore()
+	return NewManager(store, c), store
+}
+
+func serveAs(router http.Handler, principal *auth.Principal, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestManager_ResolveIsTenantScoped(t *testing.T) {
+	ctx := context.Background()
+	m, store := newTestManager(t)
+
+	_, created, err := m.Put(ctx, "default", "API_TOKEN", "s3cret", "key:admin")
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.NotContains(t, string(store.rows["default/API_TOKEN"]), "s3cret")
+
+	v, err := m.Resolve(ctx, "default", "API_TOKEN")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", v)
+
+	_, err = m.Resolve(ctx, "payments", "API_TOKEN")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// A ciphertext copied to another tenant does not decrypt there
+	store.rows["payments/API_TOKEN"] = store.rows["default/API_TOKEN"]
+	_, err = m.Resolve(ctx, "payments", "API_TOKEN")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound)
+}
+
+func TestHandlers(t *testing.T) {
+	m, _ := newTestManager(t)
+	router := mux.NewRouter()
+	NewHandler(m, auth.NewAuthorizer(noBindings{})).LoadRoutes(router)
+	admin := &auth.Principal{Subject: "key:admin", Tenant: "default", Roles: []auth.Role{auth.RoleAdmin}}
+	viewer := &auth.Principal{Subject: "key:viewer", Tenant: "default", Roles: []auth.Role{auth.RoleViewer}}
+
+	w := serveAs(router, admin, "PUT", "/secrets/SMTP_PASSWORD", `{"value": "hunter2"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"SMTP_PASSWORD"`)
+	assert.NotContains(t, w.Body.String(), "hunter2")
+
+	w = serveAs(router, admin, "PUT", "/secrets/SMTP_PASSWORD", `{"value": "hunter3"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	v, err := m.Resolve(context.Background(), "default", "SMTP_PASSWORD")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter3", v)
+
+	w = serveAs(router, viewer, "GET", "/secrets", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "SMTP_PASSWORD")
+	assert.NotContains(t, w.Body.String(), "hunter")
+
+	assert.Equal(t, http.StatusForbidden, serveAs(router, viewer, "PUT", "/secrets/OTHER", `{"value": "x"}`).Code)
+	assert.Equal(t, http.StatusForbidden, serveAs(router, viewer, "DELETE", "/secrets/SMTP_PASSWORD", "").Code)
+
+	assert.Equal(t, http.StatusBadRequest, serveAs(router, admin, "PUT", "/secrets/not-valid", `{"value": "x"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serveAs(router, admin, "PUT", "/secrets/EMPTY", `{"value": ""}`).Code)
+	tooLarge := `{"value": "` + strings.Repeat("x", MaxValueSize+1) + `"}`
+	assert.Equal(t, http.StatusBadRequest, serveAs(router, admin, "PUT", "/secrets/BIG", tooLarge).Code)
+
+	other := &auth.Principal{Subject: "key:admin", Tenant: "payments", Roles: []auth.Role{auth.RoleAdmin}}
+	assert.JSONEq(t, `{"secrets": []}`, serveAs(router, other, "GET", "/secrets", "").Body.String())
+	assert.Equal(t, http.StatusNotFound, serveAs(router, other, "DELETE", "/secrets/SMTP_PASSWORD", "").Code)
+
+	assert.Equal(t, http.StatusNoContent, serveAs(router, admin, "DELETE", "/secrets/SMTP_PASSWORD", "").Code)
+	assert.Equal(t, http.StatusNotFound, serveAs(router, admin, "DELETE", "/secrets/SMTP_PASSWORD", "").Code)
+}
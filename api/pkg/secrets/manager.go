@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrNotFound is returned when resolving a secret that does not exist.
+var ErrNotFound = errors.New("secret not found")
+
+// MaxValueSize is the largest secret value accepted, in bytes.
+const MaxValueSize = 64 << 10
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,127}$`)
+
+// ValidName reports whether s can name a secret: a letter or underscore followed by up
+// to 127 letters, digits or underscores, such as "SMTP_PASSWORD".
+func ValidName(s string) bool {
+	return namePattern.MatchString(s)
+}
+
+// Manager encrypts secrets into a Store and decrypts them on demand.
+type Manager struct {
+	store  Store
+	cipher *Cipher
+}
+
+// NewManager creates a manager that keeps secrets in store, encrypted with cipher.
+func NewManager(store Store, cipher *Cipher) *Manager {
+	return &Manager{store: store, cipher: cipher}
+}
+
+// Put encrypts value and stores it as tenant's secret name, reporting whether it was created.
+func (m *Manager) Put(ctx context.Context, tenant, name, value, updatedBy string) (*Secret, bool, error) {
+	ciphertext, err := m.cipher.Seal([]byte(value), additionalData(tenant, name))
+	if err != nil {
+		return nil, false, err
+	}
+	sec := &Secret{Name: name, UpdatedBy: updatedBy}
+	created, err := m.store.PutSecret(ctx, tenant, sec, ciphertext)
+	if err != nil {
+		return nil, false, err
+	}
+	return sec, created, nil
+}
+
+// List returns tenant's secrets without their values.
+func (m *Manager) List(ctx context.Context, tenant string) ([]Secret, error) {
+	return m.store.ListSecrets(ctx, tenant)
+}
+
+// Delete removes tenant's secret name. Returns false if it did not exist.
+func (m *Manager) Delete(ctx context.Context, tenant, name string) (bool, error) {
+	return m.store.DeleteSecret(ctx, tenant, name)
+}
+
+// Resolve decrypts tenant's secret name. It returns ErrNotFound if there is no such secret.
+func (m *Manager) Resolve(ctx context.Context, tenant, name string) (string, error) {
+	ciphertext, err := m.store.GetCiphertext(ctx, tenant, name)
+	if err != nil {
+		return "", err
+	}
+	if ciphertext == nil {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	plaintext, err := m.cipher.Open(ciphertext, additionalData(tenant, name))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// additionalData binds a ciphertext to the secret it was stored as, so a row copied to
+// another name or tenant fails to decrypt.
+func additionalData(tenant, name string) []byte {
+	return []byte(tenant + "\x00" + name)
+}
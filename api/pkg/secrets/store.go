@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Secret describes a stored secret. Its value is never part of it.
+type Secret struct {
+	Name      string    `json:"name"`
+	UpdatedBy string    `json:"updatedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Store persists encrypted secrets. Secrets are namespaced by tenant.
+type Store interface {
+	// PutSecret inserts or replaces a secret, fills in its timestamps and reports whether it was created.
+	PutSecret(ctx context.Context, tenant string, s *Secret, ciphertext []byte) (bool, error)
+	ListSecrets(ctx context.Context, tenant string) ([]Secret, error)
+	// GetCiphertext returns a secret's encrypted value. Returns nil, nil if not found.
+	GetCiphertext(ctx context.Context, tenant, name string) ([]byte, error)
+	// DeleteSecret removes a secret. Returns false if it did not exist.
+	DeleteSecret(ctx context.Context, tenant, name string) (bool, error)
+}
+
+// PostgresStore is a Store backed by the secrets table.
+type PostgresStore struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresStore creates a secret store on the given connection pool.
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{db: pool}
+}
+
+// PutSecret inserts or replaces a secret. xmax is zero only for freshly inserted rows.
+func (s *PostgresStore) PutSecret(ctx context.Context, tenant string, sec *Secret, ciphertext []byte) (bool, error) {
+	var created bool
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO secrets (tenant_id, name, ciphertext, updated_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id, name) DO UPDATE
+		SET ciphertext = EXCLUDED.ciphertext, updated_by = EXCLUDED.updated_by, updated_at = NOW()
+		RETURNING created_at, updated_at, xmax = 0
+	`, tenant, sec.Name, ciphertext, sec.UpdatedBy).Scan(&sec.CreatedAt, &sec.UpdatedAt, &created)
+	if err != nil {
+		return false, fmt.Errorf("put secret: %w", err)
+	}
+	return created, nil
+}
+
+// ListSecrets returns tenant's secrets ordered by name.
+func (s *PostgresStore) ListSecrets(ctx context.Context, tenant string) ([]Secret, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT name, updated_by, created_at, updated_at FROM secrets
+		WHERE tenant_id = $1 ORDER BY name
+	`, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("list secrets: %w", err)
+	}
+	defer rows.Close()
+
+	list := []Secret{}
+	for rows.Next() {
+		var sec Secret
+		if err := rows.Scan(&sec.Name, &sec.UpdatedBy, &sec.CreatedAt, &sec.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan secret: %w", err)
+		}
+		list = append(list, sec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list secrets: %w", err)
+	}
+	return list, nil
+}
+
+// GetCiphertext returns a secret's encrypted value. Returns nil, nil if not found.
+func (s *PostgresStore) GetCiphertext(ctx context.Context, tenant, name string) ([]byte, error) {
+	var ciphertext []byte
+	err := s.db.QueryRow(ctx, `SELECT ciphertext FROM secrets WHERE tenant_id = $1 AND name = $2`, tenant, name).Scan(&ciphertext)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get secret: %w", err)
+	}
+	return ciphertext, nil
+}
+
+// DeleteSecret removes a secret. Returns false if it did not exist.
+func (s *PostgresStore) DeleteSecret(ctx context.Context, tenant, name string) (bool, error) {
+	tag, err := s.db.Exec(ctx, `DELETE FROM secrets WHERE tenant_id = $1 AND name = $2`, tenant, name)
+	if err != nil {
+		return false, fmt.Errorf("delete secret: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
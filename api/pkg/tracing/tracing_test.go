@@ -0,0 +1,138 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	recorderOnce sync.Once
+	recorder     *tracetest.SpanRecorder
+)
+
+// spanRecorder installs a global tracer provider that records spans. Tracers bind to the
+// first provider installed, so every test shares one recorder.
+func spanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorderOnce.Do(func() {
+		recorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	return recorder
+}
+
+// endedSpan returns the ended span in traceID named name.
+func endedSpan(t *testing.T, rec *tracetest.SpanRecorder, traceID trace.TraceID, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, s := range rec.Ended() {
+		if s.SpanContext().TraceID() == traceID && s.Name() == name {
+			return s
+		}
+	}
+	require.Failf(t, "span not found", "%s in trace %s", name, traceID)
+	return nil
+}
+
+func TestMiddleware_ContinuesTraceAndNamesSpanByRoute(t *testing.T) {
+	rec := spanRecorder(t)
+	router := mux.NewRouter()
+	router.Use(Middleware)
+	router.HandleFunc("/api/v1/workflows/{id}", func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, trace.SpanFromContext(r.Context()).SpanContext().IsValid())
+	})
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest("GET", "/api/v1/workflows/abc", nil)
+	req.Header.Set("traceparent", traceparent)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	span := endedSpan(t, rec, traceID, "GET /api/v1/workflows/{id}")
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+}
+
+func TestTransport_PropagatesTraceContext(t *testing.T) {
+	rec := spanRecorder(t)
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("traceparent")
+	}))
+	defer srv.Close()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	parent.End()
+
+	traceID := parent.SpanContext().TraceID()
+	assert.Contains(t, got, traceID.String())
+	client := endedSpan(t, rec, traceID, "HTTP GET")
+	assert.Equal(t, trace.SpanKindClient, client.SpanKind())
+}
+
+func TestQueryTracer(t *testing.T) {
+	rec := spanRecorder(t)
+	tracer := QueryTracer{}
+
+	// Untraced queries do not start a trace of their own
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	assert.False(t, trace.SpanFromContext(ctx).SpanContext().IsValid())
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+
+	parentCtx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	ctx = tracer.TraceQueryStart(parentCtx, nil, pgx.TraceQueryStartData{SQL: "\n\t\tSELECT id FROM workflows"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+	ctx = tracer.TraceQueryStart(parentCtx, nil, pgx.TraceQueryStartData{SQL: "update workflows SET name = $1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("connection reset")})
+	parent.End()
+
+	traceID := parent.SpanContext().TraceID()
+	assert.Equal(t, codes.Unset, endedSpan(t, rec, traceID, "db SELECT").Status().Code)
+	failed := endedSpan(t, rec, traceID, "db UPDATE")
+	assert.Equal(t, codes.Error, failed.Status().Code)
+	assert.Equal(t, parent.SpanContext().SpanID(), failed.Parent().SpanID())
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{Exporter: ExporterNone})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), Config{Exporter: "zipkin"})
+	assert.EqualError(t, err, `unknown trace exporter "zipkin"`)
+}
+
+func TestSetup_Console(t *testing.T) {
+	spanRecorder(t) // Keep the shared recorder installed first
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	var out bytes.Buffer
+	shutdown, err := Setup(context.Background(), Config{ServiceName: "workflow-api", Exporter: ExporterConsole, Output: &out})
+	require.NoError(t, err)
+
+	_, span := otel.GetTracerProvider().Tracer("test").Start(context.Background(), "console-span")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+	assert.Contains(t, out.String(), `"Name":"console-span"`)
+	assert.Contains(t, out.String(), "workflow-api")
+}
@@ -0,0 +1,286 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workflow-code-test/api/pkg/auth"
+)
+
+const (
+	weatherWorkflowID = "550e8400-e29b-41d4-a716-446655440000"
+	otherWorkflowID   = "00000000-0000-0000-0000-000000000001"
+	workflowPath      = "/api/v1/workflows/" + weatherWorkflowID
+)
+
+// memBindingStore implements auth.BindingStore in memory for testing.
+type memBindingStore struct {
+	bindings []auth.RoleBinding
+}
+
+func (m *memBindingStore) ListBindings(_ context.Context, tenant, subject string) ([]auth.RoleBinding, error) {
+	var out []auth.RoleBinding
+	for _, b := range m.bindings {
+		if b.Tenant == tenant && (subject == "" || b.Subject == subject) {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+func (m *memBindingStore) CreateBinding(_ context.Context, b *auth.RoleBinding) error {
+	m.bindings = append(m.bindings, *b)
+	return nil
+}
+
+func (m *memBindingStore) DeleteBinding(context.Context, string, string) (bool, error) {
+	return false, nil
+}
+
+// grant binds role to subject in the default tenant.
+func (m *memBindingStore) grant(subject string, role auth.Role, workflowID string) {
+	m.bindings = append(m.bindings, auth.RoleBinding{Tenant: auth.DefaultTenant, Subject: subject, Role: role, WorkflowID: workflowID})
+}
+
+// newAccessRouter returns a router for svc that authorizes against bindings and
+// authenticates every request as subject.
+func newAccessRouter(svc *Service, bindings *memBindingStore, subject string) *mux.Router {
+	svc.authz = auth.NewAuthorizer(bindings)
+	router := setupRouter(svc)
+	router.HandleFunc("/api/v1/diagnostics", svc.HandleDiagnostics).Methods("GET")
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := &auth.Principal{Subject: subject, Method: auth.MethodAPIKey}
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+		})
+	})
+	return router
+}
+
+func serve(router http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func updateBody(t *testing.T) string {
+	t.Helper()
+	wf := testWorkflow()
+	body, err := json.Marshal(updateWorkflowRequest{Name: "Renamed", Nodes: wf.Nodes, Edges: wf.Edges})
+	require.NoError(t, err)
+	return string(body)
+}
+
+func errorMessage(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var result map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	return result["message"]
+}
+
+func TestAccess_ContractorCanRunButNotEdit(t *testing.T) {
+	bindings := &memBindingStore{}
+	bindings.grant("key:contractor", auth.RoleRunner, weatherWorkflowID)
+	router := newAccessRouter(newTestService(testWorkflow(), 30.0), bindings, "key:contractor")
+
+	assert.Equal(t, http.StatusOK, serve(router, "GET", workflowPath, "").Code)
+	assert.Equal(t, http.StatusOK, serve(router, "POST", executePath, executeBody).Code)
+
+	w := serve(router, "PUT", workflowPath, updateBody(t))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "edit permission required", errorMessage(t, w))
+}
+
+func TestAccess_WorkflowBindingDoesNotApplyElsewhere(t *testing.T) {
+	bindings := &memBindingStore{}
+	bindings.grant("key:contractor", auth.RoleRunner, otherWorkflowID)
+	router := newAccessRouter(newTestService(testWorkflow(), 30.0), bindings, "key:contractor")
+
+	w := serve(router, "GET", workflowPath, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "view permission required", errorMessage(t, w))
+}
+
+func TestAccess_ViewerCannotExecute(t *testing.T) {
+	bindings := &memBindingStore{}
+	bindings.grant("key:viewer", auth.RoleViewer, "")
+	router := newAccessRouter(newTestService(testWorkflow(), 30.0), bindings, "key:viewer")
+
+	assert.Equal(t, http.StatusOK, serve(router, "GET", workflowPath, "").Code)
+	assert.Equal(t, http.StatusOK, serve(router, "GET", workflowPath+"/schedules", "").Code)
+
+	w := serve(router, "POST", executePath, executeBody)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "run permission required", errorMessage(t, w))
+
+	w = serve(router, "POST", workflowPath+"/schedules", `{"cron": "0 9 * * *"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serve(router, "POST", workflowPath+"/tests/run", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAccess_ForbiddenResponsesAreNotStoredForIdempotency(t *testing.T) {
+	svc := newTestService(testWorkflow(), 30.0)
+	bindings := &memBindingStore{}
+	router := newAccessRouter(svc, bindings, "key:late")
+
+	assert.Equal(t, http.StatusForbidden, executeWithKey(router, "retry-1", executeBody).Code)
+
+	bindings.grant("key:late", auth.RoleRunner, "")
+	w := executeWithKey(router, "retry-1", executeBody)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(idempotentReplayed))
+}
+
+func TestAccess_IdempotencyKeysAreScopedByCaller(t *testing.T) {
+	svc := newTestService(testWorkflow(), 30.0)
+	bindings := &memBindingStore{}
+	bindings.grant("key:alice", auth.RoleRunner, "")
+	bindings.grant("key:bob", auth.RoleRunner, "")
+
+	first := executeWithKey(newAccessRouter(svc, bindings, "key:alice"), "shared", executeBody)
+	second := executeWithKey(newAccessRouter(svc, bindings, "key:bob"), "shared", executeBody)
+	require.Equal(t, http.StatusOK, first.Code)
+	require.Equal(t, http.StatusOK, second.Code)
+	assert.Empty(t, second.Header().Get(idempotentReplayed))
+	assert.NotEqual(t, executionID(t, first), executionID(t, second))
+}
+
+func TestAccess_DiagnosticsRequireAdmin(t *testing.T) {
+	bindings := &memBindingStore{}
+	bindings.grant("key:editor", auth.RoleEditor, "")
+	router := newAccessRouter(newTestService(testWorkflow(), 30.0), bindings, "key:editor")
+
+	assert.Equal(t, http.StatusForbidden, serve(router, "GET", "/api/v1/diagnostics", "").Code)
+
+	bindings.grant("key:editor", auth.RoleAdmin, "")
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/api/v1/diagnostics", "").Code)
+}
+
+func TestHandleUpdateWorkflow_Success(t *testing.T) {
+	svc := newTestService(testWorkflow(), 0)
+	router := setupRouter(svc)
+
+	w := serve(router, "PUT", workflowPath, updateBody(t))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var result Workflow
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, weatherWorkflowID, result.ID)
+	assert.Equal(t, "Renamed", result.Name)
+	assert.Len(t, result.Nodes, 6)
+}
+
+func TestHandleUpdateWorkflow_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{"no nodes", `{"name": "x", "nodes": []}`, "nodes is required"},
+		{"no start node", `{"nodes": [{"id": "a", "type": "end"}]}`, "nodes is invalid"},
+		{"duplicate node id", `{"nodes": [{"id": "a", "type": "start"}, {"id": "a", "type": "end"}]}`, "nodes is invalid"},
+		{"dangling edge", `{"nodes": [{"id": "a", "type": "start"}], "edges": [{"id": "e", "source": "a", "target": "b"}]}`, "edges is invalid"},
+		{"trigger type", `{"nodes": [{"id": "a", "type": "start", "data": {"metadata": {"trigger": {"type": "email", "token": "tok-0123456789abcdef"}}}}]}`, "trigger.type is invalid"},
+		{"short token", `{"nodes": [{"id": "a", "type": "start", "data": {"metadata": {"trigger": {"type": "webhook", "token": "abc", "condition": {"operator": "greater_than"}}}}}]}`, "trigger.token is invalid"},
+		{"token characters", `{"nodes": [{"id": "a", "type": "start", "data": {"metadata": {"trigger": {"type": "webhook", "token": "../../0123456789abcdef", "condition": {"operator": "greater_than"}}}}}]}`, "trigger.token is invalid"},
+		{"literal secret", `{"nodes": [{"id": "a", "type": "start", "data": {"metadata": {"trigger": {"type": "webhook", "token": "tok-0123456789abcdef", "secret": "s3cret", "condition": {"operator": "greater_than"}}}}}]}`, "trigger.secret is invalid"},
+		{"trigger condition", `{"nodes": [{"id": "a", "type": "start", "data": {"metadata": {"trigger": {"type": "webhook", "token": "tok-0123456789abcdef"}}}}]}`, "trigger.condition is invalid"},
+		{"unnamed test", `{"nodes": [{"id": "a", "type": "start"}], "tests": [{"input": ` + executeBody + `}]}`, "tests[0]: name is required"},
+		{"test input", `{"nodes": [{"id": "a", "type": "start"}], "tests": [{"name": "t", "input": {}}]}`, `test "t": formData is required`},
+		{"test expects unknown node", `{"nodes": [{"id": "a", "type": "start"}], "tests": [{"name": "t", "input": ` + executeBody + `, "expect": {"path": ["a", "b"]}}]}`, `test "t": expect.path: node "b" not found`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupRouter(newTestService(testWorkflow(), 0))
+			w := serve(router, "PUT", workflowPath, tt.body)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, tt.message, errorMessage(t, w))
+		})
+	}
+}
+
+func TestHandleUpdateWorkflow_WebhookTriggerNeedsRunPermission(t *testing.T) {
+	bindings := &memBindingStore{}
+	bindings.grant("key:editor", auth.RoleEditor, "")
+	svc := newTestService(testWorkflow(), 30.0)
+	router := newAccessRouter(svc, bindings, "key:editor")
+	hooked := webhookWorkflow("")
+	body, err := json.Marshal(updateWorkflowRequest{Name: "Hooked", Nodes: hooked.Nodes, Edges: hooked.Edges})
+	require.NoError(t, err)
+
+	w := serve(router, "PUT", workflowPath, string(body))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "run permission required", errorMessage(t, w))
+	assert.Equal(t, http.StatusOK, serve(router, "PUT", workflowPath, updateBody(t)).Code, "other edits need only edit")
+
+	bindings.grant("key:editor", auth.RoleRunner, weatherWorkflowID)
+	require.Equal(t, http.StatusOK, serve(router, "PUT", workflowPath, string(body)).Code)
+
+	// Once set, an editor may change the rest of the workflow and keep the trigger
+	bindings.bindings = bindings.bindings[:1]
+	hooked.Nodes[1].Data.Label = "Renamed form"
+	body, err = json.Marshal(updateWorkflowRequest{Name: "Hooked", Nodes: hooked.Nodes, Edges: hooked.Edges})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, serve(router, "PUT", workflowPath, string(body)).Code)
+}
+
+func TestHandleUpdateWorkflow_NotFound(t *testing.T) {
+	router := setupRouter(newTestService(nil, 0))
+	assert.Equal(t, http.StatusNotFound, serve(router, "PUT", workflowPath, updateBody(t)).Code)
+}
+
+func TestAccess_TenantsAreIsolated(t *testing.T) {
+	svc := newTestService(reminderWorkflow(), 30.0)
+	svc.authz = auth.NewAuthorizer(&memBindingStore{})
+	router := setupRouter(svc)
+	tenant := "default"
+	scheduleBody := `{"cron": "0 9 * * *", "input": ` + executeBody + `}`
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := &auth.Principal{Subject: "key:admin", Tenant: tenant, Roles: []auth.Role{auth.RoleAdmin}}
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+		})
+	})
+
+	// The default tenant suspends an execution and schedules its workflow
+	w := serve(router, "POST", executePath, executeBody)
+	require.Equal(t, http.StatusOK, w.Code)
+	executionPath := "/api/v1/executions/" + executionID(t, w)
+	w = serve(router, "POST", workflowPath+"/schedules", scheduleBody)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var sch Schedule
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sch))
+	schedulePath := "/api/v1/schedules/" + sch.ID
+
+	// An admin of another tenant cannot see or touch any of it
+	tenant = "payments"
+	for _, req := range []struct{ method, path, body string }{
+		{"GET", workflowPath, ""},
+		{"PUT", workflowPath, updateBody(t)},
+		{"POST", executePath, executeBody},
+		{"GET", executionPath, ""},
+		{"POST", workflowPath + "/schedules", scheduleBody},
+		{"POST", workflowPath + "/tests/run", ""},
+		{"GET", schedulePath, ""},
+		{"DELETE", schedulePath, ""},
+	} {
+		assert.Equal(t, http.StatusNotFound, serve(router, req.method, req.path, req.body).Code, "%s %s", req.method, req.path)
+	}
+	w = serve(router, "GET", workflowPath+"/schedules", "")
+	assert.JSONEq(t, `{"schedules": []}`, w.Body.String())
+
+	tenant = "default"
+	assert.Equal(t, http.StatusOK, serve(router, "GET", executionPath, "").Code)
+	assert.Equal(t, http.StatusOK, serve(router, "GET", schedulePath, "").Code)
+}
@@ -0,0 +1,78 @@
+package workflow
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// Describer is optionally implemented by a NodeExecutor to describe its node type
+// to editors, so palettes and config forms can be built without hardcoding.
+type Describer interface {
+	Describe() NodeTypeDescriptor
+}
+
+// NodeTypeDescriptor is the catalog entry for a node type.
+type NodeTypeDescriptor struct {
+	Type         string               `json:"type"`
+	DisplayName  string               `json:"displayName"`
+	Category     string               `json:"category"`
+	Description  string               `json:"description,omitempty"`
+	ConfigSchema map[string]any       `json:"configSchema,omitempty"` // JSON Schema for node.data.metadata
+	Inputs       []VariableDescriptor `json:"inputs,omitempty"`
+	Outputs      []VariableDescriptor `json:"outputs,omitempty"`
+	Handles      HandleDescriptor     `json:"handles"`
+}
+
+// VariableDescriptor describes a value a node reads from or writes to the execution.
+type VariableDescriptor struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"` // JSON type: "string", "number", "boolean", "object", "array"
+	Description string `json:"description,omitempty"`
+}
+
+// HandleDescriptor describes a node's connection points. SourceHandles lists named
+// outgoing handles for nodes that branch; it is empty for a single unnamed output.
+type HandleDescriptor struct {
+	Target        bool     `json:"target"`
+	Source        bool     `json:"source"`
+	SourceHandles []string `json:"sourceHandles,omitempty"`
+}
+
+// Types returns the registered node type names, sorted.
+func (r Registry) Types() []string {
+	types := make([]string, 0, len(r))
+	for nodeType := range r {
+		types = append(types, nodeType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Catalog describes every registered node type, sorted by type name.
+// Executors that do not implement Describer get a minimal entry.
+func (r Registry) Catalog() []NodeTypeDescriptor {
+	catalog := make([]NodeTypeDescriptor, 0, len(r))
+	for nodeType, executor := range r {
+		var desc NodeTypeDescriptor
+		if d, ok := executor.(Describer); ok {
+			desc = d.Describe()
+		} else {
+			desc = NodeTypeDescriptor{
+				DisplayName: nodeType,
+				Category:    "other",
+				Handles:     HandleDescriptor{Target: true, Source: true},
+			}
+		}
+		desc.Type = nodeType
+		catalog = append(catalog, desc)
+	}
+	sort.Slice(catalog, func(i, j int) bool { return catalog[i].Type < catalog[j].Type })
+	return catalog
+}
+
+// HandleListNodeTypes returns the node type catalog.
+func (s *Service) HandleListNodeTypes(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"nodeTypes": s.engine.registry.Catalog()})
+}
@@ -0,0 +1,64 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// undescribedExecutor is a NodeExecutor that does not implement Describer.
+type undescribedExecutor struct{}
+
+func (undescribedExecutor) Execute(_ context.Context, node Node, _ *ExecutionState) (*StepResult, error) {
+	return &StepResult{NodeID: node.ID, Status: "completed", Output: map[string]any{"message": "ok"}}, nil
+}
+
+func TestRegistry_Catalog(t *testing.T) {
+	registry := NewRegistry(&mockWeatherClient{}, nil, defaultInlineDelay)
+	registry["webhook"] = undescribedExecutor{}
+	registry.RegisterPlugins([]*PluginExecutor{
+		{descriptor: PluginDescriptor{Type: "uv-index", DisplayName: "UV Index", Handles: &HandleDescriptor{Target: true}}},
+	})
+
+	catalog := registry.Catalog()
+
+	types := make([]string, len(catalog))
+	byType := make(map[string]NodeTypeDescriptor)
+	for i, d := range catalog {
+		types[i] = d.Type
+		byType[d.Type] = d
+	}
+	assert.Equal(t, []string{"condition", "delay", "email", "end", "form", "integration", "start", "uv-index", "webhook"}, types)
+	assert.Equal(t, types, registry.Types())
+
+	assert.Equal(t, []string{"true", "false"}, byType["condition"].Handles.SourceHandles)
+	assert.False(t, byType["start"].Handles.Target)
+	assert.NotEmpty(t, byType["integration"].ConfigSchema)
+	assert.Equal(t, "other", byType["webhook"].Category)
+	assert.Equal(t, "plugin", byType["uv-index"].Category)
+	assert.False(t, byType["uv-index"].Handles.Source)
+}
+
+func TestHandleListNodeTypes(t *testing.T) {
+	svc := newTestService(nil, 0)
+	router := setupRouter(svc)
+	router.HandleFunc("/api/v1/node-types", svc.HandleListNodeTypes).Methods("GET")
+
+	req := httptest.NewRequest("GET", "/api/v1/node-types", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result struct {
+		NodeTypes []NodeTypeDescriptor `json:"nodeTypes"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.Len(t, result.NodeTypes, 7)
+	assert.Equal(t, "Check Condition", result.NodeTypes[0].DisplayName)
+}
@@ -0,0 +1,51 @@
+package workflow
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"workflow-code-test/api/pkg/resilience"
+)
+
+// Config holds tunable settings for the workflow service.
+type Config struct {
+	WeatherURL       string        // Open-Meteo forecast endpoint
+	GeocodingURL     string        // Open-Meteo geocoding search endpoint
+	WeatherTimeout   time.Duration // Longest a single forecast or geocoding request may take
+	WeatherBreaker   resilience.BreakerConfig
+	WeatherRateLimit resilience.RateLimitConfig
+
+	GeocoderOffline bool          // Resolve cities from the bundled gazetteer only
+	GeocodeCacheTTL time.Duration // How long geocoding results, including misses, are reused
+
+	PluginDir string // Directory scanned for executor plugins at startup; empty disables plugins
+
+	SchedulerInterval time.Duration // How often the scheduler polls for due schedules
+
+	DelayInlineMax time.Duration // Longest delay slept through in-process; longer delays suspend the execution
+	TimerInterval  time.Duration // How often suspended executions are checked for resumption
+
+	IdempotencyTTL time.Duration // How long responses to requests with an Idempotency-Key are replayed
+
+	Metrics prometheus.Registerer // Where execution and weather client metrics are registered; nil disables them
+}
+
+// DefaultConfig returns the settings used when nothing is overridden.
+func DefaultConfig() Config {
+	return Config{
+		WeatherURL:       DefaultWeatherURL,
+		GeocodingURL:     DefaultGeocodingURL,
+		WeatherTimeout:   10 * time.Second,
+		WeatherBreaker:   resilience.DefaultBreakerConfig(),
+		WeatherRateLimit: resilience.DefaultRateLimitConfig(),
+		GeocodeCacheTTL:  24 * time.Hour,
+
+		SchedulerInterval: 15 * time.Second,
+
+		DelayInlineMax: defaultInlineDelay,
+		TimerInterval:  5 * time.Second,
+
+		IdempotencyTTL: 24 * time.Hour,
+	}
+}
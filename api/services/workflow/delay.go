@@ -0,0 +1,209 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"workflow-code-test/api/pkg/auth"
+	"workflow-code-test/api/pkg/logging"
+)
+
+// defaultInlineDelay is the longest wait a delay node sleeps through in-process.
+// Longer waits suspend the execution until the TimerService resumes it.
+const defaultInlineDelay = 5 * time.Second
+
+// ExecutionStore persists executions suspended by delay nodes.
+type ExecutionStore interface {
+	// SaveSuspended inserts or replaces a suspended execution and releases any claim on it.
+	SaveSuspended(ctx context.Context, exec *SuspendedExecution) error
+	// FinishExecution stores the final results of a previously suspended execution.
+	FinishExecution(ctx context.Context, results *ExecutionResults) error
+	// ClaimDueExecutions leases up to limit executions due at now until leaseUntil,
+	// so that no other replica resumes them concurrently.
+	ClaimDueExecutions(ctx context.Context, now, leaseUntil time.Time, limit int) ([]SuspendedExecution, error)
+	// GetExecution returns the latest results of one of tenant's persisted executions.
+	// Returns nil, nil if not found.
+	GetExecution(ctx context.Context, tenant, id string) (*ExecutionResults, error)
+}
+
+// DelayExecutor handles the "delay" node type. It waits either a fixed duration
+// (metadata.duration, e.g. "2h", or a number of seconds) or until a point in time
+// (metadata.until, an RFC 3339 timestamp or a "{{variable.path}}" reference to one).
+// Short waits sleep in-process; longer ones suspend the execution.
+type DelayExecutor struct {
+	inlineMax time.Duration
+	now       func() time.Time
+}
+
+// NewDelayExecutor creates a delay executor that sleeps through waits up to inlineMax.
+func NewDelayExecutor(inlineMax time.Duration) *DelayExecutor {
+	return &DelayExecutor{inlineMax: inlineMax, now: time.Now}
+}
+
+func (e *DelayExecutor) Execute(ctx context.Context, node Node, state *ExecutionState) (*StepResult, error) {
+	now := e.now()
+	until, err := delayUntil(node.Data.Metadata, state.Variables, now)
+	if err != nil {
+		return nil, err
+	}
+	wait := until.Sub(now)
+
+	result := &StepResult{
+		NodeID: node.ID, NodeType: node.Type, Label: node.Data.Label,
+		Status: "completed",
+		Output: map[string]any{"resumeAt": until.UTC().Format(time.RFC3339)},
+	}
+
+	switch {
+	case wait <= 0:
+		result.Output["message"] = "Delay already elapsed"
+	case wait <= e.inlineMax:
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		result.Output["message"] = fmt.Sprintf("Waited %s", wait.Round(time.Millisecond))
+	default:
+		result.Status = "waiting"
+		result.ResumeAt = until
+		result.Output["message"] = fmt.Sprintf("Waiting until %s", until.UTC().Format(time.RFC3339))
+		logging.FromContext(ctx).Debug("Suspending execution", "resumeAt", until.UTC())
+	}
+	return result, nil
+}
+
+// Simulate reports when the delay would end without waiting or suspending.
+func (e *DelayExecutor) Simulate(_ context.Context, node Node, state *ExecutionState) (*StepResult, error) {
+	until, err := delayUntil(node.Data.Metadata, state.Variables, e.now())
+	if err != nil {
+		return nil, err
+	}
+	return &StepResult{
+		NodeID: node.ID, NodeType: node.Type, Label: node.Data.Label,
+		Status: "completed",
+		Output: map[string]any{
+			"message":  fmt.Sprintf("Would wait until %s; skipped in a dry run", until.UTC().Format(time.RFC3339)),
+			"resumeAt": until.UTC().Format(time.RFC3339),
+		},
+	}, nil
+}
+
+func (e *DelayExecutor) Describe() NodeTypeDescriptor {
+	return NodeTypeDescriptor{
+		DisplayName: "Delay",
+		Category:    "control",
+		Description: "Waits for a duration or until a point in time before continuing",
+		ConfigSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"duration": map[string]any{
+					"type":        []any{"string", "number"},
+					"description": `Go duration such as "90s" or "2h", or a number of seconds`,
+				},
+				"until": map[string]any{
+					"type":        "string",
+					"description": `RFC 3339 timestamp, or a "{{variable.path}}" reference to one`,
+				},
+			},
+		},
+		Handles: HandleDescriptor{Target: true, Source: true},
+	}
+}
+
+// delayUntil resolves a delay node's metadata to the time it should resume.
+func delayUntil(meta map[string]any, vars map[string]any, now time.Time) (time.Time, error) {
+	duration, hasDuration := meta["duration"]
+	until, hasUntil := meta["until"]
+	switch {
+	case hasDuration && hasUntil:
+		return time.Time{}, fmt.Errorf("delay node sets both duration and until")
+	case hasDuration:
+		d, err := parseDelayDuration(duration)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(d), nil
+	case hasUntil:
+		return parseDelayUntil(until, vars)
+	}
+	return time.Time{}, fmt.Errorf("delay node requires a duration or until")
+}
+
+func parseDelayDuration(v any) (time.Duration, error) {
+	var d time.Duration
+	if s, ok := v.(string); ok {
+		parsed, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil {
+			return 0, fmt.Errorf("invalid delay duration %q", s)
+		}
+		d = parsed
+	} else if secs, ok := toFloat64(v); ok {
+		d = time.Duration(secs * float64(time.Second))
+	} else {
+		return 0, fmt.Errorf("invalid delay duration %v", v)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("delay duration must not be negative")
+	}
+	return d, nil
+}
+
+func parseDelayUntil(v any, vars map[string]any) (time.Time, error) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("delay until must be a string")
+	}
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "{{") && strings.HasSuffix(s, "}}") {
+		path := strings.TrimSpace(s[2 : len(s)-2])
+		resolved, ok := lookupVariable(vars, path)
+		if !ok {
+			return time.Time{}, fmt.Errorf("delay until variable %q not found", path)
+		}
+		if s, ok = resolved.(string); !ok {
+			return time.Time{}, fmt.Errorf("delay until variable %q is not a timestamp", path)
+		}
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid delay until %q", s)
+	}
+	return t, nil
+}
+
+// HandleGetExecution returns the latest results of an execution that was suspended
+// by a delay node, whether it is still waiting or has since finished.
+func (s *Service) HandleGetExecution(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["executionId"]
+	if _, err := uuid.Parse(id); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid execution id")
+		return
+	}
+
+	results, err := s.executions.GetExecution(r.Context(), auth.Tenant(r.Context()), id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get execution", "id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if results == nil {
+		writeError(w, http.StatusNotFound, "execution not found")
+		return
+	}
+	if !s.authorize(w, r, auth.PermView, results.WorkflowID) {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(results)
+}
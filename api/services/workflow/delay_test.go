@@ -0,0 +1,261 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workflow-code-test/api/pkg/auth"
+)
+
+// memExecutionStore implements ExecutionStore in memory for testing.
+type memExecutionStore struct {
+	mu        sync.Mutex
+	suspended map[string]*SuspendedExecution
+	leases    map[string]time.Time
+	results   map[string]ExecutionResults
+	tenants   map[string]string
+}
+
+func newMemExecutionStore() *memExecutionStore {
+	return &memExecutionStore{
+		suspended: make(map[string]*SuspendedExecution),
+		leases:    make(map[string]time.Time),
+		results:   make(map[string]ExecutionResults),
+		tenants:   make(map[string]string),
+	}
+}
+
+func (m *memExecutionStore) SaveSuspended(_ context.Context, exec *SuspendedExecution) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, _ := json.Marshal(exec) // Round-trip like the database would
+	var cp SuspendedExecution
+	json.Unmarshal(data, &cp)
+	m.suspended[exec.ExecutionID] = &cp
+	delete(m.leases, exec.ExecutionID)
+	m.results[exec.ExecutionID] = cp.Results
+	m.tenants[exec.ExecutionID] = exec.TenantID
+	return nil
+}
+
+func (m *memExecutionStore) FinishExecution(_ context.Context, results *ExecutionResults) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.suspended, results.ExecutionID)
+	delete(m.leases, results.ExecutionID)
+	m.results[results.ExecutionID] = *results
+	return nil
+}
+
+func (m *memExecutionStore) ClaimDueExecutions(_ context.Context, now, leaseUntil time.Time, limit int) ([]SuspendedExecution, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []SuspendedExecution
+	for id, exec := range m.suspended {
+		if len(out) == limit || exec.ResumeAt.After(now) {
+			continue
+		}
+		if lease, ok := m.leases[id]; ok && !lease.Before(now) {
+			continue
+		}
+		m.leases[id] = leaseUntil
+		out = append(out, *exec)
+	}
+	return out, nil
+}
+
+func (m *memExecutionStore) GetExecution(_ context.Context, tenant, id string) (*ExecutionResults, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if results, ok := m.results[id]; ok && m.tenants[id] == tenant {
+		return &results, nil
+	}
+	return nil, nil
+}
+
+// reminderWorkflow checks the weather, waits two hours and checks again before alerting.
+func reminderWorkflow() *Workflow {
+	wf := testWorkflow()
+	wf.Nodes = append(wf.Nodes,
+		Node{ID: "wait", Type: "delay", Data: NodeData{Label: "Wait 2h", Metadata: map[string]any{"duration": "2h"}}},
+		Node{ID: "recheck", Type: "integration", Data: wf.Nodes[2].Data},
+	)
+	// condition(true) -> wait -> recheck -> email -> end
+	wf.Edges = []Edge{
+		{ID: "e1", Source: "start", Target: "form"},
+		{ID: "e2", Source: "form", Target: "weather-api"},
+		{ID: "e3", Source: "weather-api", Target: "condition"},
+		{ID: "e4", Source: "condition", Target: "wait", SourceHandle: "true"},
+		{ID: "e5", Source: "condition", Target: "end", SourceHandle: "false"},
+		{ID: "e6", Source: "wait", Target: "recheck"},
+		{ID: "e7", Source: "recheck", Target: "email"},
+		{ID: "e8", Source: "email", Target: "end"},
+	}
+	return wf
+}
+
+func TestDelayUntil(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	vars := map[string]any{"payload": map[string]any{"expiresAt": "2026-03-01T12:30:00+02:00"}}
+
+	tests := []struct {
+		name    string
+		meta    map[string]any
+		want    time.Time
+		wantErr bool
+	}{
+		{"duration string", map[string]any{"duration": "90m"}, now.Add(90 * time.Minute), false},
+		{"duration seconds", map[string]any{"duration": float64(30)}, now.Add(30 * time.Second), false},
+		{"until timestamp", map[string]any{"until": "2026-03-02T07:00:00Z"}, time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC), false},
+		{"until variable", map[string]any{"until": "{{ payload.expiresAt }}"}, time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC), false},
+		{"missing", map[string]any{}, time.Time{}, true},
+		{"both", map[string]any{"duration": "1h", "until": "2026-03-02T07:00:00Z"}, time.Time{}, true},
+		{"negative", map[string]any{"duration": "-1h"}, time.Time{}, true},
+		{"bad timestamp", map[string]any{"until": "tomorrow"}, time.Time{}, true},
+		{"unknown variable", map[string]any{"until": "{{payload.missing}}"}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := delayUntil(tt.meta, vars, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got %s", got)
+		})
+	}
+}
+
+func TestDelayExecutor_ShortDelaySleepsInline(t *testing.T) {
+	executor := NewDelayExecutor(time.Second)
+	node := Node{ID: "wait", Type: "delay", Data: NodeData{Metadata: map[string]any{"duration": "10ms"}}}
+
+	result, err := executor.Execute(context.Background(), node, newTestState())
+
+	require.NoError(t, err)
+	assert.Equal(t, "completed", result.Status)
+	assert.True(t, result.ResumeAt.IsZero())
+}
+
+func TestEngine_DelaySuspendsAndResumes(t *testing.T) {
+	svc := newTestService(reminderWorkflow(), 30)
+	store := svc.executions.(*memExecutionStore)
+	state := &ExecutionState{
+		FormData:  map[string]any{"name": "Alice", "email": "alice@example.com", "city": "Sydney"},
+		Condition: ConditionInput{Operator: "greater_than", Threshold: 25},
+		Variables: map[string]any{},
+	}
+
+	results, err := svc.engine.Execute(context.Background(), reminderWorkflow(), state)
+
+	require.NoError(t, err)
+	assert.Equal(t, "suspended", results.Status)
+	require.NotNil(t, results.ResumeAt)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), *results.ResumeAt, time.Minute)
+	require.Len(t, results.Steps, 5) // start, form, weather, condition, wait
+	assert.Equal(t, "waiting", results.Steps[4].Status)
+
+	// Not due yet
+	timer := NewTimerService(svc, time.Minute)
+	timer.tick(context.Background())
+	stored, _ := store.GetExecution(context.Background(), auth.DefaultTenant, results.ExecutionID)
+	assert.Equal(t, "suspended", stored.Status)
+
+	// Two hours later the timer resumes it from the node after the delay
+	timer.now = func() time.Time { return time.Now().Add(2*time.Hour + time.Second) }
+	timer.tick(context.Background())
+
+	stored, _ = store.GetExecution(context.Background(), auth.DefaultTenant, results.ExecutionID)
+	require.NotNil(t, stored)
+	assert.Equal(t, "completed", stored.Status)
+	assert.Nil(t, stored.ResumeAt)
+	assert.NotEmpty(t, stored.EndTime)
+	require.Len(t, stored.Steps, 8)
+	assert.Equal(t, "completed", stored.Steps[4].Status)
+	expectedNodes := []string{"start", "form", "weather-api", "condition", "wait", "recheck", "email", "end"}
+	for i, step := range stored.Steps {
+		assert.Equal(t, expectedNodes[i], step.NodeID, "step %d", i)
+		assert.Equal(t, i+1, step.StepNumber)
+	}
+	assert.Empty(t, store.suspended)
+}
+
+func TestTimerService_MissingWorkflowFailsExecution(t *testing.T) {
+	svc := newTestService(nil, 30)
+	store := svc.executions.(*memExecutionStore)
+	store.SaveSuspended(context.Background(), &SuspendedExecution{
+		ExecutionID:  "exec-1",
+		TenantID:     auth.DefaultTenant,
+		WorkflowID:   "gone",
+		ResumeNodeID: "email",
+		ResumeAt:     time.Now().Add(-time.Minute),
+		Results:      ExecutionResults{ExecutionID: "exec-1", Status: "suspended", StartTime: time.Now().UTC().Format(time.RFC3339)},
+	})
+
+	NewTimerService(svc, time.Minute).tick(context.Background())
+
+	stored, _ := store.GetExecution(context.Background(), auth.DefaultTenant, "exec-1")
+	assert.Equal(t, "failed", stored.Status)
+	assert.Equal(t, "workflow no longer exists", stored.Metadata["error"])
+}
+
+func TestTimerService_CheckReportsRunning(t *testing.T) {
+	timer := NewTimerService(newTestService(nil, 30), time.Hour)
+	assert.EqualError(t, timer.Check(context.Background()), "timer service is not running")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { timer.Run(ctx); close(done) }()
+
+	require.Eventually(t, func() bool { return timer.Status().LastTick != nil }, time.Second, 5*time.Millisecond)
+	assert.NoError(t, timer.Check(context.Background()))
+	cancel()
+	<-done
+	assert.Error(t, timer.Check(context.Background()))
+}
+
+func TestEngine_DelayWithoutStoreFails(t *testing.T) {
+	engine := NewEngine(NewRegistry(&mockWeatherClient{temperature: 30}, nil, defaultInlineDelay))
+	state := &ExecutionState{
+		FormData:  map[string]any{"name": "Alice", "email": "alice@example.com", "city": "Sydney"},
+		Condition: ConditionInput{Operator: "greater_than", Threshold: 25},
+	}
+
+	_, err := engine.Execute(context.Background(), reminderWorkflow(), state)
+
+	assert.ErrorContains(t, err, "no execution store configured")
+}
+
+func TestHandleGetExecution(t *testing.T) {
+	svc := newTestService(reminderWorkflow(), 30)
+	router := setupRouter(svc)
+	results, err := svc.engine.Execute(context.Background(), reminderWorkflow(), NewExecutionState(ExecuteRequest{
+		FormData:  map[string]any{"name": "Alice", "email": "alice@example.com", "city": "Sydney"},
+		Condition: ConditionInput{Operator: "greater_than", Threshold: 25},
+	}))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/api/v1/executions/"+results.ExecutionID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var got ExecutionResults
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, "suspended", got.Status)
+	assert.NotNil(t, got.ResumeAt)
+
+	req = httptest.NewRequest("GET", "/api/v1/executions/550e8400-e29b-41d4-a716-446655440099", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,28 @@
+package workflow
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"workflow-code-test/api/pkg/auth"
+	"workflow-code-test/api/pkg/resilience"
+)
+
+// DiagnosticsResponse reports the state of the service's outbound dependencies.
+type DiagnosticsResponse struct {
+	Breakers []resilience.BreakerSnapshot `json:"breakers"`
+}
+
+// HandleDiagnostics returns circuit breaker state for every guarded integration. Admin only.
+func (s *Service) HandleDiagnostics(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, auth.PermAdmin, "") {
+		return
+	}
+	resp := DiagnosticsResponse{Breakers: make([]resilience.BreakerSnapshot, 0, len(s.guards))}
+	for _, g := range s.guards {
+		resp.Breakers = append(resp.Breakers, g.Breaker.Snapshot())
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -6,13 +6,18 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"workflow-code-test/api/pkg/logging"
 )
 
 const maxSteps = 100
 
 // Engine traverses a workflow graph and executes each node in sequence.
 type Engine struct {
-	registry Registry
+	registry    Registry
+	suspensions ExecutionStore // Persists executions suspended by delay nodes; nil disables suspension
+	secrets     SecretResolver // Resolves "{{secret.NAME}}" references in node metadata; nil rejects them
+	metrics     *Metrics       // Records execution and node metrics; nil records nothing
 }
 
 // NewEngine creates an Engine with the given executor registry.
@@ -23,19 +28,74 @@ func NewEngine(registry Registry) *Engine {
 // Execute traverses the workflow graph starting from the "start" node,
 // executing each node via the registry and collecting step results.
 // On error, execution stops and partial results are returned with status "failed".
+// If a node asks to wait (see DelayExecutor), the execution is persisted and
+// returned with status "suspended"; the TimerService resumes it later.
 func (e *Engine) Execute(ctx context.Context, wf *Workflow, state *ExecutionState) (*ExecutionResults, error) {
 	if state.Variables == nil {
 		state.Variables = make(map[string]any)
 	}
 
-	startTime := time.Now()
-
 	// Find start node
-	current, err := findStartNode(wf.Nodes)
+	start, err := findStartNode(wf.Nodes)
 	if err != nil {
 		return nil, err
 	}
 
+	startTime := time.Now()
+	results := &ExecutionResults{
+		ExecutionID: uuid.New().String(),
+		WorkflowID:  wf.ID,
+		StartTime:   startTime.UTC().Format(time.RFC3339),
+	}
+	ctx = logging.With(ctx, "executionId", results.ExecutionID)
+	ctx, span := startExecutionSpan(ctx, "workflow.execute", wf, results.ExecutionID)
+	e.metrics.executionStarted(wf.ID)
+	results, err = e.run(ctx, wf, state, start, results, startTime, false)
+	e.metrics.executionFinished(wf.ID, results, err)
+	endExecutionSpan(span, results, err)
+	return results, err
+}
+
+// Resume continues a suspended execution from the node after the one that suspended it.
+// Resumed executions are saved to the execution store when they finish or suspend again.
+func (e *Engine) Resume(ctx context.Context, wf *Workflow, exec *SuspendedExecution) (*ExecutionResults, error) {
+	state := exec.State
+	if state.Variables == nil {
+		state.Variables = make(map[string]any)
+	}
+	results := exec.Results
+	results.ResumeAt = nil
+	if n := len(results.Steps); n > 0 && results.Steps[n-1].Status == "waiting" {
+		results.Steps[n-1].Status = "completed"
+	}
+	if results.Metadata == nil {
+		results.Metadata = ExecutionMetadata(&state)
+	}
+	startTime, _ := time.Parse(time.RFC3339, results.StartTime)
+
+	ctx = logging.With(ctx, "executionId", results.ExecutionID)
+	ctx, span := startExecutionSpan(ctx, "workflow.resume", wf, results.ExecutionID)
+	var finished *ExecutionResults
+	var err error
+	if exec.ResumeNodeID == "" {
+		finished, err = e.finish(ctx, &results, "completed", startTime, true)
+	} else {
+		err = fmt.Errorf("resume node %q not found", exec.ResumeNodeID)
+		for i := range wf.Nodes {
+			if wf.Nodes[i].ID == exec.ResumeNodeID {
+				finished, err = e.run(ctx, wf, &state, &wf.Nodes[i], &results, startTime, true)
+				break
+			}
+		}
+	}
+	e.metrics.executionFinished(wf.ID, finished, err)
+	endExecutionSpan(span, finished, err)
+	return finished, err
+}
+
+// run executes nodes from current onwards, appending to results.Steps.
+// resumed marks an execution that was suspended before and so is already persisted.
+func (e *Engine) run(ctx context.Context, wf *Workflow, state *ExecutionState, current *Node, results *ExecutionResults, startTime time.Time, resumed bool) (*ExecutionResults, error) {
 	// Build adjacency: source node ID -> outgoing edges
 	edgeMap := buildEdgeMap(wf.Edges)
 
@@ -45,18 +105,49 @@ func (e *Engine) Execute(ctx context.Context, wf *Workflow, state *ExecutionStat
 		nodeMap[wf.Nodes[i].ID] = &wf.Nodes[i]
 	}
 
-	var steps []ExecutionStep
-	stepNum := 0
+	stepNum := len(results.Steps)
+	limit := stepNum + maxSteps
 
-	for stepNum < maxSteps {
+	for stepNum < limit {
 		executor, ok := e.registry[current.Type]
 		if !ok {
 			return nil, fmt.Errorf("no executor registered for node type %q", current.Type)
 		}
 
+		stepCtx, span := startNodeSpan(ctx, current, stepNum+1)
+		stepCtx = logging.With(stepCtx, "nodeId", current.ID, "nodeType", current.Type)
+		nodeLog := logging.FromContext(stepCtx)
+		nodeLog.Debug("Executing node", "step", stepNum+1)
+		// The executor's logger records what it logs so it can be attached to the step
+		stepCtx, stepLogs := withStepLog(stepCtx)
+
+		// Secret values are bound into a copy of the node just for this call and
+		// redacted from everything the step leaves behind
 		stepStart := time.Now()
-		result, execErr := executor.Execute(ctx, *current, state)
+		mock, mocked := state.Mocks[current.ID]
+		simulator, canSimulate := executor.(Simulator)
+		simulated := mocked || (canSimulate && state.DryRun)
+		var result *StepResult
+		var redact *redactor
+		var execErr error
+		if mocked {
+			result = mockResult(*current, mock, state)
+		} else {
+			var node Node
+			var secretValues []string
+			node, secretValues, execErr = e.bindSecrets(stepCtx, wf.TenantID, *current)
+			redact = newRedactor(secretValues)
+			stepLogs.setRedactor(redact)
+			if execErr == nil && simulated {
+				result, execErr = simulator.Simulate(stepCtx, node, state)
+			} else if execErr == nil {
+				result, execErr = executor.Execute(stepCtx, node, state)
+			}
+		}
 		duration := time.Since(stepStart)
+		if redact != nil {
+			redact.variables(state.Variables)
+		}
 
 		stepNum++
 		step := ExecutionStep{
@@ -67,28 +158,35 @@ func (e *Engine) Execute(ctx context.Context, wf *Workflow, state *ExecutionStat
 			Label:      current.Data.Label,
 			Duration:   duration.Milliseconds(),
 			Timestamp:  time.Now().UTC().Format(time.RFC3339),
+			Logs:       stepLogs.result(),
+			Simulated:  simulated,
+			Mocked:     mocked,
 		}
 
 		if execErr != nil {
+			e.metrics.observeNode(current.Type, "error", duration)
+			message := execErr.Error()
+			if redact != nil {
+				message = redact.string(message)
+			}
 			step.Status = "error"
-			step.Error = execErr.Error()
-			step.Output = map[string]any{"message": fmt.Sprintf("Error: %s", execErr.Error())}
-			steps = append(steps, step)
-
-			endTime := time.Now()
-			return &ExecutionResults{
-				ExecutionID:   uuid.New().String(),
-				Status:        "failed",
-				StartTime:     startTime.UTC().Format(time.RFC3339),
-				EndTime:       endTime.UTC().Format(time.RFC3339),
-				TotalDuration: endTime.Sub(startTime).Milliseconds(),
-				Steps:         steps,
-			}, nil
+			step.Error = message
+			step.Output = map[string]any{"message": fmt.Sprintf("Error: %s", message)}
+			endNodeSpan(span, step.Status, message)
+			nodeLog.Warn("Node failed", "durationMs", step.Duration, "error", message)
+			results.Steps = append(results.Steps, step)
+			return e.finish(ctx, results, "failed", startTime, resumed)
 		}
 
+		e.metrics.observeNode(current.Type, result.Status, duration)
+		endNodeSpan(span, result.Status, "")
+		nodeLog.Debug("Node finished", "status", result.Status, "durationMs", step.Duration)
 		step.Status = result.Status
 		step.Output = result.Output
-		steps = append(steps, step)
+		if redact != nil {
+			step.Output = redact.value(result.Output).(map[string]any)
+		}
+		results.Steps = append(results.Steps, step)
 
 		// Find the next node via outgoing edges
 		edges := edgeMap[current.ID]
@@ -103,35 +201,83 @@ func (e *Engine) Execute(ctx context.Context, wf *Workflow, state *ExecutionStat
 					break
 				}
 			}
+		} else if result.Handle != "" {
+			// Executors that branch name the handle to follow
+			for _, edge := range edges {
+				if edge.SourceHandle == result.Handle {
+					nextNodeID = edge.Target
+					break
+				}
+			}
 		} else if len(edges) > 0 {
 			nextNodeID = edges[0].Target
 		}
 
+		if nextNodeID != "" {
+			if _, ok := nodeMap[nextNodeID]; !ok {
+				return nil, fmt.Errorf("edge target node %q not found", nextNodeID)
+			}
+		}
+
+		// The node asked to wait: persist and stop here
+		if !result.ResumeAt.IsZero() {
+			return e.suspend(ctx, wf, state, results, nextNodeID, result.ResumeAt, startTime)
+		}
+
 		// No outgoing edge means we've reached a terminal node
 		if nextNodeID == "" {
 			break
 		}
-
-		next, ok := nodeMap[nextNodeID]
-		if !ok {
-			return nil, fmt.Errorf("edge target node %q not found", nextNodeID)
-		}
-		current = next
+		current = nodeMap[nextNodeID]
 	}
 
-	if stepNum >= maxSteps {
+	if stepNum >= limit {
 		return nil, fmt.Errorf("execution exceeded maximum of %d steps (possible cycle)", maxSteps)
 	}
 
+	return e.finish(ctx, results, "completed", startTime, resumed)
+}
+
+// suspend saves the execution so it continues at resumeNodeID once resumeAt has passed.
+func (e *Engine) suspend(ctx context.Context, wf *Workflow, state *ExecutionState, results *ExecutionResults, resumeNodeID string, resumeAt, startTime time.Time) (*ExecutionResults, error) {
+	if e.suspensions == nil {
+		return nil, fmt.Errorf("node %q cannot wait: no execution store configured", results.Steps[len(results.Steps)-1].NodeID)
+	}
+
+	resumeAt = resumeAt.UTC()
+	results.Status = "suspended"
+	results.EndTime = ""
+	results.TotalDuration = time.Since(startTime).Milliseconds()
+	results.ResumeAt = &resumeAt
+
+	err := e.suspensions.SaveSuspended(ctx, &SuspendedExecution{
+		ExecutionID:  results.ExecutionID,
+		TenantID:     wf.TenantID,
+		WorkflowID:   wf.ID,
+		ResumeNodeID: resumeNodeID,
+		ResumeAt:     resumeAt,
+		State:        *state,
+		Results:      *results,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// finish stamps the end of the execution, saving it if it had been suspended before.
+func (e *Engine) finish(ctx context.Context, results *ExecutionResults, status string, startTime time.Time, resumed bool) (*ExecutionResults, error) {
 	endTime := time.Now()
-	return &ExecutionResults{
-		ExecutionID:   uuid.New().String(),
-		Status:        "completed",
-		StartTime:     startTime.UTC().Format(time.RFC3339),
-		EndTime:       endTime.UTC().Format(time.RFC3339),
-		TotalDuration: endTime.Sub(startTime).Milliseconds(),
-		Steps:         steps,
-	}, nil
+	results.Status = status
+	results.EndTime = endTime.UTC().Format(time.RFC3339)
+	results.TotalDuration = endTime.Sub(startTime).Milliseconds()
+
+	if resumed && e.suspensions != nil {
+		if err := e.suspensions.FinishExecution(ctx, results); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
 }
 
 func findStartNode(nodes []Node) (*Node, error) {
@@ -1,18 +1,25 @@
 package workflow
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"workflow-code-test/api/pkg/auth"
+	"workflow-code-test/api/pkg/logging"
 )
 
 func testWorkflow() *Workflow {
 	return &Workflow{
-		ID:   "test-wf",
-		Name: "Test Workflow",
+		ID:       "test-wf",
+		TenantID: auth.DefaultTenant,
+		Name:     "Test Workflow",
 		Nodes: []Node{
 			{ID: "start", Type: "start", Data: NodeData{Label: "Start"}},
 			{ID: "form", Type: "form", Data: NodeData{Label: "User Input"}},
@@ -56,7 +63,7 @@ func testWorkflow() *Workflow {
 
 func TestEngine_HappyPath_ConditionTrue(t *testing.T) {
 	client := &mockWeatherClient{temperature: 30.0}
-	registry := NewRegistry(client)
+	registry := NewRegistry(client, nil, defaultInlineDelay)
 	engine := NewEngine(registry)
 
 	state := &ExecutionState{
@@ -86,7 +93,7 @@ func TestEngine_HappyPath_ConditionTrue(t *testing.T) {
 
 func TestEngine_HappyPath_ConditionFalse(t *testing.T) {
 	client := &mockWeatherClient{temperature: 20.0}
-	registry := NewRegistry(client)
+	registry := NewRegistry(client, nil, defaultInlineDelay)
 	engine := NewEngine(registry)
 
 	state := &ExecutionState{
@@ -110,7 +117,7 @@ func TestEngine_HappyPath_ConditionFalse(t *testing.T) {
 
 func TestEngine_StopsOnError(t *testing.T) {
 	client := &mockWeatherClient{err: fmt.Errorf("API timeout")}
-	registry := NewRegistry(client)
+	registry := NewRegistry(client, nil, defaultInlineDelay)
 	engine := NewEngine(registry)
 
 	state := &ExecutionState{
@@ -189,3 +196,28 @@ func TestEngine_UnknownNodeType(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "no executor registered")
 }
+
+func TestEngine_LogsCarryWorkflowExecutionAndNodeIDs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := logging.With(logging.WithLogger(context.Background(), logger), "workflowId", "test-wf")
+	engine := NewEngine(NewRegistry(&mockWeatherClient{temperature: 30}, nil, defaultInlineDelay))
+
+	results, err := engine.Execute(ctx, testWorkflow(), metricsState())
+	require.NoError(t, err)
+
+	var nodeIDs []string
+	for _, raw := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(raw, &line))
+		assert.Equal(t, "test-wf", line["workflowId"])
+		assert.Equal(t, results.ExecutionID, line["executionId"])
+		if line["msg"] == "Node finished" {
+			nodeIDs = append(nodeIDs, line["nodeId"].(string))
+		}
+	}
+	require.Len(t, nodeIDs, len(results.Steps))
+	for i, step := range results.Steps {
+		assert.Equal(t, step.NodeID, nodeIDs[i])
+	}
+}
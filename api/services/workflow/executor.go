@@ -6,10 +6,15 @@ import (
 )
 
 // ExecutionState holds shared state passed between node executors during a workflow run.
+// It is persisted as JSON while an execution is suspended.
 type ExecutionState struct {
-	FormData  map[string]any
-	Condition ConditionInput
-	Variables map[string]any // Accumulated outputs (e.g., temperature, conditionResult)
+	FormData  map[string]any  `json:"formData"`
+	Condition ConditionInput  `json:"condition"`
+	Unit      TemperatureUnit `json:"unit,omitempty"` // Unit for thresholds and displayed temperatures; variables stay in Celsius
+	Variables map[string]any  `json:"variables"`      // Accumulated outputs (e.g., temperature, conditionResult)
+
+	DryRun bool                      `json:"dryRun,omitempty"` // Simulate nodes with external effects instead of running them
+	Mocks  map[string]map[string]any `json:"mocks,omitempty"`  // Outputs that stand in for running the nodes with these IDs
 }
 
 // StepResult is the output of executing a single node.
@@ -21,9 +26,12 @@ type StepResult struct {
 	Output   map[string]any // Must include "message"; may include type-specific fields
 	Duration time.Duration
 	Error    string
+	Handle   string    // Optional sourceHandle of the outgoing edge to follow
+	ResumeAt time.Time // When set, the execution suspends until then before following the outgoing edge
 }
 
 // NodeExecutor defines the interface for executing a single node type.
+// Executors log through logging.FromContext(ctx); those entries are attached to the step.
 type NodeExecutor interface {
 	Execute(ctx context.Context, node Node, state *ExecutionState) (*StepResult, error)
 }
@@ -32,13 +40,16 @@ type NodeExecutor interface {
 type Registry map[string]NodeExecutor
 
 // NewRegistry creates a registry populated with all built-in executor types.
-func NewRegistry(weatherClient WeatherClient) Registry {
+// geocoder may be nil, in which case integration nodes only accept cities listed in their options.
+// Delay nodes sleep through waits up to delayInlineMax and suspend the execution for longer ones.
+func NewRegistry(weatherClient WeatherClient, geocoder Geocoder, delayInlineMax time.Duration) Registry {
 	return Registry{
 		"start":       &StartExecutor{},
 		"form":        &FormExecutor{},
-		"integration": &IntegrationExecutor{client: weatherClient},
+		"integration": &IntegrationExecutor{client: weatherClient, geocoder: geocoder},
 		"condition":   &ConditionExecutor{},
 		"email":       &EmailExecutor{},
+		"delay":       NewDelayExecutor(delayInlineMax),
 		"end":         &EndExecutor{},
 	}
 }
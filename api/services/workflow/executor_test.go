@@ -10,13 +10,23 @@ import (
 )
 
 // mockWeatherClient implements WeatherClient for testing.
+// If report is nil, a report with only the current temperature is returned.
 type mockWeatherClient struct {
 	temperature float64
+	report      *WeatherReport
 	err         error
+	gotDays     int
 }
 
-func (m *mockWeatherClient) GetTemperature(_ context.Context, _, _ float64) (float64, error) {
-	return m.temperature, m.err
+func (m *mockWeatherClient) GetWeather(_ context.Context, _, _ float64, forecastDays int) (*WeatherReport, error) {
+	m.gotDays = forecastDays
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.report != nil {
+		return m.report, nil
+	}
+	return &WeatherReport{Current: WeatherObservation{Temperature: m.temperature}}, nil
 }
 
 func newTestState() *ExecutionState {
@@ -120,6 +130,67 @@ func TestIntegrationExecutor_Success(t *testing.T) {
 	assert.Contains(t, result.Output["message"].(string), "Sydney")
 }
 
+func TestIntegrationExecutor_StoresObservationAndForecast(t *testing.T) {
+	client := &mockWeatherClient{report: &WeatherReport{
+		Current: WeatherObservation{Temperature: 22, ApparentTemperature: 21, WindSpeed: 14, Humidity: 60, WeatherCode: 3},
+		Daily: []DailyForecast{
+			{Date: "2026-02-16", PrecipitationSum: 0},
+			{Date: "2026-02-17", PrecipitationSum: 4.2, PrecipitationProbability: 80},
+		},
+	}}
+	exec := &IntegrationExecutor{client: client}
+	node := integrationNode()
+	node.Data.Metadata["forecastDays"] = float64(3)
+	state := newTestState()
+
+	result, err := exec.Execute(context.Background(), node, state)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, client.gotDays)
+	assert.Equal(t, 21.0, state.Variables["apparentTemperature"])
+	assert.Equal(t, 14.0, state.Variables["windSpeed"])
+	assert.Equal(t, 3, state.Variables["weatherCode"])
+	assert.Equal(t, 3, result.Output["forecastDays"])
+
+	rain, ok := lookupVariable(state.Variables, "weather.daily.1.precipitationSum")
+	require.True(t, ok)
+	assert.Equal(t, 4.2, rain)
+}
+
+func TestIntegrationExecutor_OutputTemperaturesUseTheRequestUnit(t *testing.T) {
+	client := &mockWeatherClient{report: &WeatherReport{
+		Current: WeatherObservation{Temperature: 30, ApparentTemperature: 35, WindSpeed: 14},
+		Hourly:  []HourlyForecast{{Time: "2026-02-16T09:00", Temperature: 20}},
+		Daily:   []DailyForecast{{Date: "2026-02-16", TemperatureMax: 40, TemperatureMin: 10}},
+	}}
+	exec := &IntegrationExecutor{client: client}
+	state := newTestState()
+	state.Unit = Fahrenheit
+
+	result, err := exec.Execute(context.Background(), integrationNode(), state)
+
+	require.NoError(t, err)
+	observation := result.Output["observation"].(WeatherObservation)
+	assert.InDelta(t, 86.0, observation.Temperature, 1e-9)
+	assert.InDelta(t, 95.0, observation.ApparentTemperature, 1e-9)
+	assert.Equal(t, 14.0, observation.WindSpeed)
+	forecast := result.Output["forecast"].(map[string]any)
+	assert.InDelta(t, 68.0, forecast["hourly"].([]HourlyForecast)[0].Temperature, 1e-9)
+	daily := forecast["daily"].([]DailyForecast)[0]
+	assert.InDelta(t, 104.0, daily.TemperatureMax, 1e-9)
+	assert.InDelta(t, 50.0, daily.TemperatureMin, 1e-9)
+
+	assert.Equal(t, 35.0, state.Variables["apparentTemperature"], "variables stay in °C")
+	assert.Equal(t, 40.0, client.report.Daily[0].TemperatureMax, "the report is not modified")
+}
+
+func TestForecastDaysFromMetadata(t *testing.T) {
+	assert.Equal(t, defaultForecastDays, forecastDaysFromMetadata(nil))
+	assert.Equal(t, 0, forecastDaysFromMetadata(map[string]any{"forecastDays": float64(0)}))
+	assert.Equal(t, maxForecastDays, forecastDaysFromMetadata(map[string]any{"forecastDays": float64(99)}))
+	assert.Equal(t, 0, forecastDaysFromMetadata(map[string]any{"forecastDays": float64(-1)}))
+}
+
 func TestIntegrationExecutor_InvalidCoordinates(t *testing.T) {
 	client := &mockWeatherClient{temperature: 20}
 	exec := &IntegrationExecutor{client: client}
@@ -156,6 +227,44 @@ func TestIntegrationExecutor_CityNotFound(t *testing.T) {
 	assert.Contains(t, err.Error(), "not found")
 }
 
+func TestIntegrationExecutor_GeocodesWithoutOptions(t *testing.T) {
+	gazetteer, err := NewGazetteer()
+	require.NoError(t, err)
+	exec := &IntegrationExecutor{client: &mockWeatherClient{temperature: 12}, geocoder: gazetteer}
+	node := Node{ID: "weather-api", Type: "integration", Data: NodeData{Label: "Weather API"}}
+	state := newTestState()
+	state.FormData["city"] = "Perth, GB"
+
+	result, err := exec.Execute(context.Background(), node, state)
+
+	require.NoError(t, err)
+	place := result.Output["place"].(*Place)
+	assert.Equal(t, "Scotland", place.Admin1)
+	assert.Contains(t, result.Output["message"].(string), "Perth, GB")
+}
+
+func TestIntegrationExecutor_OptionsAllowListWithoutCoordinates(t *testing.T) {
+	geocoder := &stubGeocoder{place: &Place{Name: "Tokyo", CountryCode: "JP", Latitude: 35.68, Longitude: 139.65}}
+	exec := &IntegrationExecutor{client: &mockWeatherClient{temperature: 12}, geocoder: geocoder}
+	node := Node{
+		ID: "weather-api", Type: "integration",
+		Data: NodeData{Metadata: map[string]any{
+			"options": []any{map[string]any{"city": "Tokyo", "country": "JP"}},
+		}},
+	}
+	state := newTestState()
+	state.FormData["city"] = "Tokyo"
+
+	_, err := exec.Execute(context.Background(), node, state)
+	require.NoError(t, err)
+	assert.Equal(t, 1, geocoder.calls)
+
+	state.FormData["city"] = "Osaka"
+	_, err = exec.Execute(context.Background(), node, state)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found in available options")
+}
+
 func TestIntegrationExecutor_APIError(t *testing.T) {
 	client := &mockWeatherClient{err: fmt.Errorf("connection timeout")}
 	exec := &IntegrationExecutor{client: client}
@@ -215,6 +324,27 @@ func TestConditionExecutor_AllOperators(t *testing.T) {
 	}
 }
 
+func TestConditionExecutor_FahrenheitThreshold(t *testing.T) {
+	exec := &ConditionExecutor{}
+	node := Node{ID: "cond", Type: "condition", Data: NodeData{Label: "Check"}}
+	state := &ExecutionState{
+		Condition: ConditionInput{Operator: "greater_than_or_equal", Threshold: 95},
+		Unit:      Fahrenheit,
+		Variables: map[string]any{"temperature": 35.0},
+	}
+
+	result, err := exec.Execute(context.Background(), node, state)
+
+	require.NoError(t, err)
+	assert.Equal(t, true, result.Output["conditionMet"])
+	assert.Equal(t, "Temperature 95.0°F is greater than or equal to 95.0°F - condition met", result.Output["message"])
+
+	cond := result.Output["conditionResult"].(map[string]any)
+	assert.Equal(t, 35.0, cond["valueCelsius"])
+	assert.InDelta(t, 35.0, cond["thresholdCelsius"], 1e-9)
+	assert.Equal(t, 35.0, state.Variables["temperature"], "variables stay canonical")
+}
+
 func TestConditionExecutor_MissingTemperature(t *testing.T) {
 	exec := &ConditionExecutor{}
 	state := &ExecutionState{
@@ -228,6 +358,41 @@ func TestConditionExecutor_MissingTemperature(t *testing.T) {
 	assert.Contains(t, err.Error(), "temperature")
 }
 
+func TestConditionExecutor_VariablePath(t *testing.T) {
+	exec := &ConditionExecutor{}
+	node := Node{
+		ID: "cond", Type: "condition",
+		Data: NodeData{Label: "Rain tomorrow?", Metadata: map[string]any{"variable": "weather.daily.1.precipitationSum"}},
+	}
+	state := &ExecutionState{
+		Condition: ConditionInput{Operator: "greater_than", Threshold: 0},
+		Variables: map[string]any{
+			"weather": toVariable(WeatherReport{Daily: []DailyForecast{{PrecipitationSum: 0}, {PrecipitationSum: 4.2}}}),
+		},
+	}
+
+	result, err := exec.Execute(context.Background(), node, state)
+
+	require.NoError(t, err)
+	assert.Equal(t, true, result.Output["conditionMet"])
+	assert.Equal(t, "true", state.Variables["conditionResult"])
+	assert.Contains(t, result.Output["message"].(string), "weather.daily.1.precipitationSum 4.2")
+}
+
+func TestConditionExecutor_VariablePathMissing(t *testing.T) {
+	exec := &ConditionExecutor{}
+	node := Node{Data: NodeData{Metadata: map[string]any{"variable": "weather.daily.5.precipitationSum"}}}
+	state := &ExecutionState{
+		Condition: ConditionInput{Operator: "greater_than", Threshold: 0},
+		Variables: map[string]any{"weather": toVariable(WeatherReport{})},
+	}
+
+	_, err := exec.Execute(context.Background(), node, state)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.daily.5.precipitationSum")
+}
+
 func TestEmailExecutor(t *testing.T) {
 	exec := &EmailExecutor{}
 	node := Node{
@@ -259,6 +424,31 @@ func TestEmailExecutor(t *testing.T) {
 	assert.Contains(t, draft["body"].(string), "28.5")
 }
 
+func TestEmailExecutor_RewritesLegacyCelsiusSuffix(t *testing.T) {
+	exec := &EmailExecutor{}
+	node := Node{
+		ID: "email", Type: "email",
+		Data: NodeData{Metadata: map[string]any{
+			"emailTemplate": map[string]any{
+				"subject": "Alert: {{temperature}}{{unit}}",
+				"body":    "Temperature is {{temperature}}\u00b0C ({{temperatureCelsius}}\u00b0C)!",
+			},
+		}},
+	}
+	state := &ExecutionState{
+		FormData:  map[string]any{"name": "Alice", "email": "alice@example.com", "city": "Austin"},
+		Unit:      Fahrenheit,
+		Variables: map[string]any{"temperature": 35.0},
+	}
+
+	result, err := exec.Execute(context.Background(), node, state)
+
+	require.NoError(t, err)
+	draft := result.Output["emailDraft"].(map[string]any)
+	assert.Equal(t, "Alert: 95.0°F", draft["subject"])
+	assert.Equal(t, "Temperature is 95.0°F (35.0°C)!", draft["body"])
+}
+
 func TestEndExecutor(t *testing.T) {
 	exec := &EndExecutor{}
 	node := Node{ID: "end", Type: "end", Data: NodeData{Label: "Complete"}}
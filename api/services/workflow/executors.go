@@ -2,10 +2,13 @@ package workflow
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"strings"
 	"time"
+
+	"workflow-code-test/api/pkg/logging"
 )
 
 // StartExecutor handles the "start" node type. It is a no-op that marks the workflow beginning.
@@ -19,6 +22,15 @@ func (e *StartExecutor) Execute(_ context.Context, node Node, _ *ExecutionState)
 	}, nil
 }
 
+func (e *StartExecutor) Describe() NodeTypeDescriptor {
+	return NodeTypeDescriptor{
+		DisplayName: "Start",
+		Category:    "control",
+		Description: "Marks where the workflow begins",
+		Handles:     HandleDescriptor{Source: true},
+	}
+}
+
 // FormExecutor handles the "form" node type. It captures and validates user input.
 type FormExecutor struct{}
 
@@ -43,80 +55,253 @@ func (e *FormExecutor) Execute(_ context.Context, node Node, state *ExecutionSta
 	}, nil
 }
 
-// IntegrationExecutor handles the "integration" node type. It calls an external weather API.
+func (e *FormExecutor) Describe() NodeTypeDescriptor {
+	return NodeTypeDescriptor{
+		DisplayName: "User Input",
+		Category:    "input",
+		Description: "Validates the submitted form fields",
+		ConfigSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"inputFields": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			},
+		},
+		Outputs: []VariableDescriptor{
+			{Name: "name", Type: "string"},
+			{Name: "email", Type: "string"},
+			{Name: "city", Type: "string", Description: `City name, optionally with a country suffix such as "Perth, GB"`},
+			{Name: "country", Type: "string", Description: "Optional country code or name used to disambiguate the city"},
+		},
+		Handles: HandleDescriptor{Target: true, Source: true},
+	}
+}
+
+// IntegrationExecutor handles the "integration" node type. It calls an external weather API
+// and stores the current observation and forecast as execution variables.
 type IntegrationExecutor struct {
-	client WeatherClient
+	client   WeatherClient
+	geocoder Geocoder
 }
 
 func (e *IntegrationExecutor) Execute(ctx context.Context, node Node, state *ExecutionState) (*StepResult, error) {
 	city, _ := state.FormData["city"].(string)
 
-	// Look up coordinates from node metadata options
-	options, _ := node.Data.Metadata["options"].([]any)
-	var lat, lon float64
-	var found bool
-	for _, opt := range options {
-		m, ok := opt.(map[string]any)
-		if !ok {
-			continue
-		}
-		if cityName, _ := m["city"].(string); strings.EqualFold(cityName, city) {
-			var okLat, okLon bool
-			lat, okLat = toFloat64(m["lat"])
-			lon, okLon = toFloat64(m["lon"])
-			if !okLat || !okLon {
-				return nil, fmt.Errorf("invalid coordinates for city %q", city)
-			}
-			found = true
-			break
-		}
-	}
-	if !found {
-		return nil, fmt.Errorf("city %q not found in available options", city)
+	place, err := e.resolveLocation(ctx, node, state)
+	if err != nil {
+		return nil, err
 	}
+	lat, lon := place.Latitude, place.Longitude
+	state.Variables["place"] = toVariable(place)
 
 	endpoint, _ := node.Data.Metadata["apiEndpoint"].(string)
+	forecastDays := forecastDaysFromMetadata(node.Data.Metadata)
 
-	temperature, err := e.client.GetTemperature(ctx, lat, lon)
+	logging.FromContext(ctx).Debug("Fetching weather", "latitude", lat, "longitude", lon, "forecastDays", forecastDays)
+	report, err := e.client.GetWeather(ctx, lat, lon, forecastDays)
 	if err != nil {
 		return nil, fmt.Errorf("weather API error: %w", err)
 	}
 
-	state.Variables["temperature"] = temperature
+	current := report.Current
+	unit := state.Unit
+	// Variables stay in °C for conditions to convert; the output is in the request's unit
+	converted := report.inUnit(unit)
+	state.Variables["temperature"] = current.Temperature
+	state.Variables["apparentTemperature"] = current.ApparentTemperature
+	state.Variables["windSpeed"] = current.WindSpeed
+	state.Variables["precipitation"] = current.Precipitation
+	state.Variables["humidity"] = current.Humidity
+	state.Variables["weatherCode"] = current.WeatherCode
+	state.Variables["weather"] = toVariable(report)
 
 	return &StepResult{
 		NodeID: node.ID, NodeType: node.Type, Label: node.Data.Label,
 		Status: "completed",
 		Output: map[string]any{
-			"message":     fmt.Sprintf("Current temperature in %s: %.1f\u00b0C", city, temperature),
-			"temperature": temperature,
-			"location":    city,
+			"message":            fmt.Sprintf("Current temperature in %s: %.1f%s", city, unit.FromCelsius(current.Temperature), unit.Symbol()),
+			"temperature":        unit.FromCelsius(current.Temperature),
+			"temperatureCelsius": current.Temperature,
+			"unit":               unit.Symbol(),
+			"location":           city,
+			"place":              place,
+			"observation":        converted.Current,
+			"forecastDays":       forecastDays,
+			"forecast": map[string]any{
+				"hourly": converted.Hourly,
+				"daily":  converted.Daily,
+			},
 			"apiResponse": map[string]any{
 				"endpoint":   endpoint,
 				"method":     "GET",
 				"statusCode": 200,
-				"data":       map[string]any{"temperature": temperature},
+				"data":       map[string]any{"temperature": current.Temperature},
 			},
 		},
 	}, nil
 }
 
-// ConditionExecutor handles the "condition" node type. It evaluates a temperature comparison.
+func (e *IntegrationExecutor) Describe() NodeTypeDescriptor {
+	return NodeTypeDescriptor{
+		DisplayName: "Weather API",
+		Category:    "integration",
+		Description: "Fetches current weather and a forecast for the submitted city",
+		ConfigSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"apiEndpoint":  map[string]any{"type": "string", "description": "Shown in step output only"},
+				"forecastDays": map[string]any{"type": "integer", "minimum": 0, "maximum": maxForecastDays, "default": defaultForecastDays},
+				"options": map[string]any{
+					"type":        "array",
+					"description": "Optional allow-list of cities; coordinates, when given, override geocoding",
+					"items": map[string]any{
+						"type":     "object",
+						"required": []string{"city"},
+						"properties": map[string]any{
+							"city":    map[string]any{"type": "string"},
+							"country": map[string]any{"type": "string"},
+							"lat":     map[string]any{"type": "number"},
+							"lon":     map[string]any{"type": "number"},
+						},
+					},
+				},
+			},
+		},
+		Inputs: []VariableDescriptor{
+			{Name: "city", Type: "string"},
+			{Name: "country", Type: "string"},
+		},
+		Outputs: []VariableDescriptor{
+			{Name: "temperature", Type: "number", Description: "Current temperature in °C"},
+			{Name: "apparentTemperature", Type: "number", Description: "Feels-like temperature in °C"},
+			{Name: "windSpeed", Type: "number", Description: "km/h"},
+			{Name: "precipitation", Type: "number", Description: "mm"},
+			{Name: "humidity", Type: "number", Description: "Relative humidity in %"},
+			{Name: "weatherCode", Type: "number", Description: "WMO weather interpretation code"},
+			{Name: "weather", Type: "object", Description: "Current observation with hourly and daily forecasts"},
+			{Name: "place", Type: "object", Description: "Resolved location"},
+		},
+		Handles: HandleDescriptor{Target: true, Source: true},
+	}
+}
+
+// Simulate fails: the weather is the node's whole output, so dry runs must mock it
+// rather than call the weather API.
+func (e *IntegrationExecutor) Simulate(_ context.Context, node Node, _ *ExecutionState) (*StepResult, error) {
+	return nil, errors.New(`dry runs do not call the weather API; mock this node's output, e.g. {"temperature": 30}`)
+}
+
+// resolveLocation finds coordinates for the requested city. The form's "city" may carry
+// a country suffix ("Perth, GB") and an optional "country" field overrides it. When the
+// node lists "options" they act as an allow-list, and an option's lat/lon, if present,
+// override geocoding. Otherwise the city is resolved with the executor's Geocoder.
+func (e *IntegrationExecutor) resolveLocation(ctx context.Context, node Node, state *ExecutionState) (*Place, error) {
+	city, _ := state.FormData["city"].(string)
+	name, country := splitPlaceName(city)
+	if c, _ := state.FormData["country"].(string); strings.TrimSpace(c) != "" {
+		country = strings.TrimSpace(c)
+	}
+
+	options, _ := node.Data.Metadata["options"].([]any)
+	if len(options) > 0 {
+		opt, ok := findCityOption(options, name, country)
+		if !ok {
+			return nil, fmt.Errorf("city %q not found in available options", city)
+		}
+		_, hasLat := opt["lat"]
+		_, hasLon := opt["lon"]
+		optCountry, _ := opt["country"].(string)
+		if hasLat || hasLon {
+			lat, okLat := toFloat64(opt["lat"])
+			lon, okLon := toFloat64(opt["lon"])
+			if !okLat || !okLon {
+				return nil, fmt.Errorf("invalid coordinates for city %q", city)
+			}
+			optName, _ := opt["city"].(string)
+			return &Place{Name: optName, Country: optCountry, Latitude: lat, Longitude: lon}, nil
+		}
+		if country == "" {
+			country = optCountry
+		}
+	}
+
+	if e.geocoder == nil {
+		return nil, fmt.Errorf("no geocoder configured to resolve city %q", city)
+	}
+	place, err := e.geocoder.Geocode(ctx, name, country)
+	if errors.Is(err, ErrPlaceNotFound) {
+		return nil, fmt.Errorf("city %q not found", city)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("geocoding error: %w", err)
+	}
+	return place, nil
+}
+
+// findCityOption returns the allow-list entry for name. Entries with a "country" key
+// only match when the requested country, if any, agrees.
+func findCityOption(options []any, name, country string) (map[string]any, bool) {
+	for _, opt := range options {
+		m, ok := opt.(map[string]any)
+		if !ok {
+			continue
+		}
+		cityName, _ := m["city"].(string)
+		if !strings.EqualFold(cityName, name) {
+			continue
+		}
+		optCountry, _ := m["country"].(string)
+		if country != "" && optCountry != "" && !strings.EqualFold(optCountry, country) {
+			continue
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+const (
+	defaultForecastDays = 2 // Today and tomorrow
+	maxForecastDays     = 16
+)
+
+// forecastDaysFromMetadata reads the "forecastDays" horizon from node metadata,
+// clamped to what Open-Meteo supports. Zero requests current conditions only.
+func forecastDaysFromMetadata(metadata map[string]any) int {
+	days, ok := toFloat64(metadata["forecastDays"])
+	if !ok {
+		return defaultForecastDays
+	}
+	return min(max(int(days), 0), maxForecastDays)
+}
+
+// ConditionExecutor handles the "condition" node type. It compares a numeric variable
+// against the requested threshold. The variable defaults to "temperature" and may be set
+// to any dotted path via the node's "variable" metadata, e.g. "weather.daily.1.precipitationSum".
+// Temperature variables are converted into the execution's unit before comparison, since
+// that is the unit the threshold was entered in.
 type ConditionExecutor struct{}
 
 func (e *ConditionExecutor) Execute(_ context.Context, node Node, state *ExecutionState) (*StepResult, error) {
-	tempRaw, ok := state.Variables["temperature"]
+	variable, _ := node.Data.Metadata["variable"].(string)
+	if variable == "" {
+		variable = "temperature"
+	}
+	raw, ok := lookupVariable(state.Variables, variable)
 	if !ok {
-		return nil, fmt.Errorf("temperature variable not set")
+		return nil, fmt.Errorf("%s variable not set", variable)
 	}
-	temperature, ok := toFloat64(tempRaw)
+	value, ok := toFloat64(raw)
 	if !ok {
-		return nil, fmt.Errorf("temperature is not a number")
+		return nil, fmt.Errorf("%s is not a number", variable)
+	}
+	canonical := value
+	isTemperature := isTemperatureVariable(variable)
+	if isTemperature {
+		value = state.Unit.FromCelsius(value)
 	}
 
 	operator := state.Condition.Operator
 	threshold := state.Condition.Threshold
-	result := evaluateCondition(temperature, operator, threshold)
+	result := evaluateCondition(value, operator, threshold)
 
 	if result {
 		state.Variables["conditionResult"] = "true"
@@ -125,32 +310,74 @@ func (e *ConditionExecutor) Execute(_ context.Context, node Node, state *Executi
 	}
 
 	symbol := operatorSymbol(operator)
-	expression := fmt.Sprintf("%.1f %s %.1f", temperature, symbol, threshold)
+	expression := fmt.Sprintf("%.1f %s %.1f", value, symbol, threshold)
+
+	subject := fmt.Sprintf("%s %.1f", variable, value)
+	target := fmt.Sprintf("%.1f", threshold)
+	if isTemperature {
+		target += state.Unit.Symbol()
+		subject += state.Unit.Symbol()
+	}
+	if variable == "temperature" {
+		subject = fmt.Sprintf("Temperature %.1f%s", value, state.Unit.Symbol())
+	}
 
 	var message string
 	if result {
-		message = fmt.Sprintf("Temperature %.1f\u00b0C is %s %.1f\u00b0C - condition met", temperature, operatorLabel(operator), threshold)
+		message = fmt.Sprintf("%s is %s %s - condition met", subject, operatorLabel(operator), target)
 	} else {
-		message = fmt.Sprintf("Temperature %.1f\u00b0C is not %s %.1f\u00b0C - condition not met", temperature, operatorLabel(operator), threshold)
+		message = fmt.Sprintf("%s is not %s %s - condition not met", subject, operatorLabel(operator), target)
+	}
+
+	conditionResult := map[string]any{
+		"expression": expression,
+		"result":     result,
+		"variable":   variable,
+		"value":      value,
+		"operator":   operator,
+		"threshold":  threshold,
+	}
+	if isTemperature {
+		conditionResult["unit"] = state.Unit.Symbol()
+		conditionResult["valueCelsius"] = canonical
+		conditionResult["thresholdCelsius"] = state.Unit.ToCelsius(threshold)
+	}
+	if variable == "temperature" {
+		conditionResult["temperature"] = value
 	}
 
 	return &StepResult{
 		NodeID: node.ID, NodeType: node.Type, Label: node.Data.Label,
 		Status: "completed",
 		Output: map[string]any{
-			"message":      message,
-			"conditionMet": result,
-			"conditionResult": map[string]any{
-				"expression":  expression,
-				"result":      result,
-				"temperature": temperature,
-				"operator":    operator,
-				"threshold":   threshold,
-			},
+			"message":         message,
+			"conditionMet":    result,
+			"conditionResult": conditionResult,
 		},
 	}, nil
 }
 
+func (e *ConditionExecutor) Describe() NodeTypeDescriptor {
+	return NodeTypeDescriptor{
+		DisplayName: "Check Condition",
+		Category:    "logic",
+		Description: "Compares a numeric variable against the requested threshold",
+		ConfigSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"variable": map[string]any{"type": "string", "default": "temperature", "description": "Dotted variable path to compare"},
+			},
+		},
+		Inputs: []VariableDescriptor{
+			{Name: "temperature", Type: "number", Description: "Or the configured variable"},
+		},
+		Outputs: []VariableDescriptor{
+			{Name: "conditionResult", Type: "string", Description: `"true" or "false"`},
+		},
+		Handles: HandleDescriptor{Target: true, Source: true, SourceHandles: []string{"true", "false"}},
+	}
+}
+
 // EmailExecutor handles the "email" node type. It produces a mock email payload.
 type EmailExecutor struct{}
 
@@ -158,17 +385,32 @@ func (e *EmailExecutor) Execute(_ context.Context, node Node, state *ExecutionSt
 	name, _ := state.FormData["name"].(string)
 	email, _ := state.FormData["email"].(string)
 	city, _ := state.FormData["city"].(string)
-	temperature, _ := state.Variables["temperature"].(float64)
+	temperature, _ := toFloat64(state.Variables["temperature"])
+	apparent, _ := toFloat64(state.Variables["apparentTemperature"])
+	windSpeed, _ := toFloat64(state.Variables["windSpeed"])
+	precipitation, _ := toFloat64(state.Variables["precipitation"])
+	humidity, _ := toFloat64(state.Variables["humidity"])
 
 	tmpl, _ := node.Data.Metadata["emailTemplate"].(map[string]any)
 	subject, _ := tmpl["subject"].(string)
 	body, _ := tmpl["body"].(string)
 
 	// Template substitution
+	// Templates written before unit support hardcode "°C" after the placeholder;
+	// those pairs are rewritten first so the suffix matches the execution's unit.
+	unit := state.Unit
 	replacer := strings.NewReplacer(
+		"{{temperature}}\u00b0C", fmt.Sprintf("%.1f%s", unit.FromCelsius(temperature), unit.Symbol()),
+		"{{apparentTemperature}}\u00b0C", fmt.Sprintf("%.1f%s", unit.FromCelsius(apparent), unit.Symbol()),
 		"{{name}}", name,
 		"{{city}}", city,
-		"{{temperature}}", fmt.Sprintf("%.1f", temperature),
+		"{{unit}}", unit.Symbol(),
+		"{{temperature}}", fmt.Sprintf("%.1f", unit.FromCelsius(temperature)),
+		"{{temperatureCelsius}}", fmt.Sprintf("%.1f", temperature),
+		"{{apparentTemperature}}", fmt.Sprintf("%.1f", unit.FromCelsius(apparent)),
+		"{{windSpeed}}", fmt.Sprintf("%.1f", windSpeed),
+		"{{precipitation}}", fmt.Sprintf("%.1f", precipitation),
+		"{{humidity}}", fmt.Sprintf("%.0f", humidity),
 	)
 	body = replacer.Replace(body)
 	subject = replacer.Replace(subject)
@@ -197,6 +439,48 @@ func (e *EmailExecutor) Execute(_ context.Context, node Node, state *ExecutionSt
 	}, nil
 }
 
+// Simulate drafts the email as Execute does but reports it as not sent.
+func (e *EmailExecutor) Simulate(ctx context.Context, node Node, state *ExecutionState) (*StepResult, error) {
+	result, err := e.Execute(ctx, node, state)
+	if err != nil {
+		return nil, err
+	}
+	result.Output["message"] = fmt.Sprintf("%s; not sent in a dry run", result.Output["message"])
+	result.Output["emailSent"] = false
+	return result, nil
+}
+
+func (e *EmailExecutor) Describe() NodeTypeDescriptor {
+	return NodeTypeDescriptor{
+		DisplayName: "Send Alert",
+		Category:    "action",
+		Description: "Drafts an alert email from a template",
+		ConfigSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"emailTemplate": map[string]any{
+					"type":        "object",
+					"description": "Supports {{name}}, {{city}}, {{temperature}}, {{unit}} and other weather placeholders",
+					"properties": map[string]any{
+						"subject": map[string]any{"type": "string"},
+						"body":    map[string]any{"type": "string"},
+					},
+				},
+			},
+		},
+		Inputs: []VariableDescriptor{
+			{Name: "name", Type: "string"},
+			{Name: "email", Type: "string"},
+			{Name: "city", Type: "string"},
+			{Name: "temperature", Type: "number"},
+		},
+		Outputs: []VariableDescriptor{
+			{Name: "emailSent", Type: "boolean"},
+		},
+		Handles: HandleDescriptor{Target: true, Source: true},
+	}
+}
+
 // EndExecutor handles the "end" node type. It is a no-op that marks workflow completion.
 type EndExecutor struct{}
 
@@ -208,6 +492,15 @@ func (e *EndExecutor) Execute(_ context.Context, node Node, _ *ExecutionState) (
 	}, nil
 }
 
+func (e *EndExecutor) Describe() NodeTypeDescriptor {
+	return NodeTypeDescriptor{
+		DisplayName: "Complete",
+		Category:    "control",
+		Description: "Marks where the workflow finishes",
+		Handles:     HandleDescriptor{Target: true},
+	}
+}
+
 // evaluateCondition compares temperature against threshold using the given operator.
 // Both values are rounded to 1 decimal place to avoid floating-point precision issues.
 func evaluateCondition(temperature float64, operator string, threshold float64) bool {
@@ -0,0 +1,342 @@
+package workflow
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"workflow-code-test/api/pkg/resilience"
+	"workflow-code-test/api/pkg/tracing"
+)
+
+// ErrPlaceNotFound is returned by a Geocoder when no place matches the query.
+var ErrPlaceNotFound = errors.New("place not found")
+
+// Place is a geocoded location.
+type Place struct {
+	Name        string  `json:"name"`
+	Admin1      string  `json:"admin1,omitempty"` // State, province or region
+	Country     string  `json:"country"`
+	CountryCode string  `json:"countryCode"` // ISO 3166-1 alpha-2
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Population  int     `json:"population,omitempty"`
+}
+
+// Geocoder resolves a place name to coordinates. country is optional and may be an
+// ISO 3166-1 alpha-2 code or a country name; when empty, the most populous match wins.
+type Geocoder interface {
+	Geocode(ctx context.Context, name, country string) (*Place, error)
+}
+
+// matchesCountry reports whether p is in the requested country. An empty country matches all.
+func matchesCountry(p Place, country string) bool {
+	return country == "" || strings.EqualFold(p.CountryCode, country) || strings.EqualFold(p.Country, country)
+}
+
+// splitPlaceName splits "Perth, GB" into ("Perth", "GB"). Input without a comma is returned unchanged.
+func splitPlaceName(s string) (name, country string) {
+	name, country, _ = strings.Cut(s, ",")
+	return strings.TrimSpace(name), strings.TrimSpace(country)
+}
+
+//go:embed data/cities.csv
+var gazetteerCSV []byte
+
+// Gazetteer is an offline Geocoder backed by the bundled world cities dataset.
+type Gazetteer struct {
+	byName map[string][]Place // Lower-cased name -> places, most populous first
+}
+
+// NewGazetteer loads the bundled dataset.
+func NewGazetteer() (*Gazetteer, error) {
+	return parseGazetteer(gazetteerCSV)
+}
+
+// parseGazetteer reads CSV rows of name,admin1,country,country_code,latitude,longitude,population.
+func parseGazetteer(data []byte) (*Gazetteer, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read gazetteer: %w", err)
+	}
+
+	g := &Gazetteer{byName: make(map[string][]Place)}
+	for i, row := range rows {
+		if i == 0 {
+			continue // header
+		}
+		if len(row) != 7 {
+			return nil, fmt.Errorf("gazetteer row %d: expected 7 columns, got %d", i+1, len(row))
+		}
+		lat, errLat := strconv.ParseFloat(row[4], 64)
+		lon, errLon := strconv.ParseFloat(row[5], 64)
+		pop, errPop := strconv.Atoi(row[6])
+		if errLat != nil || errLon != nil || errPop != nil {
+			return nil, fmt.Errorf("gazetteer row %d: invalid number", i+1)
+		}
+		p := Place{
+			Name: row[0], Admin1: row[1], Country: row[2], CountryCode: row[3],
+			Latitude: lat, Longitude: lon, Population: pop,
+		}
+		key := strings.ToLower(p.Name)
+		g.byName[key] = append(g.byName[key], p)
+	}
+	for _, places := range g.byName {
+		sort.SliceStable(places, func(i, j int) bool { return places[i].Population > places[j].Population })
+	}
+	return g, nil
+}
+
+// Geocode looks the name up in the dataset.
+func (g *Gazetteer) Geocode(_ context.Context, name, country string) (*Place, error) {
+	for _, p := range g.byName[strings.ToLower(strings.TrimSpace(name))] {
+		if matchesCountry(p, country) {
+			return &p, nil
+		}
+	}
+	return nil, ErrPlaceNotFound
+}
+
+// OpenMeteoGeocoder calls the Open-Meteo geocoding API.
+type OpenMeteoGeocoder struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// DefaultGeocodingURL is the Open-Meteo geocoding search endpoint.
+const DefaultGeocodingURL = "https://geocoding-api.open-meteo.com/v1/search"
+
+// NewOpenMeteoGeocoder returns a geocoder for the search API at baseURL, giving up on
+// requests after timeout.
+func NewOpenMeteoGeocoder(baseURL string, timeout time.Duration) *OpenMeteoGeocoder {
+	return &OpenMeteoGeocoder{
+		httpClient: &http.Client{Timeout: timeout, Transport: tracing.Transport(nil)},
+		baseURL:    baseURL,
+	}
+}
+
+// openMeteoGeocodeResponse is the relevant subset of the geocoding API response.
+type openMeteoGeocodeResponse struct {
+	Results []struct {
+		Name        string  `json:"name"`
+		Latitude    float64 `json:"latitude"`
+		Longitude   float64 `json:"longitude"`
+		Country     string  `json:"country"`
+		CountryCode string  `json:"country_code"`
+		Admin1      string  `json:"admin1"`
+		Population  int     `json:"population"`
+	} `json:"results"`
+}
+
+// Geocode searches by name and returns the first result in the requested country.
+// The API orders results by relevance, which already favours larger places.
+func (c *OpenMeteoGeocoder) Geocode(ctx context.Context, name, country string) (*Place, error) {
+	q := url.Values{}
+	q.Set("name", name)
+	q.Set("count", "20")
+	q.Set("language", "en")
+	q.Set("format", "json")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("geocoding API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geocoding API returned status %d", resp.StatusCode)
+	}
+
+	var result openMeteoGeocodeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode geocoding response: %w", err)
+	}
+
+	for _, r := range result.Results {
+		p := Place{
+			Name: r.Name, Admin1: r.Admin1, Country: r.Country, CountryCode: r.CountryCode,
+			Latitude: r.Latitude, Longitude: r.Longitude, Population: r.Population,
+		}
+		if matchesCountry(p, country) {
+			return &p, nil
+		}
+	}
+	return nil, ErrPlaceNotFound
+}
+
+// ChainGeocoder tries each geocoder in order, moving on only when a place is not found.
+type ChainGeocoder []Geocoder
+
+// Geocode returns the first match from the chain.
+func (c ChainGeocoder) Geocode(ctx context.Context, name, country string) (*Place, error) {
+	for _, g := range c {
+		p, err := g.Geocode(ctx, name, country)
+		if errors.Is(err, ErrPlaceNotFound) {
+			continue
+		}
+		return p, err
+	}
+	return nil, ErrPlaceNotFound
+}
+
+// Cache limits for CachingGeocoder. Misses are keyed by whatever city a caller sends,
+// so they get a smaller budget than places and cannot push places out.
+const (
+	maxGeocodePlaces = 10000
+	maxGeocodeMisses = 1000
+)
+
+// CachingGeocoder memoises lookups, including misses, for a fixed TTL.
+// Errors other than ErrPlaceNotFound are not cached. Places and misses are each kept
+// to a fixed number of entries, evicting the least recently used.
+type CachingGeocoder struct {
+	next Geocoder
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*list.Element // Values are *geocodeCacheEntry
+	places    *list.List               // Cached places, most recently used first
+	misses    *list.List               // Cached misses, most recently used first
+	maxPlaces int
+	maxMisses int
+}
+
+type geocodeCacheEntry struct {
+	key     string
+	place   *Place
+	expires time.Time
+}
+
+// NewCachingGeocoder wraps next with a cache whose entries live for ttl.
+func NewCachingGeocoder(next Geocoder, ttl time.Duration) *CachingGeocoder {
+	return &CachingGeocoder{
+		next:      next,
+		ttl:       ttl,
+		now:       time.Now,
+		entries:   make(map[string]*list.Element),
+		places:    list.New(),
+		misses:    list.New(),
+		maxPlaces: maxGeocodePlaces,
+		maxMisses: maxGeocodeMisses,
+	}
+}
+
+// Geocode returns a cached result if present, otherwise delegates and caches the outcome.
+func (c *CachingGeocoder) Geocode(ctx context.Context, name, country string) (*Place, error) {
+	key := strings.ToLower(strings.TrimSpace(name)) + "|" + strings.ToLower(strings.TrimSpace(country))
+
+	if entry, ok := c.get(key); ok {
+		if entry.place == nil {
+			return nil, ErrPlaceNotFound
+		}
+		p := *entry.place
+		return &p, nil
+	}
+
+	p, err := c.next.Geocode(ctx, name, country)
+	if err != nil && !errors.Is(err, ErrPlaceNotFound) {
+		return nil, err
+	}
+	c.put(key, p)
+	return p, err
+}
+
+// get returns the unexpired entry for key, marking it recently used. Expired entries
+// are dropped.
+func (c *CachingGeocoder) get(key string) (geocodeCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return geocodeCacheEntry{}, false
+	}
+	entry := elem.Value.(*geocodeCacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.remove(elem)
+		return geocodeCacheEntry{}, false
+	}
+	c.listFor(entry.place).MoveToFront(elem)
+	return *entry, true
+}
+
+// put caches place, or a miss if it is nil, evicting the least recently used entry of
+// the same kind when that kind is full.
+func (c *CachingGeocoder) put(key string, place *Place) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	l, limit := c.places, c.maxPlaces
+	if place == nil {
+		l, limit = c.misses, c.maxMisses
+	}
+	c.entries[key] = l.PushFront(&geocodeCacheEntry{key: key, place: place, expires: c.now().Add(c.ttl)})
+	for l.Len() > limit {
+		c.remove(l.Back())
+	}
+}
+
+func (c *CachingGeocoder) remove(elem *list.Element) {
+	entry := elem.Value.(*geocodeCacheEntry)
+	c.listFor(entry.place).Remove(elem)
+	delete(c.entries, entry.key)
+}
+
+func (c *CachingGeocoder) listFor(place *Place) *list.List {
+	if place == nil {
+		return c.misses
+	}
+	return c.places
+}
+
+// GuardedGeocoder wraps a Geocoder with a rate limiter and circuit breaker.
+// A miss is a successful call and does not count against the breaker.
+type GuardedGeocoder struct {
+	geocoder Geocoder
+	guard    *resilience.Guard
+}
+
+// NewGuardedGeocoder wraps geocoder with the given guard.
+func NewGuardedGeocoder(geocoder Geocoder, guard *resilience.Guard) *GuardedGeocoder {
+	return &GuardedGeocoder{geocoder: geocoder, guard: guard}
+}
+
+// Geocode delegates to the wrapped geocoder under the guard.
+func (g *GuardedGeocoder) Geocode(ctx context.Context, name, country string) (*Place, error) {
+	var place *Place
+	var notFound bool
+	err := g.guard.Do(ctx, func(ctx context.Context) error {
+		var err error
+		place, err = g.geocoder.Geocode(ctx, name, country)
+		if errors.Is(err, ErrPlaceNotFound) {
+			notFound = true
+			return nil
+		}
+		return err
+	})
+	if notFound {
+		return nil, ErrPlaceNotFound
+	}
+	return place, err
+}
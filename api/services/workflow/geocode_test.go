@@ -0,0 +1,147 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubGeocoder implements Geocoder for testing and counts calls.
+type stubGeocoder struct {
+	place *Place
+	err   error
+	calls int
+}
+
+func (s *stubGeocoder) Geocode(_ context.Context, _, _ string) (*Place, error) {
+	s.calls++
+	return s.place, s.err
+}
+
+func TestGazetteer_Disambiguation(t *testing.T) {
+	g, err := NewGazetteer()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	tests := []struct {
+		name, country string
+		wantCode      string
+		wantAdmin1    string
+	}{
+		{"Perth", "", "AU", "Western Australia"},
+		{"perth", "GB", "GB", "Scotland"},
+		{"Perth", "United Kingdom", "GB", "Scotland"},
+		{"Springfield", "", "US", "Missouri"},
+		{"London", "CA", "CA", "Ontario"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"_"+tt.country, func(t *testing.T) {
+			p, err := g.Geocode(ctx, tt.name, tt.country)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCode, p.CountryCode)
+			assert.Equal(t, tt.wantAdmin1, p.Admin1)
+		})
+	}
+
+	_, err = g.Geocode(ctx, "Atlantis", "")
+	assert.ErrorIs(t, err, ErrPlaceNotFound)
+	_, err = g.Geocode(ctx, "Tokyo", "US")
+	assert.ErrorIs(t, err, ErrPlaceNotFound)
+}
+
+func TestOpenMeteoGeocoder_FiltersByCountry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Paris", r.URL.Query().Get("name"))
+		w.Write([]byte(`{"results": [
+			{"name": "Paris", "latitude": 48.85, "longitude": 2.35, "country": "France", "country_code": "FR", "population": 2138551},
+			{"name": "Paris", "latitude": 33.66, "longitude": -95.56, "country": "United States", "country_code": "US", "admin1": "Texas"}
+		]}`))
+	}))
+	defer srv.Close()
+
+	g := NewOpenMeteoGeocoder(srv.URL, time.Second)
+
+	p, err := g.Geocode(context.Background(), "Paris", "")
+	require.NoError(t, err)
+	assert.Equal(t, "FR", p.CountryCode)
+
+	p, err = g.Geocode(context.Background(), "Paris", "us")
+	require.NoError(t, err)
+	assert.Equal(t, "Texas", p.Admin1)
+
+	_, err = g.Geocode(context.Background(), "Paris", "DE")
+	assert.ErrorIs(t, err, ErrPlaceNotFound)
+}
+
+func TestChainGeocoder_FallsThroughOnMissOnly(t *testing.T) {
+	miss := &stubGeocoder{err: ErrPlaceNotFound}
+	hit := &stubGeocoder{place: &Place{Name: "Tokyo"}}
+	broken := &stubGeocoder{err: fmt.Errorf("connection refused")}
+
+	p, err := ChainGeocoder{miss, hit}.Geocode(context.Background(), "Tokyo", "")
+	require.NoError(t, err)
+	assert.Equal(t, "Tokyo", p.Name)
+
+	_, err = ChainGeocoder{broken, hit}.Geocode(context.Background(), "Tokyo", "")
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Equal(t, 1, hit.calls)
+}
+
+func TestCachingGeocoder(t *testing.T) {
+	clock := time.Now()
+	next := &stubGeocoder{place: &Place{Name: "Tokyo"}}
+	c := NewCachingGeocoder(next, time.Hour)
+	c.now = func() time.Time { return clock }
+	ctx := context.Background()
+
+	c.Geocode(ctx, "Tokyo", "")
+	c.Geocode(ctx, " tokyo ", "")
+	assert.Equal(t, 1, next.calls)
+
+	clock = clock.Add(2 * time.Hour)
+	c.Geocode(ctx, "Tokyo", "")
+	assert.Equal(t, 2, next.calls)
+
+	next.err = fmt.Errorf("timeout")
+	next.place = nil
+	_, err := c.Geocode(ctx, "Osaka", "")
+	require.Error(t, err)
+	_, err = c.Geocode(ctx, "Osaka", "")
+	require.Error(t, err)
+	assert.Equal(t, 4, next.calls, "errors must not be cached")
+}
+
+func TestCachingGeocoder_EvictsLeastRecentlyUsed(t *testing.T) {
+	next := &stubGeocoder{place: &Place{Name: "Somewhere"}}
+	c := NewCachingGeocoder(next, time.Hour)
+	c.maxPlaces, c.maxMisses = 2, 1
+	ctx := context.Background()
+
+	c.Geocode(ctx, "Tokyo", "")
+	c.Geocode(ctx, "Osaka", "")
+	c.Geocode(ctx, "Tokyo", "") // Tokyo is now the most recently used
+	c.Geocode(ctx, "Kyoto", "") // Evicts Osaka
+	assert.Equal(t, 3, next.calls)
+	c.Geocode(ctx, "Tokyo", "")
+	assert.Equal(t, 3, next.calls)
+	c.Geocode(ctx, "Osaka", "")
+	assert.Equal(t, 4, next.calls)
+
+	// Misses have their own budget and do not evict places
+	next.place, next.err = nil, ErrPlaceNotFound
+	c.Geocode(ctx, "Atlantis", "")
+	c.Geocode(ctx, "Lemuria", "")
+	assert.Len(t, c.entries, 3)
+	assert.Equal(t, 2, c.places.Len())
+	assert.Equal(t, 1, c.misses.Len())
+	next.calls = 0
+	c.Geocode(ctx, "Lemuria", "")
+	c.Geocode(ctx, "Atlantis", "")
+	assert.Equal(t, 1, next.calls, "only the latest miss is kept")
+}
@@ -0,0 +1,164 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"workflow-code-test/api/pkg/auth"
+	"workflow-code-test/api/pkg/logging"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	idempotentReplayed   = "Idempotent-Replayed"
+	maxIdempotencyKeyLen = 255
+	maxIdempotentBody    = 1 << 20 // 1 MiB; the body is buffered to hash and replay it
+
+	// idempotencyLock is how long a key stays reserved for an in-flight request. A key left
+	// reserved by a crashed replica can be taken over once this passes.
+	idempotencyLock = 5 * time.Minute
+)
+
+// IdempotencyStore persists responses keyed by Idempotency-Key so that retried
+// requests replay the first response instead of running again.
+type IdempotencyStore interface {
+	// ReserveIdempotencyKey claims key for a new request. It returns nil if the key was
+	// free, expired or abandoned, and the existing record otherwise.
+	ReserveIdempotencyKey(ctx context.Context, rec IdempotencyRecord, now, lockedUntil time.Time) (*IdempotencyRecord, error)
+	// CompleteIdempotencyKey stores the response for a reserved key.
+	CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, response []byte) error
+	// ReleaseIdempotencyKey frees a reserved key so the request can be retried.
+	ReleaseIdempotencyKey(ctx context.Context, scope, key string) error
+	// PurgeIdempotencyKeys deletes keys whose retention window has passed.
+	PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int64, error)
+}
+
+// idempotent makes next safe to retry. Requests carrying an Idempotency-Key header run
+// once per key, caller and request path; repeats within the retention window replay the
+// stored response, and reusing a key with a different body is rejected with 409 Conflict.
+// Server errors and authorization failures are not stored, so those requests can be retried.
+func (s *Service) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || s.idempotency == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			writeError(w, http.StatusBadRequest, errInvalid(idempotencyKeyHeader).Error())
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		rec := IdempotencyRecord{
+			Scope:       idempotencyScope(r),
+			Key:         key,
+			RequestHash: requestHash(body),
+			ExpiresAt:   now.Add(s.idempotencyTTL),
+		}
+		existing, err := s.idempotency.ReserveIdempotencyKey(r.Context(), rec, now, now.Add(idempotencyLock))
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Failed to reserve idempotency key", "scope", rec.Scope, "error", err)
+			writeError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.RequestHash != rec.RequestHash:
+				writeError(w, http.StatusConflict, "Idempotency-Key was already used with a different request")
+			case existing.StatusCode == 0:
+				writeError(w, http.StatusConflict, "a request with this Idempotency-Key is still in progress")
+			default:
+				logging.FromContext(r.Context()).Debug("Replaying idempotent response", "scope", rec.Scope)
+				w.Header().Set(idempotentReplayed, "true")
+				w.WriteHeader(existing.StatusCode)
+				w.Write(existing.Response)
+			}
+			return
+		}
+
+		rw := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r)
+
+		// Store the outcome even if the client went away, so its retry replays it
+		ctx := context.WithoutCancel(r.Context())
+		if !storeIdempotentResponse(rw.status) {
+			if err := s.idempotency.ReleaseIdempotencyKey(ctx, rec.Scope, rec.Key); err != nil {
+				logging.FromContext(r.Context()).Error("Failed to release idempotency key", "scope", rec.Scope, "error", err)
+			}
+			return
+		}
+		if err := s.idempotency.CompleteIdempotencyKey(ctx, rec.Scope, rec.Key, rw.status, rw.body.Bytes()); err != nil {
+			logging.FromContext(r.Context()).Error("Failed to store idempotent response", "scope", rec.Scope, "error", err)
+		}
+	})
+}
+
+// idempotencyScope namespaces keys by tenant and caller as well as request, so one
+// caller cannot replay another's response by guessing its key.
+func idempotencyScope(r *http.Request) string {
+	scope := r.Method + " " + r.URL.Path
+	if p, ok := auth.FromContext(r.Context()); ok {
+		scope = p.Subject + " " + scope
+	}
+	return auth.Tenant(r.Context()) + " " + scope
+}
+
+// storeIdempotentResponse reports whether a response with status is final for its key.
+func storeIdempotentResponse(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return false
+	}
+	return status < http.StatusInternalServerError
+}
+
+// requestHash fingerprints a request body. JSON bodies are compared by value, so
+// whitespace and key order do not make a retry look like a different request.
+func requestHash(body []byte) string {
+	var v any
+	if err := json.Unmarshal(body, &v); err == nil {
+		if canonical, err := json.Marshal(v); err == nil {
+			body = canonical
+		}
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// recordingWriter passes a response through while keeping a copy of its status and body.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,198 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workflow-code-test/api/pkg/auth"
+)
+
+// memIdempotencyStore implements IdempotencyStore in memory for testing.
+type memIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*IdempotencyRecord
+}
+
+func newMemIdempotencyStore() *memIdempotencyStore {
+	return &memIdempotencyStore{records: make(map[string]*IdempotencyRecord)}
+}
+
+func (m *memIdempotencyStore) ReserveIdempotencyKey(_ context.Context, rec IdempotencyRecord, now, _ time.Time) (*IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := rec.Scope + "|" + rec.Key
+	if existing, ok := m.records[id]; ok && !existing.ExpiresAt.Before(now) {
+		cp := *existing
+		return &cp, nil
+	}
+	m.records[id] = &rec
+	return nil, nil
+}
+
+func (m *memIdempotencyStore) CompleteIdempotencyKey(_ context.Context, scope, key string, statusCode int, response []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec := m.records[scope+"|"+key]
+	rec.StatusCode = statusCode
+	rec.Response = append([]byte(nil), response...)
+	return nil
+}
+
+func (m *memIdempotencyStore) ReleaseIdempotencyKey(_ context.Context, scope, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, scope+"|"+key)
+	return nil
+}
+
+func (m *memIdempotencyStore) PurgeIdempotencyKeys(_ context.Context, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for id, rec := range m.records {
+		if rec.ExpiresAt.Before(now) {
+			delete(m.records, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+const (
+	executePath     = "/api/v1/workflows/550e8400-e29b-41d4-a716-446655440000/execute"
+	executeBody     = `{"formData": {"name": "Alice", "email": "alice@example.com", "city": "Sydney"}, "condition": {"operator": "greater_than", "threshold": 25}}`
+	executeBodyAlt  = `{"condition":{"threshold":25,"operator":"greater_than"},"formData":{"city":"Sydney","email":"alice@example.com","name":"Alice"}}`
+	executeBodyDiff = `{"formData": {"name": "Alice", "email": "alice@example.com", "city": "Sydney"}, "condition": {"operator": "greater_than", "threshold": 30}}`
+)
+
+func executeWithKey(router http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", executePath, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func executionID(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var result ExecutionResults
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	return result.ExecutionID
+}
+
+func TestIdempotency_ReplaysFirstResponse(t *testing.T) {
+	svc := newTestService(testWorkflow(), 30)
+	router := setupRouter(svc)
+
+	first := executeWithKey(router, "retry-1", executeBody)
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.Empty(t, first.Header().Get(idempotentReplayed))
+
+	// Same body with different formatting and key order
+	second := executeWithKey(router, "retry-1", executeBodyAlt)
+	require.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, "true", second.Header().Get(idempotentReplayed))
+	assert.Equal(t, executionID(t, first), executionID(t, second))
+
+	// Without a key every request runs
+	third := executeWithKey(router, "", executeBody)
+	assert.NotEqual(t, executionID(t, first), executionID(t, third))
+}
+
+func TestIdempotency_DifferentBodyConflicts(t *testing.T) {
+	svc := newTestService(testWorkflow(), 30)
+	router := setupRouter(svc)
+
+	require.Equal(t, http.StatusOK, executeWithKey(router, "retry-1", executeBody).Code)
+	w := executeWithKey(router, "retry-1", executeBodyDiff)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var result map[string]string
+	json.NewDecoder(w.Body).Decode(&result)
+	assert.Equal(t, "Idempotency-Key was already used with a different request", result["message"])
+}
+
+func TestIdempotency_InFlightConflicts(t *testing.T) {
+	svc := newTestService(testWorkflow(), 30)
+	router := setupRouter(svc)
+	store := svc.idempotency.(*memIdempotencyStore)
+	store.ReserveIdempotencyKey(context.Background(), IdempotencyRecord{
+		Scope: auth.DefaultTenant + " POST " + executePath, Key: "retry-1", RequestHash: requestHash([]byte(executeBody)), ExpiresAt: time.Now().Add(time.Hour),
+	}, time.Now(), time.Now().Add(time.Minute))
+
+	w := executeWithKey(router, "retry-1", executeBody)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestIdempotency_ServerErrorsAreNotStored(t *testing.T) {
+	svc := newTestService(testWorkflow(), 30)
+	router := setupRouter(svc)
+	repo := svc.repo.(*stubRepo)
+	repo.err = errors.New("connection refused")
+
+	require.Equal(t, http.StatusInternalServerError, executeWithKey(router, "retry-1", executeBody).Code)
+
+	repo.err = nil
+	w := executeWithKey(router, "retry-1", executeBody)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(idempotentReplayed))
+}
+
+func TestIdempotency_ClientErrorsAreReplayed(t *testing.T) {
+	svc := newTestService(testWorkflow(), 30)
+	router := setupRouter(svc)
+
+	require.Equal(t, http.StatusBadRequest, executeWithKey(router, "retry-1", `{}`).Code)
+	w := executeWithKey(router, "retry-1", `{}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "true", w.Header().Get(idempotentReplayed))
+}
+
+func TestIdempotency_KeyTooLong(t *testing.T) {
+	svc := newTestService(testWorkflow(), 30)
+	router := setupRouter(svc)
+
+	w := executeWithKey(router, strings.Repeat("k", maxIdempotencyKeyLen+1), executeBody)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestIdempotency_BodyTooLarge(t *testing.T) {
+	svc := newTestService(testWorkflow(), 30)
+	router := setupRouter(svc)
+
+	w := executeWithKey(router, "big-1", `{"padding": "`+strings.Repeat("x", maxIdempotentBody)+`"}`)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "request body too large", errorMessage(t, w))
+	assert.Empty(t, svc.idempotency.(*memIdempotencyStore).records, "nothing is reserved")
+}
+
+func TestIdempotency_ExpiredKeyRunsAgain(t *testing.T) {
+	svc := newTestService(testWorkflow(), 30)
+	svc.idempotencyTTL = -time.Second // Stored responses expire immediately
+	router := setupRouter(svc)
+
+	first := executeWithKey(router, "retry-1", executeBody)
+	second := executeWithKey(router, "retry-1", executeBody)
+
+	assert.Empty(t, second.Header().Get(idempotentReplayed))
+	assert.NotEqual(t, executionID(t, first), executionID(t, second))
+
+	n, _ := svc.idempotency.PurgeIdempotencyKeys(context.Background(), time.Now())
+	assert.Equal(t, int64(1), n)
+}
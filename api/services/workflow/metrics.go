@@ -0,0 +1,99 @@
+package workflow
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics records execution and integration metrics. A nil *Metrics records nothing.
+type Metrics struct {
+	executionsStarted  *prometheus.CounterVec
+	executionsFinished *prometheus.CounterVec
+	nodeDuration       *prometheus.HistogramVec
+	weatherDuration    prometheus.Histogram
+	weatherErrors      prometheus.Counter
+}
+
+// NewMetrics creates the workflow metrics and registers them on reg.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		executionsStarted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "workflow_executions_started_total",
+			Help: "Workflow executions started, by workflow.",
+		}, []string{"workflow_id"}),
+		executionsFinished: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "workflow_executions_finished_total",
+			Help: "Workflow executions that stopped running, by workflow and status (completed, failed or suspended).",
+		}, []string{"workflow_id", "status"}),
+		nodeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "workflow_node_duration_seconds",
+			Help:    "Time spent executing nodes, by node type and step status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"node_type", "status"}),
+		weatherDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "workflow_weather_request_duration_seconds",
+			Help:    "Latency of weather API requests, including failed ones.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		weatherErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "workflow_weather_errors_total",
+			Help: "Weather API requests that failed.",
+		}),
+	}
+	reg.MustRegister(m.executionsStarted, m.executionsFinished, m.nodeDuration, m.weatherDuration, m.weatherErrors)
+	return m
+}
+
+// executionStarted counts a new execution of workflowID.
+func (m *Metrics) executionStarted(workflowID string) {
+	if m == nil {
+		return
+	}
+	m.executionsStarted.WithLabelValues(workflowID).Inc()
+}
+
+// executionFinished counts an execution that stopped running. Engine errors count as failed.
+func (m *Metrics) executionFinished(workflowID string, results *ExecutionResults, err error) {
+	if m == nil {
+		return
+	}
+	status := "failed"
+	if err == nil && results != nil {
+		status = results.Status
+	}
+	m.executionsFinished.WithLabelValues(workflowID, status).Inc()
+}
+
+// observeNode records how long a node of nodeType took.
+func (m *Metrics) observeNode(nodeType, status string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.nodeDuration.WithLabelValues(nodeType, status).Observe(d.Seconds())
+}
+
+// InstrumentedWeatherClient records the latency and errors of a WeatherClient.
+type InstrumentedWeatherClient struct {
+	client  WeatherClient
+	metrics *Metrics
+}
+
+// NewInstrumentedWeatherClient wraps client, recording to metrics.
+func NewInstrumentedWeatherClient(client WeatherClient, metrics *Metrics) *InstrumentedWeatherClient {
+	return &InstrumentedWeatherClient{client: client, metrics: metrics}
+}
+
+// GetWeather delegates to the wrapped client.
+func (c *InstrumentedWeatherClient) GetWeather(ctx context.Context, lat, lon float64, forecastDays int) (*WeatherReport, error) {
+	start := time.Now()
+	report, err := c.client.GetWeather(ctx, lat, lon, forecastDays)
+	if c.metrics != nil {
+		c.metrics.weatherDuration.Observe(time.Since(start).Seconds())
+		if err != nil {
+			c.metrics.weatherErrors.Inc()
+		}
+	}
+	return report, err
+}
@@ -0,0 +1,65 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetricsEngine(client WeatherClient) (*Engine, *Metrics) {
+	metrics := NewMetrics(prometheus.NewRegistry())
+	engine := NewEngine(NewRegistry(NewInstrumentedWeatherClient(client, metrics), nil, defaultInlineDelay))
+	engine.metrics = metrics
+	return engine, metrics
+}
+
+func metricsState() *ExecutionState {
+	return &ExecutionState{
+		FormData:  map[string]any{"name": "Alice", "email": "alice@example.com", "city": "Sydney"},
+		Condition: ConditionInput{Operator: "greater_than", Threshold: 25},
+		Variables: map[string]any{},
+	}
+}
+
+func TestMetrics_CompletedExecution(t *testing.T) {
+	engine, metrics := newMetricsEngine(&mockWeatherClient{temperature: 30})
+	wf := testWorkflow()
+
+	results, err := engine.Execute(context.Background(), wf, metricsState())
+	require.NoError(t, err)
+	require.Equal(t, "completed", results.Status)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.executionsStarted.WithLabelValues(wf.ID)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.executionsFinished.WithLabelValues(wf.ID, "completed")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.weatherErrors))
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.weatherDuration))
+	// One series per node type, all completed
+	assert.Equal(t, len(results.Steps), testutil.CollectAndCount(metrics.nodeDuration))
+}
+
+func TestMetrics_FailedExecution(t *testing.T) {
+	engine, metrics := newMetricsEngine(&mockWeatherClient{err: fmt.Errorf("API timeout")})
+	wf := testWorkflow()
+
+	results, err := engine.Execute(context.Background(), wf, metricsState())
+	require.NoError(t, err)
+	require.Equal(t, "failed", results.Status)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.executionsFinished.WithLabelValues(wf.ID, "failed")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.weatherErrors))
+	text, err := testutil.CollectAndFormat(metrics.nodeDuration, expfmt.TypeTextPlain, "workflow_node_duration_seconds")
+	require.NoError(t, err)
+	assert.Contains(t, string(text), `workflow_node_duration_seconds_count{node_type="integration",status="error"} 1`)
+}
+
+func TestMetrics_NilRecordsNothing(t *testing.T) {
+	engine := NewEngine(NewRegistry(NewInstrumentedWeatherClient(&mockWeatherClient{temperature: 30}, nil), nil, defaultInlineDelay))
+	_, err := engine.Execute(context.Background(), testWorkflow(), metricsState())
+	assert.NoError(t, err)
+}
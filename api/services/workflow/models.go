@@ -4,12 +4,14 @@ import "time"
 
 // Workflow represents a persisted workflow definition with its graph of nodes and edges.
 type Workflow struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Nodes     []Node    `json:"nodes"`
-	Edges     []Edge    `json:"edges"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID        string     `json:"id"`
+	TenantID  string     `json:"-"`
+	Name      string     `json:"name"`
+	Nodes     []Node     `json:"nodes"`
+	Edges     []Edge     `json:"edges"`
+	Tests     []TestCase `json:"tests,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
 }
 
 // Node represents a single step in a workflow graph.
@@ -48,12 +50,21 @@ type Edge struct {
 }
 
 // ExecuteRequest is the JSON body sent by the frontend to execute a workflow.
+// Unit selects the temperature unit ("celsius", "fahrenheit" or "kelvin"); when empty it
+// is derived from Locale (e.g. "en-US" selects Fahrenheit) and otherwise defaults to Celsius.
+// Mode "dry_run" skips nodes' external effects (see Simulator). Mocks maps node IDs to
+// outputs used instead of running those nodes, so authors can try each branch.
 type ExecuteRequest struct {
-	FormData  map[string]any `json:"formData"`
-	Condition ConditionInput `json:"condition"`
+	FormData  map[string]any            `json:"formData"`
+	Condition ConditionInput            `json:"condition"`
+	Unit      string                    `json:"unit,omitempty"`
+	Locale    string                    `json:"locale,omitempty"`
+	Mode      string                    `json:"mode,omitempty"` // ModeLive, the default, or ModeDryRun
+	Mocks     map[string]map[string]any `json:"mocks,omitempty"`
 }
 
 // ConditionInput holds the operator and threshold for condition evaluation.
+// Temperature thresholds are expressed in the request's unit.
 type ConditionInput struct {
 	Operator  string  `json:"operator"`
 	Threshold float64 `json:"threshold"`
@@ -61,13 +72,15 @@ type ConditionInput struct {
 
 // ExecutionResults is the top-level response returned after executing a workflow.
 type ExecutionResults struct {
-	ExecutionID   string         `json:"executionId"`
-	Status        string         `json:"status"`
-	StartTime     string         `json:"startTime"`
-	EndTime       string         `json:"endTime"`
-	TotalDuration int64          `json:"totalDuration"`
+	ExecutionID   string          `json:"executionId"`
+	WorkflowID    string          `json:"workflowId,omitempty"`
+	Status        string          `json:"status"`
+	StartTime     string          `json:"startTime"`
+	EndTime       string          `json:"endTime"`
+	TotalDuration int64           `json:"totalDuration"`
 	Steps         []ExecutionStep `json:"steps"`
-	Metadata      map[string]any `json:"metadata,omitempty"`
+	Metadata      map[string]any  `json:"metadata,omitempty"`
+	ResumeAt      *time.Time      `json:"resumeAt,omitempty"` // Set while the execution is suspended
 }
 
 // ExecutionStep represents the result of executing a single node.
@@ -82,4 +95,48 @@ type ExecutionStep struct {
 	Output     map[string]any `json:"output"`
 	Timestamp  string         `json:"timestamp"`
 	Error      string         `json:"error,omitempty"`
+	Logs       []StepLogEntry `json:"logs,omitempty"`      // Entries logged by the executor while running the step
+	Simulated  bool           `json:"simulated,omitempty"` // The node's external effects did not happen: it was mocked or ran in a dry run
+	Mocked     bool           `json:"mocked,omitempty"`    // The output came from the request's mocks, not the executor
+}
+
+// Schedule runs a workflow on a cron expression with a fixed input payload.
+// Cron is a standard five-field expression evaluated in Timezone.
+type Schedule struct {
+	ID         string         `json:"id"`
+	TenantID   string         `json:"-"`
+	WorkflowID string         `json:"workflowId"`
+	Cron       string         `json:"cron"`
+	Timezone   string         `json:"timezone"`
+	Input      ExecuteRequest `json:"input"`
+	Enabled    bool           `json:"enabled"`
+	NextRunAt  *time.Time     `json:"nextRunAt,omitempty"`
+	LastRunAt  *time.Time     `json:"lastRunAt,omitempty"`
+	LastStatus string         `json:"lastStatus,omitempty"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	UpdatedAt  time.Time      `json:"updatedAt"`
+}
+
+// SuspendedExecution is an execution paused by a delay node, persisted until ResumeAt.
+// ResumeNodeID is the node to continue from; it is empty when the delay was the last node.
+type SuspendedExecution struct {
+	ExecutionID  string
+	TenantID     string
+	WorkflowID   string
+	ResumeNodeID string
+	ResumeAt     time.Time
+	State        ExecutionState
+	Results      ExecutionResults
+}
+
+// IdempotencyRecord is the stored outcome of a request made with an Idempotency-Key.
+// Scope is the caller's tenant and subject and the request's method and path; StatusCode
+// is 0 while the request is in flight.
+type IdempotencyRecord struct {
+	Scope       string
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Response    []byte
+	ExpiresAt   time.Time
 }
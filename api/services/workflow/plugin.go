@@ -0,0 +1,286 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"workflow-code-test/api/pkg/logging"
+)
+
+// PluginProtocolVersion is the version of the JSON-over-stdio protocol spoken with
+// plugin executables. See docs/plugin-protocol.md.
+const PluginProtocolVersion = 1
+
+const (
+	defaultPluginTimeout = 30 * time.Second
+	maxPluginResponse    = 1 << 20 // 1 MiB
+	maxPluginStderr      = 4096
+)
+
+// pluginRequest is written to a plugin's stdin as a single JSON document.
+type pluginRequest struct {
+	ProtocolVersion int          `json:"protocolVersion"`
+	Method          string       `json:"method"` // "describe" or "execute"
+	Node            *Node        `json:"node,omitempty"`
+	State           *pluginState `json:"state,omitempty"`
+}
+
+// pluginState is the subset of ExecutionState exposed to plugins.
+type pluginState struct {
+	FormData  map[string]any  `json:"formData"`
+	Condition ConditionInput  `json:"condition"`
+	Unit      TemperatureUnit `json:"unit,omitempty"`
+	Variables map[string]any  `json:"variables"`
+}
+
+// PluginDescriptor is a plugin's answer to "describe".
+type PluginDescriptor struct {
+	ProtocolVersion int                  `json:"protocolVersion"`
+	Type            string               `json:"type"`
+	DisplayName     string               `json:"displayName,omitempty"`
+	Category        string               `json:"category,omitempty"`
+	Description     string               `json:"description,omitempty"`
+	ConfigSchema    map[string]any       `json:"configSchema,omitempty"`
+	Inputs          []VariableDescriptor `json:"inputs,omitempty"`
+	Outputs         []VariableDescriptor `json:"outputs,omitempty"`
+	Handles         *HandleDescriptor    `json:"handles,omitempty"`
+}
+
+// pluginExecuteResponse is a plugin's answer to "execute".
+type pluginExecuteResponse struct {
+	Status    string         `json:"status"`
+	Output    map[string]any `json:"output"`
+	Variables map[string]any `json:"variables,omitempty"` // Merged into the execution's variables
+	Handle    string         `json:"handle,omitempty"`    // Outgoing sourceHandle to follow
+	Error     string         `json:"error,omitempty"`
+}
+
+// PluginExecutor runs a node by invoking an external executable, one process per call.
+type PluginExecutor struct {
+	path       string
+	descriptor PluginDescriptor
+	timeout    time.Duration
+}
+
+// Type returns the node type the plugin registered under.
+func (p *PluginExecutor) Type() string {
+	return p.descriptor.Type
+}
+
+// Descriptor returns the plugin's self-description.
+func (p *PluginExecutor) Descriptor() PluginDescriptor {
+	return p.descriptor
+}
+
+// Describe maps the plugin's self-description onto a catalog entry.
+func (p *PluginExecutor) Describe() NodeTypeDescriptor {
+	d := p.descriptor
+	desc := NodeTypeDescriptor{
+		Type:         d.Type,
+		DisplayName:  d.DisplayName,
+		Category:     d.Category,
+		Description:  d.Description,
+		ConfigSchema: d.ConfigSchema,
+		Inputs:       d.Inputs,
+		Outputs:      d.Outputs,
+		Handles:      HandleDescriptor{Target: true, Source: true},
+	}
+	if desc.DisplayName == "" {
+		desc.DisplayName = d.Type
+	}
+	if desc.Category == "" {
+		desc.Category = "plugin"
+	}
+	if d.Handles != nil {
+		desc.Handles = *d.Handles
+	}
+	return desc
+}
+
+// Execute sends the node and current state to the plugin and applies its response.
+func (p *PluginExecutor) Execute(ctx context.Context, node Node, state *ExecutionState) (*StepResult, error) {
+	req := pluginRequest{
+		ProtocolVersion: PluginProtocolVersion,
+		Method:          "execute",
+		Node:            &node,
+		State: &pluginState{
+			FormData:  state.FormData,
+			Condition: state.Condition,
+			Unit:      state.Unit,
+			Variables: state.Variables,
+		},
+	}
+
+	var resp pluginExecuteResponse
+	if err := callPlugin(ctx, p.path, p.timeout, req, &resp); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", p.descriptor.Type, err)
+	}
+	if resp.Error != "" || resp.Status == "error" {
+		msg := resp.Error
+		if msg == "" {
+			msg = "reported an error without a message"
+		}
+		return nil, fmt.Errorf("plugin %s: %s", p.descriptor.Type, msg)
+	}
+
+	for k, v := range resp.Variables {
+		state.Variables[k] = v
+	}
+	if resp.Output == nil {
+		resp.Output = map[string]any{}
+	}
+	if _, ok := resp.Output["message"]; !ok {
+		resp.Output["message"] = fmt.Sprintf("Plugin %s completed", p.descriptor.Type)
+	}
+	if resp.Status == "" {
+		resp.Status = "completed"
+	}
+
+	return &StepResult{
+		NodeID: node.ID, NodeType: node.Type, Label: node.Data.Label,
+		Status: resp.Status,
+		Output: resp.Output,
+		Handle: resp.Handle,
+	}, nil
+}
+
+// Simulate skips the plugin, since its effects are unknown. Mock the node to give later
+// nodes the outputs it would have set.
+func (p *PluginExecutor) Simulate(_ context.Context, node Node, _ *ExecutionState) (*StepResult, error) {
+	return &StepResult{
+		NodeID: node.ID, NodeType: node.Type, Label: node.Data.Label,
+		Status: "completed",
+		Output: map[string]any{"message": fmt.Sprintf("Plugin %s not run in a dry run", p.Type())},
+	}, nil
+}
+
+// LoadPlugins runs "describe" against every executable file in dir and returns an
+// executor for each plugin that answers with a supported protocol version.
+// Plugins that fail to describe themselves are logged and skipped.
+func LoadPlugins(ctx context.Context, dir string) ([]*PluginExecutor, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read plugin dir: %w", err)
+	}
+
+	var plugins []*PluginExecutor
+	seen := make(map[string]string)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+
+		var desc PluginDescriptor
+		req := pluginRequest{ProtocolVersion: PluginProtocolVersion, Method: "describe"}
+		if err := callPlugin(ctx, path, defaultPluginTimeout, req, &desc); err != nil {
+			slog.Warn("Skipping plugin", "path", path, "error", err)
+			continue
+		}
+		if desc.ProtocolVersion != PluginProtocolVersion {
+			slog.Warn("Skipping plugin with unsupported protocol version", "path", path, "version", desc.ProtocolVersion)
+			continue
+		}
+		if strings.TrimSpace(desc.Type) == "" {
+			slog.Warn("Skipping plugin without a type", "path", path)
+			continue
+		}
+		if other, dup := seen[desc.Type]; dup {
+			slog.Warn("Skipping plugin with duplicate type", "path", path, "type", desc.Type, "registeredBy", other)
+			continue
+		}
+		seen[desc.Type] = path
+
+		plugins = append(plugins, &PluginExecutor{path: path, descriptor: desc, timeout: defaultPluginTimeout})
+	}
+
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Type() < plugins[j].Type() })
+	return plugins, nil
+}
+
+// RegisterPlugins adds plugins to the registry. Built-in types cannot be overridden;
+// conflicting plugins are logged and skipped.
+func (r Registry) RegisterPlugins(plugins []*PluginExecutor) {
+	for _, p := range plugins {
+		if _, exists := r[p.Type()]; exists {
+			slog.Warn("Plugin type conflicts with a registered executor", "type", p.Type(), "path", p.path)
+			continue
+		}
+		r[p.Type()] = p
+		slog.Info("Registered plugin", "type", p.Type(), "path", p.path)
+	}
+}
+
+// callPlugin runs the executable at path with req on stdin and decodes stdout into resp.
+func callPlugin(ctx context.Context, path string, timeout time.Duration, req pluginRequest, resp any) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	// Output past the limits is discarded as it arrives, so a noisy plugin cannot
+	// exhaust memory
+	stdout := &cappedBuffer{limit: maxPluginResponse}
+	stderr := &cappedBuffer{limit: maxPluginStderr}
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %s", timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		logging.FromContext(ctx).Debug("Plugin wrote to stderr", "path", path, "stderr", msg)
+	}
+	if stdout.truncated {
+		return fmt.Errorf("response exceeds %d bytes", maxPluginResponse)
+	}
+
+	if err := json.Unmarshal(stdout.buf.Bytes(), resp); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// cappedBuffer keeps the first limit bytes written to it and discards the rest. Writes
+// always succeed, so a plugin is not killed by a broken pipe for writing too much.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.limit - b.buf.Len(); len(p) > room {
+		p = p[:max(room, 0)]
+		b.truncated = true
+	}
+	b.buf.Write(p)
+	return n, nil
+}
+
+func (b *cappedBuffer) String() string {
+	return b.buf.String()
+}
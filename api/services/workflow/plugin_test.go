@@ -0,0 +1,178 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPluginHelperProcess is not a real test. It is the plugin executable used by the
+// tests below: writePlugin installs a script that re-runs the test binary with only this
+// test selected and PLUGIN_HELPER set to the behaviour to emulate.
+func TestPluginHelperProcess(t *testing.T) {
+	mode := os.Getenv("PLUGIN_HELPER")
+	if mode == "" {
+		return
+	}
+	defer os.Exit(0)
+
+	var req pluginRequest
+	json.NewDecoder(os.Stdin).Decode(&req)
+
+	if req.Method == "describe" {
+		version := PluginProtocolVersion
+		if mode == "old" {
+			version = 0
+		}
+		json.NewEncoder(os.Stdout).Encode(PluginDescriptor{ProtocolVersion: version, Type: "threshold-" + mode})
+		return
+	}
+
+	switch mode {
+	case "fail":
+		fmt.Fprintln(os.Stderr, "boom from plugin")
+		os.Exit(3)
+	case "noisy":
+		chunk := strings.Repeat("x", 64<<10)
+		for range 32 {
+			fmt.Fprint(os.Stdout, chunk)
+			fmt.Fprint(os.Stderr, chunk)
+		}
+	case "silent-error":
+		json.NewEncoder(os.Stdout).Encode(pluginExecuteResponse{Status: "error"})
+	default:
+		temp, _ := req.State.Variables["temperature"].(float64)
+		handle := "cool"
+		if temp > 25 {
+			handle = "hot"
+		}
+		json.NewEncoder(os.Stdout).Encode(pluginExecuteResponse{
+			Output:    map[string]any{"node": req.Node.ID},
+			Variables: map[string]any{"band": handle},
+			Handle:    handle,
+		})
+	}
+}
+
+func writePlugin(t *testing.T, dir, mode string) {
+	t.Helper()
+	script := fmt.Sprintf("#!/bin/sh\nPLUGIN_HELPER=%s exec %q -test.run=^TestPluginHelperProcess$\n", mode, os.Args[0])
+	require.NoError(t, os.WriteFile(filepath.Join(dir, mode), []byte(script), 0o755))
+}
+
+func TestLoadPlugins(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "ok")
+	writePlugin(t, dir, "old")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("not a plugin"), 0o644))
+
+	plugins, err := LoadPlugins(context.Background(), dir)
+
+	require.NoError(t, err)
+	require.Len(t, plugins, 1)
+	assert.Equal(t, "threshold-ok", plugins[0].Type())
+}
+
+func TestRegistry_RegisterPluginsKeepsBuiltins(t *testing.T) {
+	registry := NewRegistry(&mockWeatherClient{}, nil, defaultInlineDelay)
+	builtin := registry["condition"]
+
+	registry.RegisterPlugins([]*PluginExecutor{
+		{path: "/bin/a", descriptor: PluginDescriptor{Type: "condition"}},
+		{path: "/bin/b", descriptor: PluginDescriptor{Type: "uv-index"}},
+	})
+
+	assert.Same(t, builtin, registry["condition"])
+	assert.Contains(t, registry, "uv-index")
+}
+
+func TestEngine_PluginBranchesByHandle(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "ok")
+	plugins, err := LoadPlugins(context.Background(), dir)
+	require.NoError(t, err)
+
+	registry := Registry{"start": &StartExecutor{}, "end": &EndExecutor{}}
+	registry.RegisterPlugins(plugins)
+	wf := &Workflow{
+		Nodes: []Node{
+			{ID: "start", Type: "start"},
+			{ID: "band", Type: "threshold-ok"},
+			{ID: "hot", Type: "end", Data: NodeData{Label: "Hot"}},
+			{ID: "cool", Type: "end", Data: NodeData{Label: "Cool"}},
+		},
+		Edges: []Edge{
+			{ID: "e1", Source: "start", Target: "band"},
+			{ID: "e2", Source: "band", Target: "cool", SourceHandle: "cool"},
+			{ID: "e3", Source: "band", Target: "hot", SourceHandle: "hot"},
+		},
+	}
+	state := &ExecutionState{Variables: map[string]any{"temperature": 30.0}}
+
+	results, err := NewEngine(registry).Execute(context.Background(), wf, state)
+
+	require.NoError(t, err)
+	require.Equal(t, "completed", results.Status)
+	require.Len(t, results.Steps, 3)
+	assert.Equal(t, "band", results.Steps[1].Output["node"])
+	assert.Equal(t, "hot", results.Steps[2].NodeID)
+	assert.Equal(t, "hot", state.Variables["band"])
+}
+
+func TestPluginExecutor_ProcessFailure(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "fail")
+	plugins, err := LoadPlugins(context.Background(), dir)
+	require.NoError(t, err)
+	require.Len(t, plugins, 1)
+
+	_, err = plugins[0].Execute(context.Background(), Node{ID: "x"}, newTestState())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "boom from plugin")
+}
+
+func TestPluginExecutor_CapsOutput(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "noisy")
+	plugins, err := LoadPlugins(context.Background(), dir)
+	require.NoError(t, err)
+	require.Len(t, plugins, 1)
+
+	_, err = plugins[0].Execute(context.Background(), Node{ID: "x"}, newTestState())
+
+	assert.EqualError(t, err, "plugin threshold-noisy: response exceeds 1048576 bytes")
+}
+
+func TestCappedBuffer(t *testing.T) {
+	b := &cappedBuffer{limit: 4}
+	n, err := b.Write([]byte("abc"))
+	assert.Equal(t, 3, n)
+	require.NoError(t, err)
+	assert.False(t, b.truncated)
+
+	n, err = b.Write([]byte("defg"))
+	assert.Equal(t, 4, n, "writes report success so the writer keeps going")
+	require.NoError(t, err)
+	assert.True(t, b.truncated)
+	assert.Equal(t, "abcd", b.String())
+}
+
+func TestPluginExecutor_ErrorWithoutMessage(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "silent-error")
+	plugins, err := LoadPlugins(context.Background(), dir)
+	require.NoError(t, err)
+	require.Len(t, plugins, 1)
+
+	_, err = plugins[0].Execute(context.Background(), Node{ID: "x"}, newTestState())
+
+	assert.EqualError(t, err, "plugin threshold-silent-error: reported an error without a message")
+}
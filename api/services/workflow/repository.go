@@ -3,12 +3,38 @@ package workflow
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrWebhookTokenTaken is returned when saving a workflow whose webhook trigger uses a
+// token that another workflow, in any tenant, already has.
+var ErrWebhookTokenTaken = errors.New("webhook token is already in use")
+
+// webhookTokenOf returns the token of wf's webhook trigger, or nil if it has none.
+func webhookTokenOf(wf *Workflow) *string {
+	trigger, ok := webhookTrigger(wf)
+	if !ok {
+		return nil
+	}
+	return &trigger.Token
+}
+
+// saveError maps a failed workflow write to ErrWebhookTokenTaken when the webhook token
+// index rejected it.
+func saveError(op string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "workflows_webhook_token" {
+		return ErrWebhookTokenTaken
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
 // Repository handles workflow persistence in PostgreSQL.
 type Repository struct {
 	db *pgxpool.Pool
@@ -19,24 +45,6 @@ func NewRepository(pool *pgxpool.Pool) *Repository {
 	return &Repository{db: pool}
 }
 
-// InitSchema creates the workflows table if it does not exist.
-func (r *Repository) InitSchema(ctx context.Context) error {
-	_, err := r.db.Exec(ctx, `
-		CREATE TABLE IF NOT EXISTS workflows (
-			id         UUID PRIMARY KEY,
-			name       TEXT NOT NULL DEFAULT '',
-			nodes      JSONB NOT NULL DEFAULT '[]',
-			edges      JSONB NOT NULL DEFAULT '[]',
-			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("init schema: %w", err)
-	}
-	return nil
-}
-
 // Seed inserts the sample weather-alert workflow if it does not already exist.
 func (r *Repository) Seed(ctx context.Context) error {
 	nodesJSON, err := json.Marshal(sampleNodes)
@@ -59,15 +67,15 @@ func (r *Repository) Seed(ctx context.Context) error {
 	return nil
 }
 
-// Get retrieves a workflow by ID. Returns nil, nil if not found.
-func (r *Repository) Get(ctx context.Context, id string) (*Workflow, error) {
+// Get retrieves one of tenant's workflows by ID. Returns nil, nil if not found.
+func (r *Repository) Get(ctx context.Context, tenant, id string) (*Workflow, error) {
 	var wf Workflow
-	var nodesJSON, edgesJSON []byte
+	var nodesJSON, edgesJSON, testsJSON []byte
 
 	err := r.db.QueryRow(ctx, `
-		SELECT id, name, nodes, edges, created_at, updated_at
-		FROM workflows WHERE id = $1
-	`, id).Scan(&wf.ID, &wf.Name, &nodesJSON, &edgesJSON, &wf.CreatedAt, &wf.UpdatedAt)
+		SELECT id, tenant_id, name, nodes, edges, tests, created_at, updated_at
+		FROM workflows WHERE id = $1 AND tenant_id = $2
+	`, id, tenant).Scan(&wf.ID, &wf.TenantID, &wf.Name, &nodesJSON, &edgesJSON, &testsJSON, &wf.CreatedAt, &wf.UpdatedAt)
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
@@ -81,16 +89,409 @@ func (r *Repository) Get(ctx context.Context, id string) (*Workflow, error) {
 	if err := json.Unmarshal(edgesJSON, &wf.Edges); err != nil {
 		return nil, fmt.Errorf("unmarshal edges: %w", err)
 	}
+	if err := json.Unmarshal(testsJSON, &wf.Tests); err != nil {
+		return nil, fmt.Errorf("unmarshal tests: %w", err)
+	}
 	return &wf, nil
 }
 
-// InitDB creates the schema and seeds initial data. Called from main on startup.
-func InitDB(ctx context.Context, pool *pgxpool.Pool) error {
-	repo := NewRepository(pool)
-	if err := repo.InitSchema(ctx); err != nil {
-		return err
+// Update replaces a workflow's name, nodes and edges, and its test cases unless wf.Tests
+// is nil, then fills in its timestamps and stored test cases.
+// Returns false if the workflow does not exist in wf.TenantID.
+func (r *Repository) Update(ctx context.Context, wf *Workflow) (bool, error) {
+	nodesJSON, err := json.Marshal(wf.Nodes)
+	if err != nil {
+		return false, fmt.Errorf("marshal nodes: %w", err)
+	}
+	edgesJSON, err := json.Marshal(wf.Edges)
+	if err != nil {
+		return false, fmt.Errorf("marshal edges: %w", err)
+	}
+
+	var testsJSON []byte
+	if wf.Tests != nil {
+		if testsJSON, err = json.Marshal(wf.Tests); err != nil {
+			return false, fmt.Errorf("marshal tests: %w", err)
+		}
+	}
+
+	err = r.db.QueryRow(ctx, `
+		UPDATE workflows
+		SET name = $2, nodes = $3, edges = $4, tests = COALESCE($6, tests), webhook_token = $7, updated_at = NOW()
+		WHERE id = $1 AND tenant_id = $5
+		RETURNING tests, created_at, updated_at
+	`, wf.ID, wf.Name, nodesJSON, edgesJSON, wf.TenantID, testsJSON, webhookTokenOf(wf)).Scan(&testsJSON, &wf.CreatedAt, &wf.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, saveError("update workflow", err)
+	}
+	if err := json.Unmarshal(testsJSON, &wf.Tests); err != nil {
+		return false, fmt.Errorf("unmarshal tests: %w", err)
+	}
+	return true, nil
+}
+
+// Import inserts wf, or replaces its name, nodes, edges and test cases if it already exists
+// in wf.TenantID, and fills in its timestamps. Returns false if the ID belongs to another tenant.
+func (r *Repository) Import(ctx context.Context, wf *Workflow) (bool, error) {
+	nodesJSON, err := json.Marshal(wf.Nodes)
+	if err != nil {
+		return false, fmt.Errorf("marshal nodes: %w", err)
+	}
+	edgesJSON, err := json.Marshal(wf.Edges)
+	if err != nil {
+		return false, fmt.Errorf("marshal edges: %w", err)
+	}
+	tests := wf.Tests
+	if tests == nil {
+		tests = []TestCase{}
+	}
+	testsJSON, err := json.Marshal(tests)
+	if err != nil {
+		return false, fmt.Errorf("marshal tests: %w", err)
+	}
+
+	err = r.db.QueryRow(ctx, `
+		INSERT INTO workflows (id, tenant_id, name, nodes, edges, tests, webhook_token)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE
+		SET name = EXCLUDED.name, nodes = EXCLUDED.nodes, edges = EXCLUDED.edges, tests = EXCLUDED.tests,
+			webhook_token = EXCLUDED.webhook_token, updated_at = NOW()
+		WHERE workflows.tenant_id = EXCLUDED.tenant_id
+		RETURNING created_at, updated_at
+	`, wf.ID, wf.TenantID, wf.Name, nodesJSON, edgesJSON, testsJSON, webhookTokenOf(wf)).Scan(&wf.CreatedAt, &wf.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, saveError("import workflow", err)
+	}
+	return true, nil
+}
+
+// FindByWebhookToken retrieves the workflow whose start node has a webhook trigger with
+// the given token, in any tenant. Tokens are unique across tenants.
+// Returns nil, nil if not found.
+func (r *Repository) FindByWebhookToken(ctx context.Context, token string) (*Workflow, error) {
+	var id, tenant string
+	err := r.db.QueryRow(ctx, `SELECT id, tenant_id FROM workflows WHERE webhook_token = $1`, token).Scan(&id, &tenant)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find workflow by webhook token: %w", err)
+	}
+	return r.Get(ctx, tenant, id)
+}
+
+const scheduleColumns = `id, tenant_id, workflow_id, cron, timezone, input, enabled, next_run_at, last_run_at, last_status, created_at, updated_at`
+
+func scanSchedule(row pgx.Row) (*Schedule, error) {
+	var sch Schedule
+	var inputJSON []byte
+	err := row.Scan(&sch.ID, &sch.TenantID, &sch.WorkflowID, &sch.Cron, &sch.Timezone, &inputJSON, &sch.Enabled,
+		&sch.NextRunAt, &sch.LastRunAt, &sch.LastStatus, &sch.CreatedAt, &sch.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(inputJSON, &sch.Input); err != nil {
+		return nil, fmt.Errorf("unmarshal schedule input: %w", err)
+	}
+	return &sch, nil
+}
+
+func collectSchedules(rows pgx.Rows) ([]Schedule, error) {
+	defer rows.Close()
+	schedules := []Schedule{}
+	for rows.Next() {
+		sch, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *sch)
+	}
+	return schedules, rows.Err()
+}
+
+// ListSchedules returns the schedules of one of tenant's workflows, oldest first.
+func (r *Repository) ListSchedules(ctx context.Context, tenant, workflowID string) ([]Schedule, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+scheduleColumns+` FROM workflow_schedules
+		WHERE workflow_id = $1 AND tenant_id = $2
+		ORDER BY created_at
+	`, workflowID, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("list schedules: %w", err)
+	}
+	schedules, err := collectSchedules(rows)
+	if err != nil {
+		return nil, fmt.Errorf("list schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// GetSchedule retrieves one of tenant's schedules by ID. Returns nil, nil if not found.
+func (r *Repository) GetSchedule(ctx context.Context, tenant, id string) (*Schedule, error) {
+	sch, err := scanSchedule(r.db.QueryRow(ctx, `SELECT `+scheduleColumns+` FROM workflow_schedules WHERE id = $1 AND tenant_id = $2`, id, tenant))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get schedule: %w", err)
+	}
+	return sch, nil
+}
+
+// CreateSchedule inserts a schedule and fills in its timestamps.
+func (r *Repository) CreateSchedule(ctx context.Context, sch *Schedule) error {
+	inputJSON, err := json.Marshal(sch.Input)
+	if err != nil {
+		return fmt.Errorf("marshal schedule input: %w", err)
+	}
+	err = r.db.QueryRow(ctx, `
+		INSERT INTO workflow_schedules (id, tenant_id, workflow_id, cron, timezone, input, enabled, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at, updated_at
+	`, sch.ID, sch.TenantID, sch.WorkflowID, sch.Cron, sch.Timezone, inputJSON, sch.Enabled, sch.NextRunAt).Scan(&sch.CreatedAt, &sch.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("create schedule: %w", err)
+	}
+	return nil
+}
+
+// UpdateSchedule saves a schedule's definition and next run time.
+// Returns false if the schedule does not exist in sch.TenantID.
+func (r *Repository) UpdateSchedule(ctx context.Context, sch *Schedule) (bool, error) {
+	inputJSON, err := json.Marshal(sch.Input)
+	if err != nil {
+		return false, fmt.Errorf("marshal schedule input: %w", err)
+	}
+	err = r.db.QueryRow(ctx, `
+		UPDATE workflow_schedules
+		SET cron = $2, timezone = $3, input = $4, enabled = $5, next_run_at = $6, updated_at = NOW()
+		WHERE id = $1 AND tenant_id = $7
+		RETURNING created_at, updated_at
+	`, sch.ID, sch.Cron, sch.Timezone, inputJSON, sch.Enabled, sch.NextRunAt, sch.TenantID).Scan(&sch.CreatedAt, &sch.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("update schedule: %w", err)
+	}
+	return true, nil
+}
+
+// DeleteSchedule removes one of tenant's schedules. Returns false if it did not exist.
+func (r *Repository) DeleteSchedule(ctx context.Context, tenant, id string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM workflow_schedules WHERE id = $1 AND tenant_id = $2`, id, tenant)
+	if err != nil {
+		return false, fmt.Errorf("delete schedule: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// DueSchedules returns enabled schedules of every tenant whose next run is at or before
+// now, earliest first.
+func (r *Repository) DueSchedules(ctx context.Context, now time.Time, limit int) ([]Schedule, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+scheduleColumns+` FROM workflow_schedules
+		WHERE enabled AND next_run_at <= $1
+		ORDER BY next_run_at
+		LIMIT $2
+	`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("due schedules: %w", err)
+	}
+	schedules, err := collectSchedules(rows)
+	if err != nil {
+		return nil, fmt.Errorf("due schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// AdvanceSchedule moves a schedule's next run time forward from `from` before it is
+// executed, so a crash mid-run does not cause the same slot to fire again. It reports
+// false, leaving the schedule alone, if the next run time is no longer `from` because
+// another scheduler claimed the slot or the schedule was changed.
+func (r *Repository) AdvanceSchedule(ctx context.Context, id string, from time.Time, nextRunAt *time.Time) (bool, error) {
+	tag, err := r.db.Exec(ctx, `UPDATE workflow_schedules SET next_run_at = $3 WHERE id = $1 AND next_run_at = $2`, id, from, nextRunAt)
+	if err != nil {
+		return false, fmt.Errorf("advance schedule: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RecordScheduleRun stores the outcome of a scheduled execution.
+func (r *Repository) RecordScheduleRun(ctx context.Context, id string, ranAt time.Time, status string) error {
+	_, err := r.db.Exec(ctx, `UPDATE workflow_schedules SET last_run_at = $2, last_status = $3 WHERE id = $1`, id, ranAt, status)
+	if err != nil {
+		return fmt.Errorf("record schedule run: %w", err)
+	}
+	return nil
+}
+
+// SaveSuspended inserts or replaces a suspended execution and clears any lease on it.
+func (r *Repository) SaveSuspended(ctx context.Context, exec *SuspendedExecution) error {
+	stateJSON, err := json.Marshal(exec.State)
+	if err != nil {
+		return fmt.Errorf("marshal execution state: %w", err)
+	}
+	resultsJSON, err := json.Marshal(exec.Results)
+	if err != nil {
+		return fmt.Errorf("marshal execution results: %w", err)
+	}
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO workflow_executions (id, tenant_id, workflow_id, status, resume_node_id, resume_at, state, results)
+		VALUES ($1, $7, $2, 'suspended', $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE
+		SET status = 'suspended', resume_node_id = $3, resume_at = $4, lease_until = NULL,
+			state = $5, results = $6, updated_at = NOW()
+	`, exec.ExecutionID, exec.WorkflowID, exec.ResumeNodeID, exec.ResumeAt, stateJSON, resultsJSON, exec.TenantID)
+	if err != nil {
+		return fmt.Errorf("save suspended execution: %w", err)
+	}
+	return nil
+}
+
+// FinishExecution stores the final results of a previously suspended execution.
+func (r *Repository) FinishExecution(ctx context.Context, results *ExecutionResults) error {
+	resultsJSON, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("marshal execution results: %w", err)
+	}
+	_, err = r.db.Exec(ctx, `
+		UPDATE workflow_executions
+		SET status = $2, results = $3, resume_at = NULL, lease_until = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, results.ExecutionID, results.Status, resultsJSON)
+	if err != nil {
+		return fmt.Errorf("finish execution: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueExecutions leases suspended executions due at now, earliest first. Rows
+// locked by a concurrent claim are skipped, and expired leases are claimed again.
+func (r *Repository) ClaimDueExecutions(ctx context.Context, now, leaseUntil time.Time, limit int) ([]SuspendedExecution, error) {
+	rows, err := r.db.Query(ctx, `
+		UPDATE workflow_executions SET lease_until = $2
+		WHERE id IN (
+			SELECT id FROM workflow_executions
+			WHERE status = 'suspended' AND resume_at <= $1 AND (lease_until IS NULL OR lease_until < $1)
+			ORDER BY resume_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, tenant_id, workflow_id, resume_node_id, resume_at, state, results
+	`, now, leaseUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim due executions: %w", err)
+	}
+	defer rows.Close()
+
+	var execs []SuspendedExecution
+	for rows.Next() {
+		var exec SuspendedExecution
+		var stateJSON, resultsJSON []byte
+		if err := rows.Scan(&exec.ExecutionID, &exec.TenantID, &exec.WorkflowID, &exec.ResumeNodeID, &exec.ResumeAt, &stateJSON, &resultsJSON); err != nil {
+			return nil, fmt.Errorf("scan execution: %w", err)
+		}
+		if err := json.Unmarshal(stateJSON, &exec.State); err != nil {
+			return nil, fmt.Errorf("unmarshal execution state: %w", err)
+		}
+		if err := json.Unmarshal(resultsJSON, &exec.Results); err != nil {
+			return nil, fmt.Errorf("unmarshal execution results: %w", err)
+		}
+		execs = append(execs, exec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim due executions: %w", err)
+	}
+	return execs, nil
+}
+
+// GetExecution returns the latest results of one of tenant's persisted executions.
+// Returns nil, nil if not found.
+func (r *Repository) GetExecution(ctx context.Context, tenant, id string) (*ExecutionResults, error) {
+	var workflowID string
+	var resultsJSON []byte
+	err := r.db.QueryRow(ctx, `SELECT workflow_id, results FROM workflow_executions WHERE id = $1 AND tenant_id = $2`, id, tenant).
+		Scan(&workflowID, &resultsJSON)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get execution: %w", err)
+	}
+	var results ExecutionResults
+	if err := json.Unmarshal(resultsJSON, &results); err != nil {
+		return nil, fmt.Errorf("unmarshal execution results: %w", err)
+	}
+	results.WorkflowID = workflowID
+	return &results, nil
+}
+
+// ReserveIdempotencyKey inserts rec as an in-flight request. An existing row is taken
+// over if its retention window has passed or its request was abandoned mid-flight;
+// otherwise the existing row is returned. Returns nil, nil when the key was reserved.
+func (r *Repository) ReserveIdempotencyKey(ctx context.Context, rec IdempotencyRecord, now, lockedUntil time.Time) (*IdempotencyRecord, error) {
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO idempotency_keys (scope, key, request_hash, locked_until, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (scope, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, status_code = 0, response = NULL,
+			locked_until = EXCLUDED.locked_until, expires_at = EXCLUDED.expires_at, created_at = NOW()
+		WHERE idempotency_keys.expires_at < $6
+			OR (idempotency_keys.status_code = 0 AND idempotency_keys.locked_until < $6)
+	`, rec.Scope, rec.Key, rec.RequestHash, lockedUntil, rec.ExpiresAt, now)
+	if err != nil {
+		return nil, fmt.Errorf("reserve idempotency key: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		return nil, nil
+	}
+
+	existing := IdempotencyRecord{Scope: rec.Scope, Key: rec.Key}
+	err = r.db.QueryRow(ctx, `
+		SELECT request_hash, status_code, response, expires_at FROM idempotency_keys
+		WHERE scope = $1 AND key = $2
+	`, rec.Scope, rec.Key).Scan(&existing.RequestHash, &existing.StatusCode, &existing.Response, &existing.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("get idempotency key: %w", err)
+	}
+	return &existing, nil
+}
+
+// CompleteIdempotencyKey stores the response for a reserved key.
+func (r *Repository) CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, response []byte) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE idempotency_keys SET status_code = $3, response = $4, locked_until = NULL
+		WHERE scope = $1 AND key = $2
+	`, scope, key, statusCode, response)
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes an in-flight key so the request can be retried.
+func (r *Repository) ReleaseIdempotencyKey(ctx context.Context, scope, key string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND status_code = 0`, scope, key)
+	if err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// PurgeIdempotencyKeys deletes keys whose retention window has passed.
+func (r *Repository) PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at < $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("purge idempotency keys: %w", err)
 	}
-	return repo.Seed(ctx)
+	return tag.RowsAffected(), nil
 }
 
 const sampleWorkflowID = "550e8400-e29b-41d4-a716-446655440000"
@@ -126,8 +527,9 @@ var sampleNodes = []Node{
 			Metadata: map[string]any{
 				"hasHandles":      map[string]any{"source": true, "target": true},
 				"inputVariables":  []string{"city"},
-				"apiEndpoint":     "https://api.open-meteo.com/v1/forecast?latitude={lat}&longitude={lon}&current_weather=true",
-				"outputVariables": []string{"temperature"},
+				"apiEndpoint":     "https://api.open-meteo.com/v1/forecast?latitude={lat}&longitude={lon}&current=temperature_2m",
+				"forecastDays":    2,
+				"outputVariables": []string{"temperature", "apparentTemperature", "windSpeed", "precipitation", "humidity", "weatherCode", "weather"},
 				"options": []map[string]any{
 					{"city": "Sydney", "lat": -33.8688, "lon": 151.2093},
 					{"city": "Melbourne", "lat": -37.8136, "lon": 144.9631},
@@ -161,7 +563,7 @@ var sampleNodes = []Node{
 				"outputVariables": []string{"emailSent"},
 				"emailTemplate": map[string]any{
 					"subject": "Weather Alert",
-					"body":    "Weather alert for {{city}}! Temperature is {{temperature}}\u00b0C!",
+					"body":    "Weather alert for {{city}}! Temperature is {{temperature}}{{unit}}!",
 				},
 			},
 		},
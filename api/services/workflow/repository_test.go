@@ -2,14 +2,21 @@ package workflow
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"workflow-code-test/api/pkg/auth"
+	"workflow-code-test/api/pkg/db"
 )
 
+// getTestPool connects to DATABASE_URL and brings its schema up to date.
 func getTestPool(t *testing.T) *pgxpool.Pool {
 	t.Helper()
 
@@ -21,19 +28,20 @@ func getTestPool(t *testing.T) *pgxpool.Pool {
 	pool, err := pgxpool.New(context.Background(), dbURL)
 	require.NoError(t, err)
 	t.Cleanup(func() { pool.Close() })
+
+	migrator, err := db.NewMigrator(pool)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
 	return pool
 }
 
-func TestRepository_InitSchema(t *testing.T) {
+func TestRepository_SchemaIsMigrated(t *testing.T) {
 	pool := getTestPool(t)
-	repo := NewRepository(pool)
-
-	err := repo.InitSchema(context.Background())
+	migrator, err := db.NewMigrator(pool)
 	require.NoError(t, err)
 
-	// Running again should be idempotent
-	err = repo.InitSchema(context.Background())
-	require.NoError(t, err)
+	require.NoError(t, migrator.Check(context.Background()))
 }
 
 func TestRepository_Seed(t *testing.T) {
@@ -41,7 +49,6 @@ func TestRepository_Seed(t *testing.T) {
 	repo := NewRepository(pool)
 
 	ctx := context.Background()
-	require.NoError(t, repo.InitSchema(ctx))
 
 	err := repo.Seed(ctx)
 	require.NoError(t, err)
@@ -52,7 +59,6 @@ func TestRepository_Seed_Idempotent(t *testing.T) {
 	repo := NewRepository(pool)
 
 	ctx := context.Background()
-	require.NoError(t, repo.InitSchema(ctx))
 
 	require.NoError(t, repo.Seed(ctx))
 	require.NoError(t, repo.Seed(ctx)) // Second call should not error
@@ -63,14 +69,14 @@ func TestRepository_Get_Found(t *testing.T) {
 	repo := NewRepository(pool)
 
 	ctx := context.Background()
-	require.NoError(t, repo.InitSchema(ctx))
 	require.NoError(t, repo.Seed(ctx))
 
-	wf, err := repo.Get(ctx, sampleWorkflowID)
+	wf, err := repo.Get(ctx, auth.DefaultTenant, sampleWorkflowID)
 	require.NoError(t, err)
 	require.NotNil(t, wf)
 
 	assert.Equal(t, sampleWorkflowID, wf.ID)
+	assert.Equal(t, auth.DefaultTenant, wf.TenantID)
 	assert.Equal(t, "Weather Alert Workflow", wf.Name)
 	assert.Len(t, wf.Nodes, 6)
 	assert.Len(t, wf.Edges, 6)
@@ -91,9 +97,200 @@ func TestRepository_Get_NotFound(t *testing.T) {
 	repo := NewRepository(pool)
 
 	ctx := context.Background()
-	require.NoError(t, repo.InitSchema(ctx))
 
-	wf, err := repo.Get(ctx, "00000000-0000-0000-0000-000000000000")
+	wf, err := repo.Get(ctx, auth.DefaultTenant, "00000000-0000-0000-0000-000000000000")
 	require.NoError(t, err)
 	assert.Nil(t, wf)
 }
+
+func TestRepository_TenantIsolation(t *testing.T) {
+	pool := getTestPool(t)
+	repo := NewRepository(pool)
+
+	ctx := context.Background()
+	require.NoError(t, repo.Seed(ctx))
+
+	wf, err := repo.Get(ctx, "payments", sampleWorkflowID)
+	require.NoError(t, err)
+	assert.Nil(t, wf, "another tenant's workflow must not be visible")
+
+	found, err := repo.Update(ctx, &Workflow{ID: sampleWorkflowID, TenantID: "payments", Name: "Hijacked", Nodes: sampleNodes, Edges: sampleEdges})
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	sch := &Schedule{ID: uuid.New().String(), TenantID: auth.DefaultTenant, WorkflowID: sampleWorkflowID, Cron: "0 7 * * *", Timezone: "UTC"}
+	require.NoError(t, repo.CreateSchedule(ctx, sch))
+	t.Cleanup(func() { repo.DeleteSchedule(ctx, auth.DefaultTenant, sch.ID) })
+
+	other, err := repo.GetSchedule(ctx, "payments", sch.ID)
+	require.NoError(t, err)
+	assert.Nil(t, other)
+	deleted, err := repo.DeleteSchedule(ctx, "payments", sch.ID)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	own, err := repo.GetSchedule(ctx, auth.DefaultTenant, sch.ID)
+	require.NoError(t, err)
+	require.NotNil(t, own)
+	assert.Equal(t, auth.DefaultTenant, own.TenantID)
+}
+
+func TestRepository_Import(t *testing.T) {
+	pool := getTestPool(t)
+	repo := NewRepository(pool)
+
+	ctx := context.Background()
+	require.NoError(t, repo.Seed(ctx))
+	id := uuid.New().String()
+	t.Cleanup(func() { pool.Exec(context.Background(), `DELETE FROM workflows WHERE id = $1`, id) })
+
+	wf := &Workflow{ID: id, TenantID: auth.DefaultTenant, Name: "Imported", Nodes: sampleNodes, Edges: sampleEdges}
+	found, err := repo.Import(ctx, wf)
+	require.NoError(t, err)
+	assert.True(t, found)
+	created := wf.CreatedAt
+
+	wf.Name = "Imported again"
+	wf.Tests = []TestCase{{Name: "hot day", Expect: TestExpectation{Path: []string{"start"}}}}
+	found, err = repo.Import(ctx, wf)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, created, wf.CreatedAt, "a second import replaces the workflow")
+	got, err := repo.Get(ctx, auth.DefaultTenant, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Imported again", got.Name)
+	assert.Equal(t, wf.Tests, got.Tests)
+
+	// Updates keep the tests unless they are given
+	got.Tests = nil
+	found, err = repo.Update(ctx, got)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, wf.Tests, got.Tests)
+
+	found, err = repo.Import(ctx, &Workflow{ID: sampleWorkflowID, TenantID: "payments", Name: "Hijacked", Nodes: sampleNodes, Edges: sampleEdges})
+	require.NoError(t, err)
+	assert.False(t, found, "another tenant's workflow must not be replaced")
+}
+
+func TestRepository_FindByWebhookToken(t *testing.T) {
+	pool := getTestPool(t)
+	repo := NewRepository(pool)
+
+	ctx := context.Background()
+
+	wf := webhookWorkflow("")
+	wf.ID = "6f1c2a9e-4b7d-4e2a-9c1f-0d3e5b7a9c11"
+	nodesJSON, _ := json.Marshal(wf.Nodes)
+	edgesJSON, _ := json.Marshal(wf.Edges)
+	_, err := pool.Exec(ctx, `
+		INSERT INTO workflows (id, name, nodes, edges) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET nodes = EXCLUDED.nodes
+	`, wf.ID, "Webhook Workflow", nodesJSON, edgesJSON)
+	require.NoError(t, err)
+	wf.TenantID = auth.DefaultTenant
+	found, err := repo.Update(ctx, wf)
+	require.NoError(t, err)
+	require.True(t, found)
+
+	got, err := repo.FindByWebhookToken(ctx, "tok-0123456789abcdef")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, wf.ID, got.ID)
+
+	missing, err := repo.FindByWebhookToken(ctx, "no-such-token")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	// Another workflow, in any tenant, cannot take the token
+	copied := webhookWorkflow("")
+	copied.ID = uuid.New().String()
+	copied.TenantID = "payments"
+	t.Cleanup(func() { pool.Exec(context.Background(), `DELETE FROM workflows WHERE id = $1`, copied.ID) })
+	_, err = repo.Import(ctx, copied)
+	assert.ErrorIs(t, err, ErrWebhookTokenTaken)
+}
+
+func TestRepository_SuspendedExecutions(t *testing.T) {
+	pool := getTestPool(t)
+	repo := NewRepository(pool)
+
+	ctx := context.Background()
+	require.NoError(t, repo.Seed(ctx))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	exec := &SuspendedExecution{
+		ExecutionID:  uuid.New().String(),
+		TenantID:     auth.DefaultTenant,
+		WorkflowID:   sampleWorkflowID,
+		ResumeNodeID: "email",
+		ResumeAt:     now.Add(-time.Second),
+		State:        ExecutionState{FormData: map[string]any{"city": "Sydney"}, Unit: Celsius, Variables: map[string]any{"temperature": 31.5}},
+		Results:      ExecutionResults{Status: "suspended", StartTime: now.Format(time.RFC3339)},
+	}
+	exec.Results.ExecutionID = exec.ExecutionID
+	require.NoError(t, repo.SaveSuspended(ctx, exec))
+
+	claimed, err := repo.ClaimDueExecutions(ctx, now, now.Add(time.Minute), 100)
+	require.NoError(t, err)
+	var found *SuspendedExecution
+	for i := range claimed {
+		if claimed[i].ExecutionID == exec.ExecutionID {
+			found = &claimed[i]
+		}
+	}
+	require.NotNil(t, found)
+	assert.Equal(t, "email", found.ResumeNodeID)
+	assert.Equal(t, auth.DefaultTenant, found.TenantID)
+	assert.Equal(t, 31.5, found.State.Variables["temperature"])
+
+	// Leased executions are not claimed again until the lease expires
+	again, err := repo.ClaimDueExecutions(ctx, now, now.Add(time.Minute), 100)
+	require.NoError(t, err)
+	for _, c := range again {
+		assert.NotEqual(t, exec.ExecutionID, c.ExecutionID)
+	}
+
+	exec.Results.Status = "completed"
+	require.NoError(t, repo.FinishExecution(ctx, &exec.Results))
+	results, err := repo.GetExecution(ctx, auth.DefaultTenant, exec.ExecutionID)
+	require.NoError(t, err)
+	require.NotNil(t, results)
+	assert.Equal(t, "completed", results.Status)
+
+	hidden, err := repo.GetExecution(ctx, "payments", exec.ExecutionID)
+	require.NoError(t, err)
+	assert.Nil(t, hidden)
+}
+
+func TestRepository_IdempotencyKeys(t *testing.T) {
+	pool := getTestPool(t)
+	repo := NewRepository(pool)
+
+	ctx := context.Background()
+
+	now := time.Now()
+	rec := IdempotencyRecord{Scope: "POST /test", Key: uuid.New().String(), RequestHash: "abc", ExpiresAt: now.Add(time.Hour)}
+
+	existing, err := repo.ReserveIdempotencyKey(ctx, rec, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	existing, err = repo.ReserveIdempotencyKey(ctx, rec, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, 0, existing.StatusCode)
+
+	require.NoError(t, repo.CompleteIdempotencyKey(ctx, rec.Scope, rec.Key, 200, []byte(`{"ok":true}`)))
+	existing, err = repo.ReserveIdempotencyKey(ctx, rec, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, 200, existing.StatusCode)
+	assert.JSONEq(t, `{"ok":true}`, string(existing.Response))
+
+	// Once the retention window passes the key can be reused
+	later := now.Add(2 * time.Hour)
+	rec.ExpiresAt = later.Add(time.Hour)
+	existing, err = repo.ReserveIdempotencyKey(ctx, rec, later, later.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+}
@@ -0,0 +1,243 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/robfig/cron/v3"
+
+	"workflow-code-test/api/pkg/auth"
+	"workflow-code-test/api/pkg/logging"
+)
+
+// ScheduleStore abstracts schedule persistence for testability.
+type ScheduleStore interface {
+	ListSchedules(ctx context.Context, tenant, workflowID string) ([]Schedule, error)
+	GetSchedule(ctx context.Context, tenant, id string) (*Schedule, error)
+	CreateSchedule(ctx context.Context, sch *Schedule) error
+	UpdateSchedule(ctx context.Context, sch *Schedule) (bool, error)
+	DeleteSchedule(ctx context.Context, tenant, id string) (bool, error)
+	DueSchedules(ctx context.Context, now time.Time, limit int) ([]Schedule, error)
+	AdvanceSchedule(ctx context.Context, id string, from time.Time, nextRunAt *time.Time) (bool, error)
+	RecordScheduleRun(ctx context.Context, id string, ranAt time.Time, status string) error
+}
+
+// scheduleRequest is the JSON body for creating or replacing a schedule.
+type scheduleRequest struct {
+	Cron     string         `json:"cron"`
+	Timezone string         `json:"timezone"`
+	Input    ExecuteRequest `json:"input"`
+	Enabled  *bool          `json:"enabled"` // Defaults to true
+}
+
+// nextRun returns the first time after `after` that the cron expression fires in tz.
+func nextRun(expr, tz string, after time.Time) (time.Time, error) {
+	if strings.HasPrefix(expr, "TZ=") || strings.HasPrefix(expr, "CRON_TZ=") {
+		return time.Time{}, fmt.Errorf("set the timezone field instead of a TZ prefix")
+	}
+	sched, err := cron.ParseStandard(expr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return sched.Next(after.In(loc)).UTC(), nil
+}
+
+// applyScheduleRequest validates req and copies it onto sch, recomputing the next run.
+func applyScheduleRequest(sch *Schedule, req scheduleRequest, now time.Time) error {
+	if strings.TrimSpace(req.Cron) == "" {
+		return errMissing("cron")
+	}
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return errInvalid("timezone")
+	}
+	next, err := nextRun(req.Cron, req.Timezone, now)
+	if err != nil {
+		return errInvalid("cron")
+	}
+	if err := ValidateExecuteRequest(req.Input); err != nil {
+		return err
+	}
+	// Schedules exist to act; dry runs and mocks are for trying a workflow out
+	if req.Input.Mode == ModeDryRun {
+		return errInvalid("mode")
+	}
+	if len(req.Input.Mocks) > 0 {
+		return errInvalid("mocks")
+	}
+
+	sch.Cron = strings.TrimSpace(req.Cron)
+	sch.Timezone = req.Timezone
+	sch.Input = req.Input
+	sch.Enabled = req.Enabled == nil || *req.Enabled
+	sch.NextRunAt = nil
+	if sch.Enabled {
+		sch.NextRunAt = &next
+	}
+	return nil
+}
+
+// HandleListSchedules returns the schedules of a workflow.
+func (s *Service) HandleListSchedules(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, err := uuid.Parse(id); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid workflow id")
+		return
+	}
+	if !s.authorize(w, r, auth.PermView, id) {
+		return
+	}
+
+	schedules, err := s.schedules.ListSchedules(r.Context(), auth.Tenant(r.Context()), id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to list schedules", "workflowId", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"schedules": schedules})
+}
+
+// HandleCreateSchedule adds a schedule to a workflow.
+func (s *Service) HandleCreateSchedule(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, err := uuid.Parse(id); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid workflow id")
+		return
+	}
+	if !s.authorize(w, r, auth.PermRun, id) {
+		return
+	}
+
+	var req scheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	wf, err := s.repo.Get(r.Context(), auth.Tenant(r.Context()), id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get workflow for schedule", "id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if wf == nil {
+		writeError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+
+	sch := &Schedule{ID: uuid.New().String(), TenantID: wf.TenantID, WorkflowID: id}
+	if err := applyScheduleRequest(sch, req, time.Now()); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.schedules.CreateSchedule(r.Context(), sch); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to create schedule", "workflowId", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sch)
+}
+
+// HandleGetSchedule returns a single schedule.
+func (s *Service) HandleGetSchedule(w http.ResponseWriter, r *http.Request) {
+	sch, ok := s.loadSchedule(w, r, auth.PermView)
+	if !ok {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(sch)
+}
+
+// HandleUpdateSchedule replaces a schedule's cron expression, timezone, input and enabled flag.
+func (s *Service) HandleUpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	sch, ok := s.loadSchedule(w, r, auth.PermRun)
+	if !ok {
+		return
+	}
+
+	var req scheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := applyScheduleRequest(sch, req, time.Now()); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	found, err := s.schedules.UpdateSchedule(r.Context(), sch)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to update schedule", "id", sch.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "schedule not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(sch)
+}
+
+// HandleDeleteSchedule removes a schedule.
+func (s *Service) HandleDeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	sch, ok := s.loadSchedule(w, r, auth.PermRun)
+	if !ok {
+		return
+	}
+
+	found, err := s.schedules.DeleteSchedule(r.Context(), sch.TenantID, sch.ID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to delete schedule", "id", sch.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "schedule not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadSchedule fetches the schedule named in the URL and checks the caller holds perm on
+// its workflow, writing an error response on failure.
+func (s *Service) loadSchedule(w http.ResponseWriter, r *http.Request, perm auth.Permission) (*Schedule, bool) {
+	id := mux.Vars(r)["scheduleId"]
+	if _, err := uuid.Parse(id); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid schedule id")
+		return nil, false
+	}
+
+	sch, err := s.schedules.GetSchedule(r.Context(), auth.Tenant(r.Context()), id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get schedule", "id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return nil, false
+	}
+	if sch == nil {
+		writeError(w, http.StatusNotFound, "schedule not found")
+		return nil, false
+	}
+	if !s.authorize(w, r, perm, sch.WorkflowID) {
+		return nil, false
+	}
+	return sch, true
+}
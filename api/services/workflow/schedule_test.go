@@ -0,0 +1,290 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workflow-code-test/api/pkg/auth"
+)
+
+// memScheduleStore implements ScheduleStore in memory for testing.
+type memScheduleStore struct {
+	mu        sync.Mutex
+	schedules map[string]*Schedule
+}
+
+func newMemScheduleStore() *memScheduleStore {
+	return &memScheduleStore{schedules: make(map[string]*Schedule)}
+}
+
+func (m *memScheduleStore) ListSchedules(_ context.Context, tenant, workflowID string) ([]Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := []Schedule{}
+	for _, s := range m.schedules {
+		if s.TenantID == tenant && s.WorkflowID == workflowID {
+			out = append(out, *s)
+		}
+	}
+	return out, nil
+}
+
+func (m *memScheduleStore) GetSchedule(_ context.Context, tenant, id string) (*Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.schedules[id]; ok && s.TenantID == tenant {
+		cp := *s
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+func (m *memScheduleStore) CreateSchedule(_ context.Context, sch *Schedule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *sch
+	m.schedules[sch.ID] = &cp
+	return nil
+}
+
+func (m *memScheduleStore) UpdateSchedule(_ context.Context, sch *Schedule) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.schedules[sch.ID]; !ok || s.TenantID != sch.TenantID {
+		return false, nil
+	}
+	cp := *sch
+	m.schedules[sch.ID] = &cp
+	return true, nil
+}
+
+func (m *memScheduleStore) DeleteSchedule(_ context.Context, tenant, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.schedules[id]; !ok || s.TenantID != tenant {
+		return false, nil
+	}
+	delete(m.schedules, id)
+	return true, nil
+}
+
+func (m *memScheduleStore) DueSchedules(_ context.Context, now time.Time, limit int) ([]Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Schedule
+	for _, s := range m.schedules {
+		if s.Enabled && s.NextRunAt != nil && !s.NextRunAt.After(now) {
+			out = append(out, *s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].NextRunAt.Before(*out[j].NextRunAt) })
+	return out[:min(limit, len(out))], nil
+}
+
+func (m *memScheduleStore) AdvanceSchedule(_ context.Context, id string, from time.Time, nextRunAt *time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.schedules[id]
+	if !ok || s.NextRunAt == nil || !s.NextRunAt.Equal(from) {
+		return false, nil
+	}
+	s.NextRunAt = nextRunAt
+	return true, nil
+}
+
+// claimingScheduleStore hands out due schedules and then advances them itself, as an
+// overlapping scheduler would.
+type claimingScheduleStore struct {
+	*memScheduleStore
+}
+
+func (c claimingScheduleStore) DueSchedules(ctx context.Context, now time.Time, limit int) ([]Schedule, error) {
+	due, err := c.memScheduleStore.DueSchedules(ctx, now, limit)
+	for _, sch := range due {
+		next := now.Add(time.Hour)
+		c.memScheduleStore.AdvanceSchedule(ctx, sch.ID, *sch.NextRunAt, &next)
+	}
+	return due, err
+}
+
+func (m *memScheduleStore) RecordScheduleRun(_ context.Context, id string, ranAt time.Time, status string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.schedules[id].LastRunAt = &ranAt
+	m.schedules[id].LastStatus = status
+	return nil
+}
+
+// fakeLock is a LeaderLock whose leadership is set by the test.
+type fakeLock struct{ leader bool }
+
+func (l *fakeLock) TryAcquire(context.Context) (bool, error) { return l.leader, nil }
+func (l *fakeLock) Release(context.Context)                  {}
+
+var morningInput = ExecuteRequest{
+	FormData:  map[string]any{"name": "Alice", "email": "alice@example.com", "city": "Sydney"},
+	Condition: ConditionInput{Operator: "greater_than", Threshold: 35},
+}
+
+func TestNextRun_Timezone(t *testing.T) {
+	after := time.Date(2026, 2, 16, 0, 0, 0, 0, time.UTC) // 11:00 in Sydney (AEDT, UTC+11)
+
+	next, err := nextRun("0 7 * * *", "Australia/Sydney", after)
+
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 2, 16, 20, 0, 0, 0, time.UTC), next) // 07:00 on the 17th in Sydney
+
+	_, err = nextRun("CRON_TZ=UTC 0 7 * * *", "UTC", after)
+	assert.Error(t, err)
+	_, err = nextRun("61 * * * *", "UTC", after)
+	assert.Error(t, err)
+}
+
+func TestScheduleHandlers_CRUD(t *testing.T) {
+	svc := newTestService(testWorkflow(), 30)
+	router := setupRouter(svc)
+
+	body, _ := json.Marshal(scheduleRequest{Cron: "0 7 * * *", Timezone: "Australia/Sydney", Input: morningInput})
+	req := httptest.NewRequest("POST", "/api/v1/workflows/550e8400-e29b-41d4-a716-446655440000/schedules", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created Schedule
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	assert.True(t, created.Enabled)
+	assert.NotNil(t, created.NextRunAt)
+
+	req = httptest.NewRequest("GET", "/api/v1/workflows/550e8400-e29b-41d4-a716-446655440000/schedules", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct{ Schedules []Schedule }
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	assert.Len(t, list.Schedules, 1)
+
+	disabled := false
+	body, _ = json.Marshal(scheduleRequest{Cron: "30 6 * * 1-5", Input: morningInput, Enabled: &disabled})
+	req = httptest.NewRequest("PUT", "/api/v1/schedules/"+created.ID, bytes.NewReader(body))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var updated Schedule
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&updated))
+	assert.Equal(t, "UTC", updated.Timezone)
+	assert.False(t, updated.Enabled)
+	assert.Nil(t, updated.NextRunAt)
+
+	req = httptest.NewRequest("DELETE", "/api/v1/schedules/"+created.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	req = httptest.NewRequest("GET", "/api/v1/schedules/"+created.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestScheduleHandlers_Validation(t *testing.T) {
+	svc := newTestService(testWorkflow(), 30)
+	router := setupRouter(svc)
+
+	tests := []struct {
+		name string
+		req  scheduleRequest
+		want string
+	}{
+		{"missing cron", scheduleRequest{Input: morningInput}, "cron is required"},
+		{"bad cron", scheduleRequest{Cron: "every morning", Input: morningInput}, "cron is invalid"},
+		{"bad timezone", scheduleRequest{Cron: "0 7 * * *", Timezone: "Mars/Olympus", Input: morningInput}, "timezone is invalid"},
+		{"bad input", scheduleRequest{Cron: "0 7 * * *"}, "formData is required"},
+		{"dry run", scheduleRequest{Cron: "0 7 * * *", Input: ExecuteRequest{FormData: morningInput.FormData, Condition: morningInput.Condition, Mode: ModeDryRun}}, "mode is invalid"},
+		{"mocks", scheduleRequest{Cron: "0 7 * * *", Input: ExecuteRequest{FormData: morningInput.FormData, Condition: morningInput.Condition, Mocks: map[string]map[string]any{"weather-api": {"temperature": 40}}}}, "mocks is invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.req)
+			req := httptest.NewRequest("POST", "/api/v1/workflows/550e8400-e29b-41d4-a716-446655440000/schedules", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var result map[string]string
+			json.NewDecoder(w.Body).Decode(&result)
+			assert.Equal(t, tt.want, result["message"])
+		})
+	}
+}
+
+func TestScheduler_TickRunsDueSchedules(t *testing.T) {
+	svc := newTestService(testWorkflow(), 38)
+	store := svc.schedules.(*memScheduleStore)
+	now := time.Date(2026, 2, 16, 20, 0, 30, 0, time.UTC)
+	due := now.Add(-30 * time.Second)
+	later := now.Add(time.Hour)
+	store.CreateSchedule(context.Background(), &Schedule{ID: "due", TenantID: auth.DefaultTenant, Cron: "0 7 * * *", Timezone: "Australia/Sydney", Input: morningInput, Enabled: true, NextRunAt: &due})
+	store.CreateSchedule(context.Background(), &Schedule{ID: "later", TenantID: auth.DefaultTenant, Cron: "0 7 * * *", Timezone: "UTC", Input: morningInput, Enabled: true, NextRunAt: &later})
+
+	scheduler := NewScheduler(svc, &fakeLock{leader: true}, time.Minute)
+	scheduler.now = func() time.Time { return now }
+	scheduler.tick(context.Background())
+
+	ran, _ := store.GetSchedule(context.Background(), auth.DefaultTenant, "due")
+	assert.Equal(t, "completed", ran.LastStatus)
+	assert.Equal(t, now, *ran.LastRunAt)
+	assert.Equal(t, time.Date(2026, 2, 17, 20, 0, 0, 0, time.UTC), *ran.NextRunAt)
+
+	notRun, _ := store.GetSchedule(context.Background(), auth.DefaultTenant, "later")
+	assert.Nil(t, notRun.LastRunAt)
+}
+
+func TestScheduler_TickSkipsSlotsClaimedElsewhere(t *testing.T) {
+	svc := newTestService(testWorkflow(), 38)
+	store := svc.schedules.(*memScheduleStore)
+	now := time.Date(2026, 2, 16, 20, 0, 30, 0, time.UTC)
+	due := now.Add(-30 * time.Second)
+	store.CreateSchedule(context.Background(), &Schedule{ID: "due", TenantID: auth.DefaultTenant, Cron: "0 7 * * *", Timezone: "UTC", Input: morningInput, Enabled: true, NextRunAt: &due})
+	svc.schedules = claimingScheduleStore{store}
+
+	scheduler := NewScheduler(svc, &fakeLock{leader: true}, time.Minute)
+	scheduler.now = func() time.Time { return now }
+	scheduler.tick(context.Background())
+
+	sch, _ := store.GetSchedule(context.Background(), auth.DefaultTenant, "due")
+	assert.Nil(t, sch.LastRunAt, "the other scheduler runs the slot")
+	assert.Equal(t, now.Add(time.Hour), *sch.NextRunAt, "its next run is kept")
+}
+
+func TestScheduler_FollowerDoesNotRun(t *testing.T) {
+	svc := newTestService(testWorkflow(), 38)
+	store := svc.schedules.(*memScheduleStore)
+	due := time.Now().Add(-time.Minute)
+	store.CreateSchedule(context.Background(), &Schedule{ID: "due", TenantID: auth.DefaultTenant, Cron: "* * * * *", Timezone: "UTC", Input: morningInput, Enabled: true, NextRunAt: &due})
+
+	scheduler := NewScheduler(svc, &fakeLock{leader: false}, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { scheduler.Run(ctx); close(done) }()
+
+	require.Eventually(t, func() bool { return scheduler.Status().LastTick != nil }, time.Second, 5*time.Millisecond)
+	assert.False(t, scheduler.Status().Leader)
+	assert.NoError(t, scheduler.Check(context.Background()), "followers are ready")
+	cancel()
+	<-done
+
+	sch, _ := store.GetSchedule(context.Background(), auth.DefaultTenant, "due")
+	assert.Nil(t, sch.LastRunAt)
+	assert.False(t, scheduler.Status().Running)
+	assert.EqualError(t, scheduler.Check(context.Background()), "scheduler is not running")
+}
@@ -0,0 +1,252 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"workflow-code-test/api/pkg/logging"
+)
+
+// schedulerLockKey is the Postgres advisory lock key that elects the scheduler leader.
+const schedulerLockKey int64 = 0x776f726b666c6f77 // "workflow"
+
+const dueScheduleBatch = 50
+
+// LeaderLock elects a single scheduler leader among API replicas.
+type LeaderLock interface {
+	// TryAcquire reports whether this process holds leadership, acquiring it if free.
+	TryAcquire(ctx context.Context) (bool, error)
+	// Release gives up leadership.
+	Release(ctx context.Context)
+}
+
+// AdvisoryLock is a LeaderLock backed by a session-level Postgres advisory lock.
+// Leadership is tied to a dedicated pool connection, so it is lost automatically if
+// that connection or the process dies.
+type AdvisoryLock struct {
+	pool *pgxpool.Pool
+	key  int64
+
+	mu   sync.Mutex
+	conn *pgxpool.Conn
+}
+
+// NewAdvisoryLock creates an advisory lock for the scheduler.
+func NewAdvisoryLock(pool *pgxpool.Pool) *AdvisoryLock {
+	return &AdvisoryLock{pool: pool, key: schedulerLockKey}
+}
+
+// TryAcquire checks the held connection is still alive, or tries to take the lock.
+func (l *AdvisoryLock) TryAcquire(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		if err := l.conn.Ping(ctx); err == nil {
+			return true, nil
+		}
+		// The session is gone and took the lock with it.
+		l.conn.Release()
+		l.conn = nil
+	}
+
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("acquire connection: %w", err)
+	}
+	var ok bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, l.key).Scan(&ok); err != nil {
+		conn.Release()
+		return false, fmt.Errorf("try advisory lock: %w", err)
+	}
+	if !ok {
+		conn.Release()
+		return false, nil
+	}
+	l.conn = conn
+	return true, nil
+}
+
+// Release unlocks and returns the connection to the pool.
+func (l *AdvisoryLock) Release(ctx context.Context) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return
+	}
+	if _, err := l.conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, l.key); err != nil {
+		slog.Warn("Failed to release scheduler lock", "error", err)
+	}
+	l.conn.Release()
+	l.conn = nil
+}
+
+// SchedulerStatus is a point-in-time view of the scheduler for diagnostics.
+type SchedulerStatus struct {
+	Running  bool       `json:"running"`
+	Leader   bool       `json:"leader"`
+	LastTick *time.Time `json:"lastTick,omitempty"`
+}
+
+// Scheduler fires due schedules. Every replica runs one, but only the replica holding
+// the LeaderLock executes schedules. The leader also purges expired idempotency keys.
+type Scheduler struct {
+	store       ScheduleStore
+	workflows   WorkflowRepo
+	engine      *Engine
+	idempotency IdempotencyStore
+	lock        LeaderLock
+	interval    time.Duration
+	now         func() time.Time
+
+	mu     sync.Mutex
+	status SchedulerStatus
+}
+
+// NewScheduler creates a scheduler for the service's workflows that polls every interval.
+func NewScheduler(svc *Service, lock LeaderLock, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		store:       svc.schedules,
+		workflows:   svc.repo,
+		engine:      svc.engine,
+		idempotency: svc.idempotency,
+		lock:        lock,
+		interval:    interval,
+		now:         time.Now,
+	}
+}
+
+// Run polls until ctx is cancelled, then releases leadership.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.setStatus(func(st *SchedulerStatus) { st.Running = true })
+	defer s.setStatus(func(st *SchedulerStatus) { st.Running, st.Leader = false, false })
+	defer s.lock.Release(context.Background())
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		leader, err := s.lock.TryAcquire(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("Scheduler leader election failed", "error", err)
+		}
+		now := s.now()
+		s.setStatus(func(st *SchedulerStatus) { st.Leader, st.LastTick = leader, &now })
+		if leader {
+			s.tick(ctx)
+			s.purgeIdempotencyKeys(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status returns the scheduler's current state.
+func (s *Scheduler) Status() SchedulerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// Check is a readiness check that fails unless the scheduler is running. Replicas that
+// are not the leader are still ready.
+func (s *Scheduler) Check(context.Context) error {
+	if !s.Status().Running {
+		return errors.New("scheduler is not running")
+	}
+	return nil
+}
+
+func (s *Scheduler) setStatus(fn func(*SchedulerStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.status)
+}
+
+// tick executes every schedule that is due. Each schedule's next run is advanced
+// before executing, so a slot fires at most once and missed slots are not replayed.
+// A slot that was advanced by someone else in the meantime, such as an overlapping
+// leader, is skipped.
+func (s *Scheduler) tick(ctx context.Context) {
+	now := s.now()
+	due, err := s.store.DueSchedules(ctx, now, dueScheduleBatch)
+	if err != nil {
+		slog.Error("Failed to load due schedules", "error", err)
+		return
+	}
+
+	for _, sch := range due {
+		if ctx.Err() != nil {
+			return
+		}
+
+		var nextRunAt *time.Time
+		if next, err := nextRun(sch.Cron, sch.Timezone, now); err != nil {
+			slog.Error("Disabling schedule with invalid cron", "scheduleId", sch.ID, "error", err)
+		} else {
+			nextRunAt = &next
+		}
+		claimed, err := s.store.AdvanceSchedule(ctx, sch.ID, *sch.NextRunAt, nextRunAt)
+		if err != nil {
+			slog.Error("Failed to advance schedule", "scheduleId", sch.ID, "error", err)
+			continue
+		}
+		if !claimed {
+			slog.Debug("Skipping schedule advanced elsewhere", "scheduleId", sch.ID)
+			continue
+		}
+
+		status := s.runSchedule(ctx, sch)
+		if err := s.store.RecordScheduleRun(ctx, sch.ID, now, status); err != nil {
+			slog.Error("Failed to record schedule run", "scheduleId", sch.ID, "error", err)
+		}
+	}
+}
+
+// purgeIdempotencyKeys removes idempotency keys past their retention window.
+func (s *Scheduler) purgeIdempotencyKeys(ctx context.Context) {
+	if s.idempotency == nil {
+		return
+	}
+	n, err := s.idempotency.PurgeIdempotencyKeys(ctx, s.now())
+	if err != nil {
+		slog.Error("Failed to purge idempotency keys", "error", err)
+		return
+	}
+	if n > 0 {
+		slog.Debug("Purged expired idempotency keys", "count", n)
+	}
+}
+
+// runSchedule executes one schedule and returns the status to record.
+func (s *Scheduler) runSchedule(ctx context.Context, sch Schedule) string {
+	ctx = logging.With(ctx, "scheduleId", sch.ID, "workflowId", sch.WorkflowID)
+	wf, err := s.workflows.Get(ctx, sch.TenantID, sch.WorkflowID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get workflow for schedule", "error", err)
+		return "error"
+	}
+	if wf == nil {
+		logging.FromContext(ctx).Warn("Scheduled workflow no longer exists")
+		return "error"
+	}
+
+	logging.FromContext(ctx).Info("Running scheduled workflow")
+	results, err := s.engine.Execute(ctx, wf, NewExecutionState(sch.Input))
+	if err != nil {
+		logging.FromContext(ctx).Error("Scheduled execution failed", "error", err)
+		return "error"
+	}
+	return results.Status
+}
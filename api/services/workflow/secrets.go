@@ -0,0 +1,164 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"workflow-code-test/api/pkg/secrets"
+)
+
+// redactedValue replaces secret values in step outputs, errors and variables.
+const redactedValue = "[redacted]"
+
+// secretRefPattern matches a "{{secret.NAME}}" reference in node metadata.
+var secretRefPattern = regexp.MustCompile(`\{\{\s*secret\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// SecretResolver decrypts a tenant's named secret. secrets.Manager implements it.
+type SecretResolver interface {
+	Resolve(ctx context.Context, tenant, name string) (string, error)
+}
+
+// bindSecrets returns a copy of node whose metadata has every "{{secret.NAME}}" reference
+// replaced by the secret's value, along with the values substituted. The workflow
+// definition itself is never modified, so values exist only for the executor's call.
+func (e *Engine) bindSecrets(ctx context.Context, tenant string, node Node) (Node, []string, error) {
+	if !hasSecretRef(node.Data.Metadata) {
+		return node, nil, nil
+	}
+	if e.secrets == nil {
+		return node, nil, errors.New("node references secrets but no secrets key is configured")
+	}
+
+	resolved := make(map[string]string)
+	var resolveErr error
+	metadata := mapStrings(node.Data.Metadata, func(s string) string {
+		return secretRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+			name := secretRefPattern.FindStringSubmatch(ref)[1]
+			if v, ok := resolved[name]; ok {
+				return v
+			}
+			v, err := e.secrets.Resolve(ctx, tenant, name)
+			if err != nil {
+				if errors.Is(err, secrets.ErrNotFound) {
+					err = fmt.Errorf("secret %q not found", name)
+				}
+				if resolveErr == nil {
+					resolveErr = err
+				}
+				return ref
+			}
+			resolved[name] = v
+			return v
+		})
+	}).(map[string]any)
+	if resolveErr != nil {
+		return node, nil, resolveErr
+	}
+
+	values := make([]string, 0, len(resolved))
+	for _, v := range resolved {
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	node.Data.Metadata = metadata
+	return node, values, nil
+}
+
+// resolveSecretRef returns s with any secret references resolved for tenant. Unlike
+// bindSecrets it is for values the service itself uses, such as webhook HMAC keys.
+func (e *Engine) resolveSecretRef(ctx context.Context, tenant, s string) (string, error) {
+	node := Node{Data: NodeData{Metadata: map[string]any{"value": s}}}
+	bound, _, err := e.bindSecrets(ctx, tenant, node)
+	if err != nil {
+		return "", err
+	}
+	return bound.Data.Metadata["value"].(string), nil
+}
+
+// hasSecretRef reports whether any string within v contains a secret reference.
+func hasSecretRef(v any) bool {
+	found := false
+	mapStrings(v, func(s string) string {
+		if !found && secretRefPattern.MatchString(s) {
+			found = true
+		}
+		return s
+	})
+	return found
+}
+
+// redactor replaces secret values with redactedValue.
+type redactor struct {
+	replacer *strings.Replacer
+}
+
+// newRedactor returns a redactor for values, or nil if there is nothing to redact.
+// Longer values are replaced first so that one secret containing another is fully hidden.
+func newRedactor(values []string) *redactor {
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	pairs := make([]string, 0, 2*len(values))
+	for _, v := range values {
+		pairs = append(pairs, v, redactedValue)
+	}
+	return &redactor{replacer: strings.NewReplacer(pairs...)}
+}
+
+// string redacts s.
+func (r *redactor) string(s string) string {
+	return r.replacer.Replace(s)
+}
+
+// value returns a copy of a decoded JSON value with secrets redacted from its strings.
+func (r *redactor) value(v any) any {
+	return mapStrings(v, r.string)
+}
+
+// variables redacts secrets from every variable in place.
+func (r *redactor) variables(vars map[string]any) {
+	for k, v := range vars {
+		vars[k] = r.value(v)
+	}
+}
+
+// mapStrings returns a copy of v with f applied to every string in its maps and slices.
+// Values of other types are returned unchanged.
+func mapStrings(v any, f func(string) string) any {
+	switch v := v.(type) {
+	case string:
+		return f(v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = mapStrings(item, f)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = mapStrings(item, f)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]string, len(v))
+		for k, item := range v {
+			out[k] = f(item)
+		}
+		return out
+	case []string:
+		out := make([]string, len(v))
+		for i, item := range v {
+			out[i] = f(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
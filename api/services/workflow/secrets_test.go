@@ -0,0 +1,133 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workflow-code-test/api/pkg/auth"
+	"workflow-code-test/api/pkg/secrets"
+)
+
+// mapSecrets implements SecretResolver from a map keyed by "tenant/name".
+type mapSecrets map[string]string
+
+func (m mapSecrets) Resolve(_ context.Context, tenant, name string) (string, error) {
+	v, ok := m[tenant+"/"+name]
+	if !ok {
+		return "", secrets.ErrNotFound
+	}
+	return v, nil
+}
+
+// credentialExecutor records the metadata it was given and echoes its token into its
+// output, a variable and, if metadata.fail is set, its error.
+type credentialExecutor struct {
+	seen map[string]any
+}
+
+func (e *credentialExecutor) Execute(_ context.Context, node Node, state *ExecutionState) (*StepResult, error) {
+	e.seen = node.Data.Metadata
+	header, _ := node.Data.Metadata["headers"].(map[string]any)["Authorization"].(string)
+	if fail, _ := node.Data.Metadata["fail"].(bool); fail {
+		return nil, errors.New("401 Unauthorized for " + header)
+	}
+	state.Variables["response"] = map[string]any{"echo": header}
+	return &StepResult{Status: "completed", Output: map[string]any{"message": "sent " + header}}, nil
+}
+
+func credentialWorkflow(fail bool) *Workflow {
+	return &Workflow{
+		ID:       "wf",
+		TenantID: auth.DefaultTenant,
+		Nodes: []Node{
+			{ID: "start", Type: "start"},
+			{ID: "call", Type: "http", Data: NodeData{Metadata: map[string]any{
+				"headers": map[string]any{"Authorization": "Bearer {{ secret.API_TOKEN }}"},
+				"fail":    fail,
+			}}},
+		},
+		Edges: []Edge{{ID: "e1", Source: "start", Target: "call"}},
+	}
+}
+
+func newCredentialEngine(resolver SecretResolver) (*Engine, *credentialExecutor) {
+	exec := &credentialExecutor{}
+	engine := NewEngine(Registry{"start": &StartExecutor{}, "http": exec})
+	engine.secrets = resolver
+	return engine, exec
+}
+
+func TestEngine_ResolvesAndRedactsSecrets(t *testing.T) {
+	engine, exec := newCredentialEngine(mapSecrets{"default/API_TOKEN": "tok-abc123"})
+	wf := credentialWorkflow(false)
+	state := &ExecutionState{Variables: map[string]any{}}
+
+	results, err := engine.Execute(context.Background(), wf, state)
+	require.NoError(t, err)
+	require.Equal(t, "completed", results.Status)
+
+	assert.Equal(t, "Bearer tok-abc123", exec.seen["headers"].(map[string]any)["Authorization"])
+	assert.Equal(t, "sent Bearer [redacted]", results.Steps[1].Output["message"])
+	assert.Equal(t, map[string]any{"echo": "Bearer [redacted]"}, state.Variables["response"])
+	assert.Equal(t, "Bearer {{ secret.API_TOKEN }}", wf.Nodes[1].Data.Metadata["headers"].(map[string]any)["Authorization"],
+		"the workflow definition keeps the reference")
+}
+
+func TestEngine_RedactsSecretsFromErrors(t *testing.T) {
+	engine, _ := newCredentialEngine(mapSecrets{"default/API_TOKEN": "tok-abc123"})
+
+	results, err := engine.Execute(context.Background(), credentialWorkflow(true), &ExecutionState{Variables: map[string]any{}})
+	require.NoError(t, err)
+	assert.Equal(t, "failed", results.Status)
+	assert.Equal(t, "401 Unauthorized for Bearer [redacted]", results.Steps[1].Error)
+	assert.NotContains(t, results.Steps[1].Output["message"], "tok-abc123")
+}
+
+func TestEngine_UnresolvableSecretsFailTheStep(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver SecretResolver
+		tenant   string
+		message  string
+	}{
+		{"missing", mapSecrets{}, auth.DefaultTenant, `secret "API_TOKEN" not found`},
+		{"other tenant", mapSecrets{"default/API_TOKEN": "tok-abc123"}, "payments", `secret "API_TOKEN" not found`},
+		{"not configured", nil, auth.DefaultTenant, "node references secrets but no secrets key is configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, exec := newCredentialEngine(tt.resolver)
+			wf := credentialWorkflow(false)
+			wf.TenantID = tt.tenant
+
+			results, err := engine.Execute(context.Background(), wf, &ExecutionState{Variables: map[string]any{}})
+			require.NoError(t, err)
+			assert.Equal(t, "failed", results.Status)
+			assert.Equal(t, tt.message, results.Steps[1].Error)
+			assert.Nil(t, exec.seen, "the executor is not called")
+		})
+	}
+}
+
+func TestHandleWebhook_SecretReference(t *testing.T) {
+	svc := newTestService(webhookWorkflow("{{secret.HOOK_KEY}}"), 30.0)
+	svc.engine.secrets = mapSecrets{"default/HOOK_KEY": "s3cret"}
+	router := setupRouter(svc)
+
+	post := func(signature string) int {
+		req := httptest.NewRequest("POST", "/api/v1/hooks/tok-0123456789abcdef", bytes.NewReader([]byte(monitoringPayload)))
+		req.Header.Set("X-Signature-256", signature)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, post(sign("s3cret", monitoringPayload)))
+	assert.Equal(t, http.StatusUnauthorized, post(sign("{{secret.HOOK_KEY}}", monitoringPayload)))
+}
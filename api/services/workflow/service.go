@@ -3,29 +3,95 @@ package workflow
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"workflow-code-test/api/pkg/auth"
+	"workflow-code-test/api/pkg/resilience"
 )
 
-// WorkflowRepo abstracts workflow persistence for testability.
+// WorkflowRepo abstracts workflow persistence for testability. Workflows are only visible
+// within their tenant; webhook tokens are looked up across tenants.
 type WorkflowRepo interface {
-	Get(ctx context.Context, id string) (*Workflow, error)
+	Get(ctx context.Context, tenant, id string) (*Workflow, error)
+	FindByWebhookToken(ctx context.Context, token string) (*Workflow, error)
+	Update(ctx context.Context, wf *Workflow) (bool, error)
 }
 
 // Service wires together the repository and execution engine for the workflow domain.
 type Service struct {
-	repo   WorkflowRepo
-	engine *Engine
+	repo       WorkflowRepo
+	schedules  ScheduleStore
+	executions ExecutionStore
+	engine     *Engine
+
+	idempotency    IdempotencyStore
+	idempotencyTTL time.Duration // How long idempotent responses are replayed
+
+	authz  *auth.Authorizer    // Checks callers' roles; nil disables permission checks
+	guards []*resilience.Guard // Outbound integrations reported by the diagnostics endpoint
 }
 
-// NewService creates a Service with a real PostgreSQL repository and Open-Meteo weather client.
-func NewService(pool *pgxpool.Pool) (*Service, error) {
+// NewService creates a Service with a real PostgreSQL repository and Open-Meteo weather client
+// calling cfg.WeatherURL.
+// Outbound clients are wrapped in a rate limiter and circuit breaker configured by cfg.
+// Cities are geocoded from the bundled gazetteer first, then the Open-Meteo geocoding API
+// at cfg.GeocodingURL unless cfg.GeocoderOffline is set.
+// Executor plugins found in cfg.PluginDir are registered alongside the built-in node types.
+// Execution, node and weather client metrics are registered on cfg.Metrics when it is set.
+// Handlers check the caller's permissions with authz. Nodes' "{{secret.NAME}}" references
+// are resolved with secrets, which may be nil if no secrets key is configured.
+func NewService(ctx context.Context, pool *pgxpool.Pool, cfg Config, authz *auth.Authorizer, secrets SecretResolver) (*Service, error) {
 	repo := NewRepository(pool)
-	weatherClient := NewOpenMeteoClient()
-	registry := NewRegistry(weatherClient)
+	var metrics *Metrics
+	if cfg.Metrics != nil {
+		metrics = NewMetrics(cfg.Metrics)
+	}
+	weatherGuard := resilience.NewGuard("open-meteo", cfg.WeatherBreaker, cfg.WeatherRateLimit)
+	weatherClient := NewGuardedWeatherClient(NewInstrumentedWeatherClient(NewOpenMeteoClient(cfg.WeatherURL, cfg.WeatherTimeout), metrics), weatherGuard)
+	guards := []*resilience.Guard{weatherGuard}
+
+	gazetteer, err := NewGazetteer()
+	if err != nil {
+		return nil, err
+	}
+	geocoders := ChainGeocoder{gazetteer}
+	if !cfg.GeocoderOffline {
+		geocodeGuard := resilience.NewGuard("open-meteo-geocoding", cfg.WeatherBreaker, cfg.WeatherRateLimit)
+		geocoders = append(geocoders, NewGuardedGeocoder(NewOpenMeteoGeocoder(cfg.GeocodingURL, cfg.WeatherTimeout), geocodeGuard))
+		guards = append(guards, geocodeGuard)
+	}
+	geocoder := NewCachingGeocoder(geocoders, cfg.GeocodeCacheTTL)
+
+	registry := NewRegistry(weatherClient, geocoder, cfg.DelayInlineMax)
+	if cfg.PluginDir != "" {
+		plugins, err := LoadPlugins(ctx, cfg.PluginDir)
+		if err != nil {
+			return nil, err
+		}
+		registry.RegisterPlugins(plugins)
+	}
 	engine := NewEngine(registry)
-	return &Service{repo: repo, engine: engine}, nil
+	engine.suspensions = repo
+	engine.secrets = secrets
+	engine.metrics = metrics
+	return &Service{
+		repo:           repo,
+		schedules:      repo,
+		executions:     repo,
+		engine:         engine,
+		idempotency:    repo,
+		idempotencyTTL: cfg.IdempotencyTTL,
+		authz:          authz,
+		guards:         guards,
+	}, nil
+}
+
+// NodeTypes returns the node types the service can execute, including plugins, sorted.
+func (s *Service) NodeTypes() []string {
+	return s.engine.registry.Types()
 }
 
 // jsonMiddleware sets the Content-Type header to application/json.
@@ -43,5 +109,44 @@ func (s *Service) LoadRoutes(parentRouter *mux.Router) {
 	router.Use(jsonMiddleware)
 
 	router.HandleFunc("/{id}", s.HandleGetWorkflow).Methods("GET")
-	router.HandleFunc("/{id}/execute", s.HandleExecuteWorkflow).Methods("POST")
+	router.HandleFunc("/{id}", s.HandleUpdateWorkflow).Methods("PUT")
+	router.Handle("/{id}/execute", s.idempotent(http.HandlerFunc(s.HandleExecuteWorkflow))).Methods("POST")
+	router.HandleFunc("/{id}/schedules", s.HandleListSchedules).Methods("GET")
+	router.HandleFunc("/{id}/schedules", s.HandleCreateSchedule).Methods("POST")
+	router.HandleFunc("/{id}/tests/run", s.HandleRunTests).Methods("POST")
+
+	schedules := parentRouter.PathPrefix("/schedules").Subrouter()
+	schedules.Use(jsonMiddleware)
+	schedules.HandleFunc("/{scheduleId}", s.HandleGetSchedule).Methods("GET")
+	schedules.HandleFunc("/{scheduleId}", s.HandleUpdateSchedule).Methods("PUT")
+	schedules.HandleFunc("/{scheduleId}", s.HandleDeleteSchedule).Methods("DELETE")
+
+	executions := parentRouter.PathPrefix("/executions").Subrouter()
+	executions.Use(jsonMiddleware)
+	executions.HandleFunc("/{executionId}", s.HandleGetExecution).Methods("GET")
+
+	hooks := parentRouter.PathPrefix("/hooks").Subrouter()
+	hooks.Use(jsonMiddleware)
+	hooks.HandleFunc("/{token}", s.HandleWebhook).Methods("POST")
+
+	parentRouter.Handle("/node-types", jsonMiddleware(http.HandlerFunc(s.HandleListNodeTypes))).Methods("GET")
+	parentRouter.Handle("/diagnostics", jsonMiddleware(http.HandlerFunc(s.HandleDiagnostics))).Methods("GET")
+}
+
+// authorize checks that the caller holds perm on workflowID, or globally when workflowID
+// is empty, writing a 403 response and returning false if not.
+func (s *Service) authorize(w http.ResponseWriter, r *http.Request, perm auth.Permission, workflowID string) bool {
+	if s.authz == nil {
+		return true
+	}
+	return s.authz.Check(w, r, perm, workflowID)
+}
+
+// allowed reports whether the caller holds perm on workflowID without writing a response.
+func (s *Service) allowed(r *http.Request, perm auth.Permission, workflowID string) (bool, error) {
+	if s.authz == nil {
+		return true, nil
+	}
+	p, _ := auth.FromContext(r.Context())
+	return s.authz.Allowed(r.Context(), p, perm, workflowID)
 }
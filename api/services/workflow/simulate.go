@@ -0,0 +1,85 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Execution modes selected by ExecuteRequest.Mode.
+const (
+	ModeLive   = "live"
+	ModeDryRun = "dry_run"
+)
+
+// Simulator is optionally implemented by a NodeExecutor whose node reaches outside the
+// process, such as calling an API or sending a message. In dry runs the engine calls
+// Simulate instead of Execute; it must have no external effects. Executors without
+// external effects run normally in dry runs.
+type Simulator interface {
+	Simulate(ctx context.Context, node Node, state *ExecutionState) (*StepResult, error)
+}
+
+// mockResult stands in for running node. The mock becomes the step's output and, except
+// for its message, is merged into the execution variables as an executor's outputs
+// would be, so that mocking {"temperature": 40} steers a later condition.
+// Mocked temperatures are read in the request's unit, like a weather step's output, and
+// stored in °C like the variables a weather step sets. A boolean conditionResult is
+// stored as the "true" or "false" handle a condition node sets.
+func mockResult(node Node, mock map[string]any, state *ExecutionState) *StepResult {
+	output := make(map[string]any, len(mock)+1)
+	output["message"] = "Output mocked"
+	for k, v := range mock {
+		if b, ok := v.(bool); ok && k == "conditionResult" {
+			v = strconv.FormatBool(b)
+		}
+		output[k] = v
+		if k == "message" {
+			continue
+		}
+		if t, ok := toFloat64(v); ok && isTemperatureVariable(k) && !strings.HasSuffix(k, "Celsius") {
+			v = state.Unit.ToCelsius(t)
+		}
+		state.Variables[k] = v
+	}
+	return &StepResult{
+		NodeID: node.ID, NodeType: node.Type, Label: node.Data.Label,
+		Status: "completed",
+		Output: output,
+	}
+}
+
+// ValidateMocks checks that every mocked node exists in wf, and that a mocked
+// conditionResult names a branch.
+func ValidateMocks(wf *Workflow, mocks map[string]map[string]any) error {
+	for id, mock := range mocks {
+		found := false
+		for _, n := range wf.Nodes {
+			if n.ID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("mocks: node %q not found", id)
+		}
+		if v, ok := mock["conditionResult"]; ok {
+			switch v {
+			case true, false, "true", "false":
+			default:
+				return fmt.Errorf("mocks: node %q: conditionResult must be true or false", id)
+			}
+		}
+	}
+	return nil
+}
+
+// ExecutionMetadata describes how an execution ran, for ExecutionResults.Metadata.
+func ExecutionMetadata(state *ExecutionState) map[string]any {
+	metadata := map[string]any{"unit": string(state.Unit)}
+	if state.DryRun {
+		metadata["mode"] = ModeDryRun
+	}
+	return metadata
+}
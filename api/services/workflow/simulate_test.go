@@ -0,0 +1,145 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func simulationState(dryRun bool, mocks map[string]map[string]any) *ExecutionState {
+	return &ExecutionState{
+		FormData:  map[string]any{"name": "Alice", "email": "alice@example.com", "city": "Sydney"},
+		Condition: ConditionInput{Operator: "greater_than", Threshold: 25},
+		Variables: map[string]any{},
+		DryRun:    dryRun,
+		Mocks:     mocks,
+	}
+}
+
+func TestEngine_MockSteersTheCondition(t *testing.T) {
+	client := &mockWeatherClient{temperature: 10}
+	engine := NewEngine(NewRegistry(client, nil, defaultInlineDelay))
+	state := simulationState(false, map[string]map[string]any{"weather-api": {"temperature": 40.0}})
+
+	results, err := engine.Execute(context.Background(), testWorkflow(), state)
+
+	require.NoError(t, err)
+	assert.Equal(t, "completed", results.Status)
+	require.Len(t, results.Steps, 6, "the mocked 40° should take the alert branch")
+	weather := results.Steps[2]
+	assert.True(t, weather.Mocked)
+	assert.True(t, weather.Simulated)
+	assert.Equal(t, 40.0, weather.Output["temperature"])
+	assert.Equal(t, "Output mocked", weather.Output["message"])
+	assert.False(t, results.Steps[4].Simulated, "only mocked nodes are simulated in live mode")
+	assert.Equal(t, 0, client.gotDays, "the weather API is not called")
+}
+
+func TestEngine_MockedTemperaturesUseTheRequestUnit(t *testing.T) {
+	engine := NewEngine(NewRegistry(&mockWeatherClient{temperature: 10}, nil, defaultInlineDelay))
+	run := func(temperature float64) (*ExecutionResults, *ExecutionState) {
+		state := simulationState(true, map[string]map[string]any{"weather-api": {"temperature": temperature, "apparentTemperature": temperature}})
+		state.Unit = Fahrenheit
+		state.Condition.Threshold = 80
+		results, err := engine.Execute(context.Background(), testWorkflow(), state)
+		require.NoError(t, err)
+		return results, state
+	}
+
+	results, state := run(77)
+	assert.Len(t, results.Steps, 5, "77°F is below the 80°F threshold")
+	assert.Equal(t, 77.0, results.Steps[2].Output["temperature"])
+	assert.InDelta(t, 25.0, state.Variables["temperature"], 1e-9)
+	assert.InDelta(t, 25.0, state.Variables["apparentTemperature"], 1e-9)
+
+	results, _ = run(86)
+	assert.Len(t, results.Steps, 6, "86°F is above the 80°F threshold")
+}
+
+func TestEngine_DryRunWithoutWeatherMockFails(t *testing.T) {
+	engine := NewEngine(NewRegistry(&mockWeatherClient{temperature: 30}, nil, defaultInlineDelay))
+
+	results, err := engine.Execute(context.Background(), testWorkflow(), simulationState(true, nil))
+
+	require.NoError(t, err)
+	assert.Equal(t, "failed", results.Status)
+	require.Len(t, results.Steps, 3)
+	assert.True(t, results.Steps[2].Simulated)
+	assert.Contains(t, results.Steps[2].Error, "mock this node's output")
+}
+
+func TestEngine_DryRunDoesNotSendEmail(t *testing.T) {
+	engine := NewEngine(NewRegistry(&mockWeatherClient{}, nil, defaultInlineDelay))
+	state := simulationState(true, map[string]map[string]any{"weather-api": {"temperature": 30.0}})
+
+	results, err := engine.Execute(context.Background(), testWorkflow(), state)
+
+	require.NoError(t, err)
+	require.Len(t, results.Steps, 6)
+	email := results.Steps[4]
+	assert.True(t, email.Simulated)
+	assert.False(t, email.Mocked)
+	assert.Equal(t, false, email.Output["emailSent"])
+	assert.Contains(t, email.Output["message"], "not sent in a dry run")
+	assert.False(t, results.Steps[3].Simulated, "nodes without external effects run normally")
+}
+
+func TestDelayExecutor_SimulateDoesNotWait(t *testing.T) {
+	executor := NewDelayExecutor(time.Second)
+	node := Node{ID: "wait", Type: "delay", Data: NodeData{Metadata: map[string]any{"duration": "1h"}}}
+
+	result, err := executor.Simulate(context.Background(), node, newTestState())
+
+	require.NoError(t, err)
+	assert.Equal(t, "completed", result.Status)
+	assert.True(t, result.ResumeAt.IsZero())
+	assert.Contains(t, result.Output["message"], "skipped in a dry run")
+	assert.NotEmpty(t, result.Output["resumeAt"])
+}
+
+func TestValidateMocks(t *testing.T) {
+	assert.NoError(t, ValidateMocks(testWorkflow(), nil))
+	assert.NoError(t, ValidateMocks(testWorkflow(), map[string]map[string]any{"weather-api": {}}))
+	assert.EqualError(t, ValidateMocks(testWorkflow(), map[string]map[string]any{"sms": {}}), `mocks: node "sms" not found`)
+	for _, v := range []any{true, false, "true", "false"} {
+		assert.NoError(t, ValidateMocks(testWorkflow(), map[string]map[string]any{"condition": {"conditionResult": v}}))
+	}
+	for _, v := range []any{"yes", 1, nil} {
+		assert.EqualError(t, ValidateMocks(testWorkflow(), map[string]map[string]any{"condition": {"conditionResult": v}}),
+			`mocks: node "condition": conditionResult must be true or false`)
+	}
+}
+
+func TestEngine_MockedConditionTakesEitherBranch(t *testing.T) {
+	engine := NewEngine(NewRegistry(&mockWeatherClient{temperature: 10}, nil, defaultInlineDelay))
+	tests := []struct {
+		result any
+		path   []string
+	}{
+		{true, []string{"start", "form", "weather-api", "condition", "email", "end"}},
+		{false, []string{"start", "form", "weather-api", "condition", "end"}},
+		{"true", []string{"start", "form", "weather-api", "condition", "email", "end"}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.result), func(t *testing.T) {
+			state := simulationState(true, map[string]map[string]any{
+				"weather-api": {"temperature": 10.0},
+				"condition":   {"conditionResult": tt.result},
+			})
+
+			results, err := engine.Execute(context.Background(), testWorkflow(), state)
+
+			require.NoError(t, err)
+			assert.Equal(t, "completed", results.Status)
+			var path []string
+			for _, step := range results.Steps {
+				path = append(path, step.NodeID)
+			}
+			assert.Equal(t, tt.path, path)
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"workflow-code-test/api/pkg/logging"
+)
+
+// maxStepLogEntries caps the log entries kept for one step. Later entries are counted and
+// reported in a final entry instead.
+const maxStepLogEntries = 100
+
+// StepLogEntry is one line an executor logged while running a step.
+type StepLogEntry struct {
+	Time    time.Time      `json:"time"`
+	Level   string         `json:"level"`
+	Message string         `json:"message"`
+	Attrs   map[string]any `json:"attrs,omitempty"`
+}
+
+// stepLog collects the entries logged during one step.
+type stepLog struct {
+	mu      sync.Mutex
+	entries []StepLogEntry
+	dropped int
+	redact  *redactor // Hides the step's secret values; nil until secrets are bound
+}
+
+// withStepLog returns a copy of ctx whose logger also records every entry, at any level,
+// into a new stepLog. Entries still reach the process log as before, with the same
+// secrets redacted; only attributes added by the executor are recorded, not the request
+// and execution IDs already on the logger.
+func withStepLog(ctx context.Context) (context.Context, *stepLog) {
+	log := &stepLog{}
+	handler := &stepLogHandler{next: logging.FromContext(ctx).Handler(), log: log}
+	return logging.WithLogger(ctx, slog.New(handler)), log
+}
+
+// setRedactor redacts the step's secret values from every entry logged from now on,
+// both in the step and in the process log.
+func (l *stepLog) setRedactor(redact *redactor) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.redact = redact
+}
+
+func (l *stepLog) redactor() *redactor {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.redact
+}
+
+func (l *stepLog) add(e StepLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) >= maxStepLogEntries {
+		l.dropped++
+		return
+	}
+	l.entries = append(l.entries, e)
+}
+
+// result returns the recorded entries, or nil if there are none.
+func (l *stepLog) result() []StepLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries := l.entries
+	if l.dropped > 0 {
+		entries = append(entries, StepLogEntry{
+			Time:    time.Now().UTC(),
+			Level:   slog.LevelWarn.String(),
+			Message: fmt.Sprintf("%d more log entries dropped", l.dropped),
+		})
+	}
+	return entries
+}
+
+// stepLogHandler is a slog.Handler that records entries into a stepLog and forwards them
+// to next.
+type stepLogHandler struct {
+	next   slog.Handler
+	log    *stepLog
+	attrs  []slog.Attr // Attributes added with WithAttrs since the step began
+	groups []string
+}
+
+func (h *stepLogHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *stepLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if redact := h.log.redactor(); redact != nil {
+		r = redactRecord(redact, r)
+	}
+	attrs := make(map[string]any)
+	for _, a := range h.attrs {
+		addAttr(attrs, a)
+	}
+	group := attrs
+	for _, g := range h.groups {
+		sub, ok := group[g].(map[string]any)
+		if !ok {
+			sub = make(map[string]any)
+			group[g] = sub
+		}
+		group = sub
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(group, a)
+		return true
+	})
+	pruneEmpty(attrs)
+
+	entry := StepLogEntry{Time: r.Time.UTC(), Level: r.Level.String(), Message: r.Message}
+	if len(attrs) > 0 {
+		entry.Attrs = attrs
+	}
+	h.log.add(entry)
+
+	if h.next.Enabled(ctx, r.Level) {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (h *stepLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	if redact := h.log.redactor(); redact != nil {
+		attrs = redactAttrs(redact, attrs)
+	}
+	next := *h
+	next.next = h.next.WithAttrs(attrs)
+	// Attributes added inside a group belong to it, so nest them the way Handle nests records
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		attrs = []slog.Attr{{Key: h.groups[i], Value: slog.GroupValue(attrs...)}}
+	}
+	next.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &next
+}
+
+func (h *stepLogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := *h
+	next.next = h.next.WithGroup(name)
+	next.groups = append(append([]string(nil), h.groups...), name)
+	return &next
+}
+
+// redactRecord returns a copy of r with secrets redacted from its message and attributes.
+func redactRecord(redact *redactor, r slog.Record) slog.Record {
+	out := slog.NewRecord(r.Time, r.Level, redact.string(r.Message), r.PC)
+	var attrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	out.AddAttrs(redactAttrs(redact, attrs)...)
+	return out
+}
+
+// redactAttrs returns copies of attrs with secrets redacted from their string values,
+// including errors and strings inside groups, maps and slices.
+func redactAttrs(redact *redactor, attrs []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		v := a.Value.Resolve()
+		switch v.Kind() {
+		case slog.KindString:
+			v = slog.StringValue(redact.string(v.String()))
+		case slog.KindGroup:
+			v = slog.GroupValue(redactAttrs(redact, v.Group())...)
+		case slog.KindAny:
+			if err, ok := v.Any().(error); ok {
+				v = slog.StringValue(redact.string(err.Error()))
+			} else {
+				v = slog.AnyValue(redact.value(v.Any()))
+			}
+		}
+		out[i] = slog.Attr{Key: a.Key, Value: v}
+	}
+	return out
+}
+
+// addAttr adds a to m as a JSON-friendly value, following slog's rules for empty keys
+// and groups.
+func addAttr(m map[string]any, a slog.Attr) {
+	v := a.Value.Resolve()
+	if a.Key == "" && v.Kind() != slog.KindGroup {
+		return
+	}
+	switch v.Kind() {
+	case slog.KindGroup:
+		target := m
+		if a.Key != "" {
+			sub, ok := m[a.Key].(map[string]any)
+			if !ok {
+				sub = make(map[string]any)
+				m[a.Key] = sub
+			}
+			target = sub
+		}
+		for _, ga := range v.Group() {
+			addAttr(target, ga)
+		}
+	case slog.KindTime:
+		m[a.Key] = v.Time().UTC().Format(time.RFC3339Nano)
+	case slog.KindDuration:
+		m[a.Key] = v.Duration().String()
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			m[a.Key] = err.Error()
+		} else {
+			m[a.Key] = v.Any()
+		}
+	default:
+		m[a.Key] = v.Any()
+	}
+}
+
+// pruneEmpty removes groups left without attributes, as slog's handlers omit them.
+func pruneEmpty(m map[string]any) {
+	for k, v := range m {
+		if sub, ok := v.(map[string]any); ok {
+			pruneEmpty(sub)
+			if len(sub) == 0 {
+				delete(m, k)
+			}
+		}
+	}
+}
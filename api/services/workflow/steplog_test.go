@@ -0,0 +1,141 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workflow-code-test/api/pkg/auth"
+	"workflow-code-test/api/pkg/logging"
+)
+
+// executorFunc adapts a function to NodeExecutor.
+type executorFunc func(ctx context.Context, node Node, state *ExecutionState) (*StepResult, error)
+
+func (f executorFunc) Execute(ctx context.Context, node Node, state *ExecutionState) (*StepResult, error) {
+	return f(ctx, node, state)
+}
+
+// runLoggingNode executes a start node followed by one "log" node run by f, logging the
+// process log to buf, and returns the log node's step.
+func runLoggingNode(t *testing.T, buf *bytes.Buffer, resolver SecretResolver, metadata map[string]any, f executorFunc) ExecutionStep {
+	t.Helper()
+	engine := NewEngine(Registry{"start": &StartExecutor{}, "log": f})
+	engine.secrets = resolver
+	wf := &Workflow{
+		ID:       "wf",
+		TenantID: auth.DefaultTenant,
+		Nodes: []Node{
+			{ID: "start", Type: "start"},
+			{ID: "call", Type: "log", Data: NodeData{Metadata: metadata}},
+		},
+		Edges: []Edge{{ID: "e1", Source: "start", Target: "call"}},
+	}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	ctx := logging.WithLogger(context.Background(), logger)
+
+	results, err := engine.Execute(ctx, wf, &ExecutionState{Variables: map[string]any{}})
+	require.NoError(t, err)
+	require.Len(t, results.Steps, 2)
+	assert.Empty(t, results.Steps[0].Logs, "the start node logs nothing")
+	return results.Steps[1]
+}
+
+func TestEngine_AttachesExecutorLogsToStep(t *testing.T) {
+	var buf bytes.Buffer
+	step := runLoggingNode(t, &buf, nil, nil, func(ctx context.Context, node Node, _ *ExecutionState) (*StepResult, error) {
+		log := logging.FromContext(ctx)
+		log.Debug("Calling upstream", "attempt", 1)
+		log.With("upstream", "crm").WithGroup("http").Info("Upstream responded", "status", 200, "elapsed", 1500*time.Millisecond)
+		return &StepResult{Status: "completed", Output: map[string]any{"message": "ok"}}, nil
+	})
+
+	require.Len(t, step.Logs, 2)
+	assert.Equal(t, "DEBUG", step.Logs[0].Level)
+	assert.Equal(t, "Calling upstream", step.Logs[0].Message)
+	assert.Equal(t, map[string]any{"attempt": int64(1)}, step.Logs[0].Attrs)
+	assert.False(t, step.Logs[0].Time.IsZero())
+
+	assert.Equal(t, "INFO", step.Logs[1].Level)
+	assert.Equal(t, map[string]any{
+		"upstream": "crm",
+		"http":     map[string]any{"status": int64(200), "elapsed": "1.5s"},
+	}, step.Logs[1].Attrs, "correlation IDs stay out of step logs")
+
+	// The process log still receives entries at its own level, with correlation IDs
+	assert.NotContains(t, buf.String(), "Calling upstream")
+	assert.Contains(t, buf.String(), `"msg":"Upstream responded"`)
+	assert.Contains(t, buf.String(), `"nodeId":"call"`)
+}
+
+func TestEngine_AttachesLogsToFailedStep(t *testing.T) {
+	var buf bytes.Buffer
+	step := runLoggingNode(t, &buf, nil, nil, func(ctx context.Context, _ Node, _ *ExecutionState) (*StepResult, error) {
+		logging.FromContext(ctx).Warn("Upstream rejected request", "error", errors.New("429 Too Many Requests"))
+		return nil, errors.New("upstream unavailable")
+	})
+
+	assert.Equal(t, "error", step.Status)
+	require.Len(t, step.Logs, 1)
+	assert.Equal(t, "WARN", step.Logs[0].Level)
+	assert.Equal(t, map[string]any{"error": "429 Too Many Requests"}, step.Logs[0].Attrs)
+}
+
+func TestEngine_RedactsSecretsFromStepLogs(t *testing.T) {
+	var buf bytes.Buffer
+	resolver := mapSecrets{"default/API_TOKEN": "tok-abc123"}
+	metadata := map[string]any{"token": "{{ secret.API_TOKEN }}"}
+	step := runLoggingNode(t, &buf, resolver, metadata, func(ctx context.Context, node Node, _ *ExecutionState) (*StepResult, error) {
+		token := node.Data.Metadata["token"].(string)
+		logging.FromContext(ctx).Info("Using token "+token, "token", token)
+		return &StepResult{Status: "completed", Output: map[string]any{"message": "ok"}}, nil
+	})
+
+	require.Len(t, step.Logs, 1)
+	assert.Equal(t, "Using token [redacted]", step.Logs[0].Message)
+	assert.Equal(t, map[string]any{"token": "[redacted]"}, step.Logs[0].Attrs)
+	assert.Contains(t, buf.String(), "Using token [redacted]")
+	assert.NotContains(t, buf.String(), "tok-abc123", "the process log is redacted too")
+}
+
+func TestEngine_RedactsSecretsFromLoggerAttrsAndErrors(t *testing.T) {
+	var buf bytes.Buffer
+	resolver := mapSecrets{"default/API_TOKEN": "tok-abc123"}
+	metadata := map[string]any{"token": "{{ secret.API_TOKEN }}"}
+	step := runLoggingNode(t, &buf, resolver, metadata, func(ctx context.Context, node Node, _ *ExecutionState) (*StepResult, error) {
+		token := node.Data.Metadata["token"].(string)
+		logger := logging.FromContext(ctx).With("auth", "Bearer "+token)
+		logger.Warn("Request failed", "error", fmt.Errorf("rejected %s", token), slog.Group("request", "headers", map[string]any{"Authorization": token}))
+		return &StepResult{Status: "completed", Output: map[string]any{"message": "ok"}}, nil
+	})
+
+	require.Len(t, step.Logs, 1)
+	assert.Equal(t, map[string]any{
+		"auth":    "Bearer [redacted]",
+		"error":   "rejected [redacted]",
+		"request": map[string]any{"headers": map[string]any{"Authorization": "[redacted]"}},
+	}, step.Logs[0].Attrs)
+	assert.NotContains(t, buf.String(), "tok-abc123")
+}
+
+func TestEngine_CapsStepLogs(t *testing.T) {
+	var buf bytes.Buffer
+	step := runLoggingNode(t, &buf, nil, nil, func(ctx context.Context, _ Node, _ *ExecutionState) (*StepResult, error) {
+		for i := 0; i < maxStepLogEntries+5; i++ {
+			logging.FromContext(ctx).Debug("tick", "i", i)
+		}
+		return &StepResult{Status: "completed", Output: map[string]any{"message": "ok"}}, nil
+	})
+
+	require.Len(t, step.Logs, maxStepLogEntries+1)
+	last := step.Logs[maxStepLogEntries]
+	assert.Equal(t, "WARN", last.Level)
+	assert.Equal(t, "5 more log entries dropped", last.Message)
+}
@@ -0,0 +1,226 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"workflow-code-test/api/pkg/auth"
+	"workflow-code-test/api/pkg/logging"
+)
+
+// TestCase is a workflow author's test, stored with the workflow. Its input runs as a dry
+// run, so nodes with external effects must be mocked or are simulated.
+type TestCase struct {
+	Name   string          `json:"name"`
+	Input  ExecuteRequest  `json:"input"`
+	Expect TestExpectation `json:"expect"`
+}
+
+// TestExpectation is what a TestCase asserts about its execution. Variables and outputs
+// are matched key by key, so a test names only the values it cares about.
+type TestExpectation struct {
+	Status    string                    `json:"status,omitempty"`    // "completed" (the default) or "failed"
+	Path      []string                  `json:"path,omitempty"`      // Every node id run, in order
+	Variables map[string]any            `json:"variables,omitempty"` // Final execution variables
+	Outputs   map[string]map[string]any `json:"outputs,omitempty"`   // Step outputs by node id
+}
+
+// TestResult is the outcome of one TestCase.
+type TestResult struct {
+	Name     string   `json:"name"`
+	Passed   bool     `json:"passed"`
+	Failures []string `json:"failures,omitempty"`
+	Status   string   `json:"status,omitempty"` // Status of the execution; empty if it could not start
+	Path     []string `json:"path"`
+}
+
+// TestReport is the outcome of running a workflow's test cases.
+type TestReport struct {
+	WorkflowID string       `json:"workflowId"`
+	Passed     int          `json:"passed"`
+	Failed     int          `json:"failed"`
+	Results    []TestResult `json:"results"`
+}
+
+// ValidateTestCases checks that wf's test cases have unique names and valid inputs, and
+// that every node they mock or expect exists.
+func ValidateTestCases(wf *Workflow) error {
+	nodes := make(map[string]bool, len(wf.Nodes))
+	for _, n := range wf.Nodes {
+		nodes[n.ID] = true
+	}
+	names := make(map[string]bool, len(wf.Tests))
+	for i, tc := range wf.Tests {
+		if strings.TrimSpace(tc.Name) == "" {
+			return fmt.Errorf("tests[%d]: name is required", i)
+		}
+		if names[tc.Name] {
+			return fmt.Errorf("test %q: name is not unique", tc.Name)
+		}
+		names[tc.Name] = true
+
+		if err := ValidateExecuteRequest(tc.Input); err != nil {
+			return fmt.Errorf("test %q: %w", tc.Name, err)
+		}
+		if tc.Input.Mode == ModeLive {
+			return fmt.Errorf("test %q: %w", tc.Name, errInvalid("mode"))
+		}
+		if err := ValidateMocks(wf, tc.Input.Mocks); err != nil {
+			return fmt.Errorf("test %q: %w", tc.Name, err)
+		}
+		if s := tc.Expect.Status; s != "" && s != "completed" && s != "failed" {
+			return fmt.Errorf("test %q: %w", tc.Name, errInvalid("expect.status"))
+		}
+		for _, id := range tc.Expect.Path {
+			if !nodes[id] {
+				return fmt.Errorf("test %q: expect.path: node %q not found", tc.Name, id)
+			}
+		}
+		for id := range tc.Expect.Outputs {
+			if !nodes[id] {
+				return fmt.Errorf("test %q: expect.outputs: node %q not found", tc.Name, id)
+			}
+		}
+	}
+	return nil
+}
+
+// RunTests executes each of cases against wf as a dry run and checks its expectations.
+// The cases are expected to have passed ValidateTestCases.
+func (e *Engine) RunTests(ctx context.Context, wf *Workflow, cases []TestCase) *TestReport {
+	report := &TestReport{WorkflowID: wf.ID, Results: make([]TestResult, 0, len(cases))}
+	for _, tc := range cases {
+		result := e.runTest(ctx, wf, tc)
+		if result.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+// HandleRunTests runs a workflow's stored test cases and reports the outcome of each.
+// Failing tests still return 200; the report says which failed and why.
+func (s *Service) HandleRunTests(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, err := uuid.Parse(id); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid workflow id")
+		return
+	}
+	if !s.authorize(w, r, auth.PermRun, id) {
+		return
+	}
+	ctx := logging.With(r.Context(), "workflowId", id)
+
+	wf, err := s.repo.Get(ctx, auth.Tenant(ctx), id)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get workflow for tests", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if wf == nil {
+		writeError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+
+	report := s.engine.RunTests(ctx, wf, wf.Tests)
+	logging.FromContext(ctx).Info("Ran workflow tests", "passed", report.Passed, "failed", report.Failed)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+func (e *Engine) runTest(ctx context.Context, wf *Workflow, tc TestCase) TestResult {
+	result := TestResult{Name: tc.Name, Path: []string{}}
+	input := tc.Input
+	input.Mode = ModeDryRun
+	state := NewExecutionState(input)
+	execution, err := e.Execute(ctx, wf, state)
+	if err != nil {
+		result.Failures = []string{fmt.Sprintf("execution: %v", err)}
+		return result
+	}
+
+	result.Status = execution.Status
+	outputs := make(map[string]map[string]any, len(execution.Steps))
+	var stepError string
+	for _, step := range execution.Steps {
+		result.Path = append(result.Path, step.NodeID)
+		outputs[step.NodeID] = step.Output
+		if step.Error != "" {
+			stepError = fmt.Sprintf(" (%s: %s)", step.NodeID, step.Error)
+		}
+	}
+
+	want := tc.Expect.Status
+	if want == "" {
+		want = "completed"
+	}
+	if execution.Status != want {
+		result.Failures = append(result.Failures, fmt.Sprintf("status: got %q, want %q%s", execution.Status, want, stepError))
+	}
+	if tc.Expect.Path != nil && !slices.Equal(result.Path, tc.Expect.Path) {
+		result.Failures = append(result.Failures, fmt.Sprintf("path: got %s, want %s",
+			strings.Join(result.Path, " → "), strings.Join(tc.Expect.Path, " → ")))
+	}
+	result.Failures = append(result.Failures, compareValues("variables", state.Variables, tc.Expect.Variables)...)
+	for _, id := range sortedKeys(tc.Expect.Outputs) {
+		output, ran := outputs[id]
+		if !ran {
+			result.Failures = append(result.Failures, fmt.Sprintf("outputs.%s: node did not run", id))
+			continue
+		}
+		result.Failures = append(result.Failures, compareValues("outputs."+id, output, tc.Expect.Outputs[id])...)
+	}
+	result.Passed = len(result.Failures) == 0
+	return result
+}
+
+// compareValues reports each key of want that got lacks or holds a different value.
+// Values are compared as JSON, so 40 matches 40.0.
+func compareValues(prefix string, got, want map[string]any) []string {
+	var failures []string
+	for _, key := range sortedKeys(want) {
+		v, ok := got[key]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("%s.%s: missing, want %s", prefix, key, toJSON(want[key])))
+			continue
+		}
+		if toJSON(v) != toJSON(want[key]) {
+			failures = append(failures, fmt.Sprintf("%s.%s: got %s, want %s", prefix, key, toJSON(v), toJSON(want[key])))
+		}
+	}
+	return failures
+}
+
+// toJSON renders v in canonical JSON: numbers in their shortest form and object keys sorted.
+func toJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	var normal any
+	if err := json.Unmarshal(b, &normal); err != nil {
+		return string(b)
+	}
+	b, _ = json.Marshal(normal)
+	return string(b)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,146 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func weatherTest(name string, temperature float64, expect TestExpectation) TestCase {
+	return TestCase{
+		Name: name,
+		Input: ExecuteRequest{
+			FormData:  map[string]any{"name": "Alice", "email": "alice@example.com", "city": "Sydney"},
+			Condition: ConditionInput{Operator: "greater_than", Threshold: 25},
+			Mocks:     map[string]map[string]any{"weather-api": {"temperature": temperature}},
+		},
+		Expect: expect,
+	}
+}
+
+func TestValidateTestCases(t *testing.T) {
+	valid := weatherTest("hot", 30, TestExpectation{Path: []string{"start", "end"}})
+	tests := []struct {
+		name   string
+		modify func(tc *TestCase)
+		want   string
+	}{
+		{"valid", func(*TestCase) {}, ""},
+		{"no name", func(tc *TestCase) { tc.Name = " " }, "tests[1]: name is required"},
+		{"duplicate name", func(tc *TestCase) { tc.Name = "hot" }, `test "hot": name is not unique`},
+		{"bad input", func(tc *TestCase) { tc.Input.Condition.Operator = "hotter" }, `test "t": operator is invalid`},
+		{"live mode", func(tc *TestCase) { tc.Input.Mode = ModeLive }, `test "t": mode is invalid`},
+		{"unknown mock", func(tc *TestCase) { tc.Input.Mocks = map[string]map[string]any{"sms": {}} }, `test "t": mocks: node "sms" not found`},
+		{"bad status", func(tc *TestCase) { tc.Expect.Status = "suspended" }, `test "t": expect.status is invalid`},
+		{"unknown output", func(tc *TestCase) { tc.Expect.Outputs = map[string]map[string]any{"sms": {}} }, `test "t": expect.outputs: node "sms" not found`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := weatherTest("t", 20, TestExpectation{})
+			tt.modify(&tc)
+			wf := testWorkflow()
+			wf.Tests = []TestCase{valid, tc}
+
+			err := ValidateTestCases(wf)
+			if tt.want == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+func TestEngine_RunTests(t *testing.T) {
+	engine := NewEngine(NewRegistry(&mockWeatherClient{temperature: 10}, nil, defaultInlineDelay))
+	cases := []TestCase{
+		weatherTest("hot day alerts", 30, TestExpectation{
+			Path:      []string{"start", "form", "weather-api", "condition", "email", "end"},
+			Variables: map[string]any{"temperature": 30, "conditionResult": "true"},
+			Outputs:   map[string]map[string]any{"email": {"emailSent": false}},
+		}),
+		weatherTest("mild day alerts", 20, TestExpectation{
+			Path:      []string{"start", "form", "weather-api", "condition", "email", "end"},
+			Variables: map[string]any{"conditionResult": "true", "windSpeed": 5},
+			Outputs:   map[string]map[string]any{"email": {"emailSent": false}},
+		}),
+	}
+
+	report := engine.RunTests(context.Background(), testWorkflow(), cases)
+
+	assert.Equal(t, "test-wf", report.WorkflowID)
+	assert.Equal(t, 1, report.Passed)
+	assert.Equal(t, 1, report.Failed)
+	require.Len(t, report.Results, 2)
+	assert.True(t, report.Results[0].Passed, "failures: %v", report.Results[0].Failures)
+	assert.Equal(t, "completed", report.Results[0].Status)
+
+	mild := report.Results[1]
+	assert.False(t, mild.Passed)
+	assert.Equal(t, []string{"start", "form", "weather-api", "condition", "end"}, mild.Path)
+	assert.Equal(t, []string{
+		"path: got start → form → weather-api → condition → end, want start → form → weather-api → condition → email → end",
+		`variables.conditionResult: got "false", want "true"`,
+		"variables.windSpeed: missing, want 5",
+		"outputs.email: node did not run",
+	}, mild.Failures)
+}
+
+func TestEngine_RunTestsReportsFailedSteps(t *testing.T) {
+	engine := NewEngine(NewRegistry(&mockWeatherClient{temperature: 30}, nil, defaultInlineDelay))
+	unmocked := weatherTest("forgot the mock", 0, TestExpectation{})
+	unmocked.Input.Mocks = nil
+	expectFailure := unmocked
+	expectFailure.Name = "expects the failure"
+	expectFailure.Expect.Status = "failed"
+
+	report := engine.RunTests(context.Background(), testWorkflow(), []TestCase{unmocked, expectFailure})
+
+	require.Len(t, report.Results, 2)
+	require.Len(t, report.Results[0].Failures, 1)
+	assert.Contains(t, report.Results[0].Failures[0], `status: got "failed", want "completed" (weather-api: dry runs do not call the weather API`)
+	assert.True(t, report.Results[1].Passed)
+}
+
+func TestHandleRunTests(t *testing.T) {
+	wf := testWorkflow()
+	wf.Tests = []TestCase{
+		weatherTest("hot day alerts", 30, TestExpectation{Outputs: map[string]map[string]any{"email": {"emailSent": false}}}),
+		weatherTest("mild day alerts", 20, TestExpectation{Outputs: map[string]map[string]any{"email": {"emailSent": false}}}),
+	}
+	router := setupRouter(newTestService(wf, 10))
+
+	w := serve(router, "POST", workflowPath+"/tests/run", "")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var report TestReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, 1, report.Passed)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, []string{"outputs.email: node did not run"}, report.Results[1].Failures)
+
+	router = setupRouter(newTestService(nil, 0))
+	assert.Equal(t, http.StatusNotFound, serve(router, "POST", workflowPath+"/tests/run", "").Code)
+}
+
+func TestHandleUpdateWorkflow_KeepsTestsWhenOmitted(t *testing.T) {
+	wf := testWorkflow()
+	wf.Tests = []TestCase{weatherTest("hot day alerts", 30, TestExpectation{})}
+	router := setupRouter(newTestService(wf, 0))
+
+	w := serve(router, "PUT", workflowPath, updateBody(t))
+	require.Equal(t, http.StatusOK, w.Code)
+	var result Workflow
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	require.Len(t, result.Tests, 1)
+
+	w = serve(router, "PUT", workflowPath, `{"name": "x", "nodes": [{"id": "a", "type": "start"}], "tests": []}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	result = Workflow{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Empty(t, result.Tests)
+}
@@ -0,0 +1,153 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"workflow-code-test/api/pkg/logging"
+)
+
+const (
+	dueExecutionBatch = 50
+	// resumeLease is how long a claimed execution is reserved for the replica resuming it.
+	// If that replica dies mid-resume, another picks the execution up after the lease expires.
+	resumeLease = 5 * time.Minute
+)
+
+// TimerStatus is a point-in-time view of the timer service for diagnostics.
+type TimerStatus struct {
+	Running  bool       `json:"running"`
+	LastTick *time.Time `json:"lastTick,omitempty"`
+}
+
+// TimerService resumes executions suspended by delay nodes once they are due.
+// Every replica runs one; claims are leased in the database, so no leader is needed.
+type TimerService struct {
+	store     ExecutionStore
+	workflows WorkflowRepo
+	engine    *Engine
+	interval  time.Duration
+	now       func() time.Time
+
+	mu     sync.Mutex
+	status TimerStatus
+}
+
+// NewTimerService creates a timer service for the service's executions that polls every interval.
+func NewTimerService(svc *Service, interval time.Duration) *TimerService {
+	return &TimerService{
+		store:     svc.executions,
+		workflows: svc.repo,
+		engine:    svc.engine,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// Run polls until ctx is cancelled.
+func (t *TimerService) Run(ctx context.Context) {
+	t.setStatus(func(st *TimerStatus) { st.Running = true })
+	defer t.setStatus(func(st *TimerStatus) { st.Running = false })
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		now := t.now()
+		t.setStatus(func(st *TimerStatus) { st.LastTick = &now })
+		t.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status returns the timer service's current state.
+func (t *TimerService) Status() TimerStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
+}
+
+// Check is a readiness check that fails unless the timer service is running.
+func (t *TimerService) Check(context.Context) error {
+	if !t.Status().Running {
+		return errors.New("timer service is not running")
+	}
+	return nil
+}
+
+func (t *TimerService) setStatus(fn func(*TimerStatus)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn(&t.status)
+}
+
+// tick resumes every execution that is due.
+func (t *TimerService) tick(ctx context.Context) {
+	now := t.now()
+	due, err := t.store.ClaimDueExecutions(ctx, now, now.Add(resumeLease), dueExecutionBatch)
+	if err != nil {
+		slog.Error("Failed to claim due executions", "error", err)
+		return
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		t.resume(ctx, &due[i])
+	}
+}
+
+// resume continues one execution. Executions that cannot be resumed are marked failed
+// rather than retried forever.
+func (t *TimerService) resume(ctx context.Context, exec *SuspendedExecution) {
+	ctx = logging.With(ctx, "workflowId", exec.WorkflowID)
+	log := logging.FromContext(ctx).With("executionId", exec.ExecutionID)
+	wf, err := t.workflows.Get(ctx, exec.TenantID, exec.WorkflowID)
+	if err != nil {
+		// Leave it claimed; it is retried when the lease expires
+		log.Error("Failed to get workflow for resumed execution", "error", err)
+		return
+	}
+	if wf == nil {
+		log.Warn("Suspended execution's workflow no longer exists")
+		t.fail(ctx, exec, "workflow no longer exists")
+		return
+	}
+
+	log.Info("Resuming execution")
+	results, err := t.engine.Resume(ctx, wf, exec)
+	if err != nil {
+		log.Error("Resumed execution failed", "error", err)
+		t.fail(ctx, exec, err.Error())
+		return
+	}
+	log.Info("Resumed execution finished", "status", results.Status)
+}
+
+// fail records a suspended execution as failed with the given reason.
+func (t *TimerService) fail(ctx context.Context, exec *SuspendedExecution, reason string) {
+	now := t.now()
+	results := exec.Results
+	start, _ := time.Parse(time.RFC3339, results.StartTime)
+	results.Status = "failed"
+	results.ResumeAt = nil
+	results.EndTime = now.UTC().Format(time.RFC3339)
+	results.TotalDuration = now.Sub(start).Milliseconds()
+	if results.Metadata == nil {
+		results.Metadata = map[string]any{}
+	}
+	results.Metadata["error"] = reason
+
+	if err := t.store.FinishExecution(ctx, &results); err != nil {
+		slog.Error("Failed to record failed execution", "executionId", exec.ExecutionID, "error", err)
+	}
+}
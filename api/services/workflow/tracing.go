@@ -0,0 +1,57 @@
+package workflow
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer records execution and node spans. It follows the global tracer provider, so
+// spans are dropped unless tracing.Setup installed an exporter.
+var tracer = otel.Tracer("workflow-code-test/api/services/workflow")
+
+// startExecutionSpan starts the span covering one run of an execution, named
+// "workflow.execute" or "workflow.resume".
+func startExecutionSpan(ctx context.Context, name string, wf *Workflow, executionID string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(
+		attribute.String("workflow.id", wf.ID),
+		attribute.String("workflow.tenant", wf.TenantID),
+		attribute.String("workflow.execution_id", executionID),
+	))
+}
+
+// endExecutionSpan records the execution's outcome and ends span.
+func endExecutionSpan(span trace.Span, results *ExecutionResults, err error) {
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case results != nil:
+		span.SetAttributes(attribute.String("workflow.status", results.Status), attribute.Int("workflow.steps", len(results.Steps)))
+		if results.Status == "failed" {
+			span.SetStatus(codes.Error, "execution failed")
+		}
+	}
+	span.End()
+}
+
+// startNodeSpan starts the span covering one NodeExecutor.Execute call.
+func startNodeSpan(ctx context.Context, node *Node, stepNum int) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "node.execute "+node.Type, trace.WithAttributes(
+		attribute.String("node.id", node.ID),
+		attribute.String("node.type", node.Type),
+		attribute.Int("workflow.step", stepNum),
+	))
+}
+
+// endNodeSpan records the step's outcome and ends span. Errors are recorded already redacted.
+func endNodeSpan(span trace.Span, status, errMessage string) {
+	span.SetAttributes(attribute.String("node.status", status))
+	if errMessage != "" {
+		span.SetStatus(codes.Error, errMessage)
+	}
+	span.End()
+}
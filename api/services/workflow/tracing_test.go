@@ -0,0 +1,95 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	recorderOnce sync.Once
+	recorder     *tracetest.SpanRecorder
+)
+
+// spanRecorder installs a global tracer provider that records spans. The package tracer
+// binds to the first provider installed, so every test shares one recorder.
+func spanRecorder() *tracetest.SpanRecorder {
+	recorderOnce.Do(func() {
+		recorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	})
+	return recorder
+}
+
+// executionSpans returns the execution span recorded for executionID and its child spans.
+func executionSpans(t *testing.T, executionID string) (sdktrace.ReadOnlySpan, []sdktrace.ReadOnlySpan) {
+	t.Helper()
+	var root sdktrace.ReadOnlySpan
+	for _, s := range spanRecorder().Ended() {
+		for _, kv := range s.Attributes() {
+			if kv.Key == "workflow.execution_id" && kv.Value.AsString() == executionID {
+				root = s
+			}
+		}
+	}
+	require.NotNil(t, root, "no span for execution %s", executionID)
+	var children []sdktrace.ReadOnlySpan
+	for _, s := range spanRecorder().Ended() {
+		if s.Parent().SpanID() == root.SpanContext().SpanID() {
+			children = append(children, s)
+		}
+	}
+	return root, children
+}
+
+func attr(s sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, kv := range s.Attributes() {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestEngine_TracesExecutionAndNodes(t *testing.T) {
+	spanRecorder()
+	engine := NewEngine(NewRegistry(&mockWeatherClient{temperature: 30}, nil, defaultInlineDelay))
+
+	results, err := engine.Execute(context.Background(), testWorkflow(), metricsState())
+	require.NoError(t, err)
+
+	root, nodes := executionSpans(t, results.ExecutionID)
+	assert.Equal(t, "workflow.execute", root.Name())
+	assert.Equal(t, "test-wf", attr(root, "workflow.id"))
+	assert.Equal(t, "completed", attr(root, "workflow.status"))
+	require.Len(t, nodes, len(results.Steps))
+	for i, step := range results.Steps {
+		assert.Equal(t, "node.execute "+step.NodeType, nodes[i].Name())
+		assert.Equal(t, step.NodeID, attr(nodes[i], "node.id"))
+		assert.Equal(t, step.NodeType, attr(nodes[i], "node.type"))
+	}
+}
+
+func TestEngine_TracesFailedNode(t *testing.T) {
+	spanRecorder()
+	engine := NewEngine(NewRegistry(&mockWeatherClient{err: fmt.Errorf("API timeout")}, nil, defaultInlineDelay))
+
+	results, err := engine.Execute(context.Background(), testWorkflow(), metricsState())
+	require.NoError(t, err)
+
+	root, nodes := executionSpans(t, results.ExecutionID)
+	assert.Equal(t, codes.Error, root.Status().Code)
+	failed := nodes[len(nodes)-1]
+	assert.Equal(t, "weather-api", attr(failed, "node.id"))
+	assert.Equal(t, codes.Error, failed.Status().Code)
+	assert.Contains(t, failed.Status().Description, "API timeout")
+}
@@ -0,0 +1,88 @@
+package workflow
+
+import "strings"
+
+// TemperatureUnit is the unit temperatures are entered and displayed in for an execution.
+// Weather data and temperature variables are always stored in Celsius; the unit only
+// affects thresholds, comparisons, messages and templates. The zero value means Celsius.
+type TemperatureUnit string
+
+const (
+	Celsius    TemperatureUnit = "celsius"
+	Fahrenheit TemperatureUnit = "fahrenheit"
+	Kelvin     TemperatureUnit = "kelvin"
+)
+
+// ParseTemperatureUnit accepts a unit name or its initial, case-insensitively.
+func ParseTemperatureUnit(s string) (TemperatureUnit, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "c", "celsius":
+		return Celsius, true
+	case "f", "fahrenheit":
+		return Fahrenheit, true
+	case "k", "kelvin":
+		return Kelvin, true
+	default:
+		return "", false
+	}
+}
+
+// fahrenheitRegions are the ISO 3166-1 regions that conventionally use Fahrenheit.
+var fahrenheitRegions = map[string]bool{
+	"US": true, "LR": true, "MM": true, "BS": true, "KY": true, "PW": true, "FM": true, "MH": true,
+}
+
+// UnitForLocale picks the conventional unit for a BCP 47 locale such as "en-US".
+// Locales without a Fahrenheit region, and unparseable ones, get Celsius.
+func UnitForLocale(locale string) TemperatureUnit {
+	parts := strings.FieldsFunc(locale, func(r rune) bool { return r == '-' || r == '_' })
+	for _, p := range parts[min(1, len(parts)):] {
+		if len(p) == 2 && fahrenheitRegions[strings.ToUpper(p)] {
+			return Fahrenheit
+		}
+	}
+	return Celsius
+}
+
+// FromCelsius converts a canonical Celsius value into u.
+func (u TemperatureUnit) FromCelsius(c float64) float64 {
+	switch u {
+	case Fahrenheit:
+		return c*9/5 + 32
+	case Kelvin:
+		return c + 273.15
+	default:
+		return c
+	}
+}
+
+// ToCelsius converts a value expressed in u into Celsius.
+func (u TemperatureUnit) ToCelsius(v float64) float64 {
+	switch u {
+	case Fahrenheit:
+		return (v - 32) * 5 / 9
+	case Kelvin:
+		return v - 273.15
+	default:
+		return v
+	}
+}
+
+// Symbol returns the display suffix for u, e.g. "°F".
+func (u TemperatureUnit) Symbol() string {
+	switch u {
+	case Fahrenheit:
+		return "°F"
+	case Kelvin:
+		return "K"
+	default:
+		return "°C"
+	}
+}
+
+// isTemperatureVariable reports whether a variable path refers to a temperature,
+// judged by its final segment (e.g. "temperature", "weather.daily.1.temperatureMax").
+func isTemperatureVariable(path string) bool {
+	last := path[strings.LastIndex(path, ".")+1:]
+	return strings.Contains(strings.ToLower(last), "temperature")
+}
@@ -0,0 +1,34 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemperatureUnit_Conversions(t *testing.T) {
+	assert.InDelta(t, 95.0, Fahrenheit.FromCelsius(35), 1e-9)
+	assert.InDelta(t, 35.0, Fahrenheit.ToCelsius(95), 1e-9)
+	assert.InDelta(t, 308.15, Kelvin.FromCelsius(35), 1e-9)
+	assert.InDelta(t, 35.0, Kelvin.ToCelsius(308.15), 1e-9)
+	assert.Equal(t, 35.0, TemperatureUnit("").FromCelsius(35))
+	assert.Equal(t, "°C", TemperatureUnit("").Symbol())
+}
+
+func TestParseTemperatureUnit(t *testing.T) {
+	for in, want := range map[string]TemperatureUnit{"F": Fahrenheit, "fahrenheit": Fahrenheit, " Kelvin ": Kelvin, "c": Celsius} {
+		got, ok := ParseTemperatureUnit(in)
+		assert.True(t, ok, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, ok := ParseTemperatureUnit("rankine")
+	assert.False(t, ok)
+}
+
+func TestUnitForLocale(t *testing.T) {
+	assert.Equal(t, Fahrenheit, UnitForLocale("en-US"))
+	assert.Equal(t, Fahrenheit, UnitForLocale("es_US"))
+	assert.Equal(t, Celsius, UnitForLocale("en-AU"))
+	assert.Equal(t, Celsius, UnitForLocale("en"))
+	assert.Equal(t, Celsius, UnitForLocale(""))
+}
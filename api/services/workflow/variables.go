@@ -0,0 +1,52 @@
+package workflow
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// lookupVariable resolves a dotted path such as "weather.daily.1.precipitationSum"
+// against the execution variables.
+func lookupVariable(vars map[string]any, path string) (any, bool) {
+	return lookupPath(vars, path)
+}
+
+// lookupPath resolves a dotted path against a decoded JSON value. Path segments index
+// into maps by key and into slices by position.
+func lookupPath(root any, path string) (any, bool) {
+	cur := root
+	for _, seg := range strings.Split(path, ".") {
+		switch v := cur.(type) {
+		case map[string]any:
+			next, ok := v[seg]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			cur = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// toVariable normalises a struct value into the map/slice form that lookupVariable
+// traverses, using its JSON field names.
+func toVariable(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil
+	}
+	return out
+}
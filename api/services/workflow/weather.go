@@ -5,62 +5,235 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"time"
+
+	"workflow-code-test/api/pkg/resilience"
+	"workflow-code-test/api/pkg/tracing"
 )
 
-// WeatherClient fetches current temperature for geographic coordinates.
+// WeatherClient fetches current conditions and forecasts for geographic coordinates.
 type WeatherClient interface {
-	GetTemperature(ctx context.Context, lat, lon float64) (float64, error)
+	GetWeather(ctx context.Context, lat, lon float64, forecastDays int) (*WeatherReport, error)
+}
+
+// WeatherReport bundles the current observation with hourly and daily forecasts.
+// Forecast slices cover forecastDays days starting today, in the location's local time.
+type WeatherReport struct {
+	Current WeatherObservation `json:"current"`
+	Hourly  []HourlyForecast   `json:"hourly"`
+	Daily   []DailyForecast    `json:"daily"`
+}
+
+// inUnit returns a copy of r with every temperature converted from °C into u.
+func (r WeatherReport) inUnit(u TemperatureUnit) WeatherReport {
+	r.Current.Temperature = u.FromCelsius(r.Current.Temperature)
+	r.Current.ApparentTemperature = u.FromCelsius(r.Current.ApparentTemperature)
+	r.Hourly = slices.Clone(r.Hourly)
+	for i := range r.Hourly {
+		r.Hourly[i].Temperature = u.FromCelsius(r.Hourly[i].Temperature)
+	}
+	r.Daily = slices.Clone(r.Daily)
+	for i := range r.Daily {
+		r.Daily[i].TemperatureMax = u.FromCelsius(r.Daily[i].TemperatureMax)
+		r.Daily[i].TemperatureMin = u.FromCelsius(r.Daily[i].TemperatureMin)
+	}
+	return r
+}
+
+// WeatherObservation is the current weather at a location.
+type WeatherObservation struct {
+	Time                string  `json:"time"`
+	Temperature         float64 `json:"temperature"`         // °C
+	ApparentTemperature float64 `json:"apparentTemperature"` // °C
+	WindSpeed           float64 `json:"windSpeed"`           // km/h
+	Precipitation       float64 `json:"precipitation"`       // mm
+	Humidity            float64 `json:"humidity"`            // %
+	WeatherCode         int     `json:"weatherCode"`         // WMO weather interpretation code
+}
+
+// HourlyForecast is the forecast for a single hour.
+type HourlyForecast struct {
+	Time                     string  `json:"time"`
+	Temperature              float64 `json:"temperature"`
+	PrecipitationProbability float64 `json:"precipitationProbability"`
+	Precipitation            float64 `json:"precipitation"`
+	WindSpeed                float64 `json:"windSpeed"`
+	WeatherCode              int     `json:"weatherCode"`
+}
+
+// DailyForecast is the forecast for a single day.
+type DailyForecast struct {
+	Date                     string  `json:"date"`
+	TemperatureMax           float64 `json:"temperatureMax"`
+	TemperatureMin           float64 `json:"temperatureMin"`
+	PrecipitationSum         float64 `json:"precipitationSum"`
+	PrecipitationProbability float64 `json:"precipitationProbability"`
+	WindSpeedMax             float64 `json:"windSpeedMax"`
+	WeatherCode              int     `json:"weatherCode"`
 }
 
 // OpenMeteoClient calls the Open-Meteo public weather API.
 type OpenMeteoClient struct {
 	httpClient *http.Client
+	baseURL    string
 }
 
-// NewOpenMeteoClient returns a client with a 10-second timeout.
-func NewOpenMeteoClient() *OpenMeteoClient {
+// DefaultWeatherURL is the Open-Meteo forecast endpoint.
+const DefaultWeatherURL = "https://api.open-meteo.com/v1/forecast"
+
+// NewOpenMeteoClient returns a client for the forecast API at baseURL, giving up on
+// requests after timeout.
+func NewOpenMeteoClient(baseURL string, timeout time.Duration) *OpenMeteoClient {
 	return &OpenMeteoClient{
-		httpClient: &http.Client{Timeout: 10 * time.Second},
+		httpClient: &http.Client{Timeout: timeout, Transport: tracing.Transport(nil)},
+		baseURL:    baseURL,
 	}
 }
 
+const (
+	openMeteoCurrent = "temperature_2m,apparent_temperature,relative_humidity_2m,precipitation,weather_code,wind_speed_10m"
+	openMeteoHourly  = "temperature_2m,precipitation_probability,precipitation,weather_code,wind_speed_10m"
+	openMeteoDaily   = "weather_code,temperature_2m_max,temperature_2m_min,precipitation_sum,precipitation_probability_max,wind_speed_10m_max"
+)
+
 // openMeteoResponse is the relevant subset of the Open-Meteo API response.
+// Forecast series are parallel arrays indexed by time.
 type openMeteoResponse struct {
-	CurrentWeather *struct {
-		Temperature float64 `json:"temperature"`
-	} `json:"current_weather"`
+	Current *struct {
+		Time                string  `json:"time"`
+		Temperature         float64 `json:"temperature_2m"`
+		ApparentTemperature float64 `json:"apparent_temperature"`
+		Humidity            float64 `json:"relative_humidity_2m"`
+		Precipitation       float64 `json:"precipitation"`
+		WeatherCode         int     `json:"weather_code"`
+		WindSpeed           float64 `json:"wind_speed_10m"`
+	} `json:"current"`
+	Hourly struct {
+		Time                     []string  `json:"time"`
+		Temperature              []float64 `json:"temperature_2m"`
+		PrecipitationProbability []float64 `json:"precipitation_probability"`
+		Precipitation            []float64 `json:"precipitation"`
+		WeatherCode              []int     `json:"weather_code"`
+		WindSpeed                []float64 `json:"wind_speed_10m"`
+	} `json:"hourly"`
+	Daily struct {
+		Time                     []string  `json:"time"`
+		WeatherCode              []int     `json:"weather_code"`
+		TemperatureMax           []float64 `json:"temperature_2m_max"`
+		TemperatureMin           []float64 `json:"temperature_2m_min"`
+		PrecipitationSum         []float64 `json:"precipitation_sum"`
+		PrecipitationProbability []float64 `json:"precipitation_probability_max"`
+		WindSpeedMax             []float64 `json:"wind_speed_10m_max"`
+	} `json:"daily"`
 }
 
-// GetTemperature fetches the current temperature in Celsius for the given coordinates.
-func (c *OpenMeteoClient) GetTemperature(ctx context.Context, lat, lon float64) (float64, error) {
-	url := fmt.Sprintf(
-		"https://api.open-meteo.com/v1/forecast?latitude=%.4f&longitude=%.4f&current_weather=true",
-		lat, lon,
-	)
+// GetWeather fetches current conditions and a forecastDays-day forecast for the given coordinates.
+func (c *OpenMeteoClient) GetWeather(ctx context.Context, lat, lon float64, forecastDays int) (*WeatherReport, error) {
+	q := url.Values{}
+	q.Set("latitude", strconv.FormatFloat(lat, 'f', 4, 64))
+	q.Set("longitude", strconv.FormatFloat(lon, 'f', 4, 64))
+	q.Set("current", openMeteoCurrent)
+	q.Set("timezone", "auto")
+	if forecastDays > 0 {
+		q.Set("hourly", openMeteoHourly)
+		q.Set("daily", openMeteoDaily)
+		q.Set("forecast_days", strconv.Itoa(forecastDays))
+	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"?"+q.Encode(), nil)
 	if err != nil {
-		return 0, fmt.Errorf("create request: %w", err)
+		return nil, fmt.Errorf("create request: %w", err)
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return 0, fmt.Errorf("weather API request failed: %w", err)
+		return nil, fmt.Errorf("weather API request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("weather API returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("weather API returned status %d", resp.StatusCode)
 	}
 
 	var result openMeteoResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, fmt.Errorf("decode weather response: %w", err)
+		return nil, fmt.Errorf("decode weather response: %w", err)
+	}
+	if result.Current == nil {
+		return nil, fmt.Errorf("weather API response missing current data")
+	}
+
+	report := &WeatherReport{
+		Current: WeatherObservation{
+			Time:                result.Current.Time,
+			Temperature:         result.Current.Temperature,
+			ApparentTemperature: result.Current.ApparentTemperature,
+			WindSpeed:           result.Current.WindSpeed,
+			Precipitation:       result.Current.Precipitation,
+			Humidity:            result.Current.Humidity,
+			WeatherCode:         result.Current.WeatherCode,
+		},
+	}
+
+	h := result.Hourly
+	for i, t := range h.Time {
+		report.Hourly = append(report.Hourly, HourlyForecast{
+			Time:                     t,
+			Temperature:              at(h.Temperature, i),
+			PrecipitationProbability: at(h.PrecipitationProbability, i),
+			Precipitation:            at(h.Precipitation, i),
+			WindSpeed:                at(h.WindSpeed, i),
+			WeatherCode:              at(h.WeatherCode, i),
+		})
+	}
+
+	d := result.Daily
+	for i, t := range d.Time {
+		report.Daily = append(report.Daily, DailyForecast{
+			Date:                     t,
+			TemperatureMax:           at(d.TemperatureMax, i),
+			TemperatureMin:           at(d.TemperatureMin, i),
+			PrecipitationSum:         at(d.PrecipitationSum, i),
+			PrecipitationProbability: at(d.PrecipitationProbability, i),
+			WindSpeedMax:             at(d.WindSpeedMax, i),
+			WeatherCode:              at(d.WeatherCode, i),
+		})
 	}
-	if result.CurrentWeather == nil {
-		return 0, fmt.Errorf("weather API response missing current_weather data")
+
+	return report, nil
+}
+
+// at returns s[i], or the zero value if the series is shorter than the time axis.
+func at[T any](s []T, i int) T {
+	var zero T
+	if i < len(s) {
+		return s[i]
 	}
+	return zero
+}
+
+// GuardedWeatherClient wraps a WeatherClient with a rate limiter and circuit breaker
+// so that provider outages fail fast instead of holding every execution for the full timeout.
+type GuardedWeatherClient struct {
+	client WeatherClient
+	guard  *resilience.Guard
+}
+
+// NewGuardedWeatherClient wraps client with the given guard.
+func NewGuardedWeatherClient(client WeatherClient, guard *resilience.Guard) *GuardedWeatherClient {
+	return &GuardedWeatherClient{client: client, guard: guard}
+}
 
-	return result.CurrentWeather.Temperature, nil
+// GetWeather delegates to the wrapped client under the guard.
+func (c *GuardedWeatherClient) GetWeather(ctx context.Context, lat, lon float64, forecastDays int) (*WeatherReport, error) {
+	var report *WeatherReport
+	err := c.guard.Do(ctx, func(ctx context.Context) error {
+		var err error
+		report, err = c.client.GetWeather(ctx, lat, lon, forecastDays)
+		return err
+	})
+	return report, err
 }
@@ -0,0 +1,85 @@
+package workflow
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const openMeteoFixture = `{
+	"current": {"time": "2026-02-16T07:00", "temperature_2m": 31.4, "apparent_temperature": 33.0,
+		"relative_humidity_2m": 48, "precipitation": 0.0, "weather_code": 1, "wind_speed_10m": 12.5},
+	"hourly": {"time": ["2026-02-16T00:00", "2026-02-16T01:00"], "temperature_2m": [24.1, 23.8],
+		"precipitation_probability": [5, null], "precipitation": [0, 0.2], "weather_code": [0, 61],
+		"wind_speed_10m": [8.1, 9.0]},
+	"daily": {"time": ["2026-02-16", "2026-02-17"], "weather_code": [1, 63],
+		"temperature_2m_max": [33.2, 27.0], "temperature_2m_min": [22.0, 19.5],
+		"precipitation_sum": [0, 6.4], "precipitation_probability_max": [10, 85],
+		"wind_speed_10m_max": [20.1, 31.7]}
+}`
+
+func TestOpenMeteoClient_GetWeather(t *testing.T) {
+	var gotQuery map[string][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+		w.Write([]byte(openMeteoFixture))
+	}))
+	defer srv.Close()
+
+	client := NewOpenMeteoClient(srv.URL, time.Second)
+
+	report, err := client.GetWeather(context.Background(), -33.8688, 151.2093, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2"}, gotQuery["forecast_days"])
+	assert.Equal(t, 31.4, report.Current.Temperature)
+	assert.Equal(t, 33.0, report.Current.ApparentTemperature)
+	assert.Equal(t, 48.0, report.Current.Humidity)
+	assert.Equal(t, 1, report.Current.WeatherCode)
+
+	require.Len(t, report.Hourly, 2)
+	assert.Equal(t, 61, report.Hourly[1].WeatherCode)
+	assert.Equal(t, 0.0, report.Hourly[1].PrecipitationProbability)
+
+	require.Len(t, report.Daily, 2)
+	assert.Equal(t, "2026-02-17", report.Daily[1].Date)
+	assert.Equal(t, 6.4, report.Daily[1].PrecipitationSum)
+	assert.Equal(t, 85.0, report.Daily[1].PrecipitationProbability)
+}
+
+func TestOpenMeteoClient_CurrentOnly(t *testing.T) {
+	var gotQuery map[string][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+		w.Write([]byte(`{"current": {"temperature_2m": 18.0}}`))
+	}))
+	defer srv.Close()
+
+	client := NewOpenMeteoClient(srv.URL, time.Second)
+
+	report, err := client.GetWeather(context.Background(), 0, 0, 0)
+
+	require.NoError(t, err)
+	assert.NotContains(t, gotQuery, "daily")
+	assert.Equal(t, 18.0, report.Current.Temperature)
+	assert.Empty(t, report.Daily)
+}
+
+func TestOpenMeteoClient_BadStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := NewOpenMeteoClient(srv.URL, time.Second)
+
+	_, err := client.GetWeather(context.Background(), 0, 0, 1)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+}
@@ -0,0 +1,249 @@
+package workflow
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"workflow-code-test/api/pkg/logging"
+)
+
+const (
+	defaultSignatureHeader = "X-Signature-256"
+	maxWebhookBody         = 1 << 20 // 1 MiB
+)
+
+// webhookTokenPattern is the form of a trigger token. Without a secret the token is all
+// that authenticates a webhook, so it must be long enough not to be guessed.
+var webhookTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{16,128}$`)
+
+// WebhookTrigger is the "trigger" metadata of a start node that can be started by
+// POST /hooks/{token}. Mapping takes form field names to dotted paths in the payload,
+// e.g. {"city": "location.name"}; the whole payload is also available as the
+// "payload" variable.
+type WebhookTrigger struct {
+	Type            string            `json:"type"` // "webhook"
+	Token           string            `json:"token"`
+	Secret          string            `json:"secret,omitempty"`          // A "{{secret.NAME}}" reference; enables HMAC-SHA256 verification when set
+	SignatureHeader string            `json:"signatureHeader,omitempty"` // Defaults to X-Signature-256
+	Mapping         map[string]string `json:"mapping,omitempty"`
+	Condition       ConditionInput    `json:"condition"`
+	Unit            string            `json:"unit,omitempty"`
+}
+
+// webhookTrigger returns the webhook trigger configured on the workflow's start node, if any.
+func webhookTrigger(wf *Workflow) (*WebhookTrigger, bool) {
+	start, err := findStartNode(wf.Nodes)
+	if err != nil {
+		return nil, false
+	}
+	raw, ok := start.Data.Metadata["trigger"]
+	if !ok {
+		return nil, false
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, false
+	}
+	var trigger WebhookTrigger
+	if err := json.Unmarshal(data, &trigger); err != nil || trigger.Type != "webhook" || trigger.Token == "" {
+		return nil, false
+	}
+	return &trigger, true
+}
+
+// triggerMetadata returns the raw "trigger" metadata of the start node among nodes.
+func triggerMetadata(nodes []Node) (any, bool) {
+	start, err := findStartNode(nodes)
+	if err != nil {
+		return nil, false
+	}
+	raw, ok := start.Data.Metadata["trigger"]
+	return raw, ok && raw != nil
+}
+
+// validateTrigger checks the trigger on the start node among nodes, if there is one,
+// against the rules HandleWebhook relies on.
+func validateTrigger(nodes []Node) error {
+	raw, ok := triggerMetadata(nodes)
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return errInvalid("trigger")
+	}
+	var trigger WebhookTrigger
+	if err := json.Unmarshal(data, &trigger); err != nil {
+		return errInvalid("trigger")
+	}
+	if trigger.Type != "webhook" {
+		return errInvalid("trigger.type")
+	}
+	if !webhookTokenPattern.MatchString(trigger.Token) {
+		return errInvalid("trigger.token")
+	}
+	// Node metadata is readable by viewers, so the secret itself is never stored in it
+	if trigger.Secret != "" && secretRefPattern.FindString(trigger.Secret) != trigger.Secret {
+		return errInvalid("trigger.secret")
+	}
+	if !validOperators[trigger.Condition.Operator] {
+		return errInvalid("trigger.condition")
+	}
+	if _, ok := ParseTemperatureUnit(trigger.Unit); trigger.Unit != "" && !ok {
+		return errInvalid("trigger.unit")
+	}
+	return nil
+}
+
+// withoutTriggerCredentials returns wf with its webhook trigger's token and secret
+// replaced by redactedValue, for callers who may view the workflow but not edit it.
+// wf itself is left unchanged.
+func withoutTriggerCredentials(wf *Workflow) *Workflow {
+	raw, _ := triggerMetadata(wf.Nodes)
+	trigger, ok := raw.(map[string]any)
+	if !ok {
+		return wf
+	}
+	trigger = maps.Clone(trigger)
+	for _, key := range []string{"token", "secret"} {
+		if v, ok := trigger[key]; ok && v != "" {
+			trigger[key] = redactedValue
+		}
+	}
+
+	redacted := *wf
+	redacted.Nodes = slices.Clone(wf.Nodes)
+	for i := range redacted.Nodes {
+		if redacted.Nodes[i].Type == "start" {
+			metadata := maps.Clone(redacted.Nodes[i].Data.Metadata)
+			metadata["trigger"] = trigger
+			redacted.Nodes[i].Data.Metadata = metadata
+			break
+		}
+	}
+	return &redacted
+}
+
+// verifySignature checks a hex HMAC-SHA256 of body, optionally prefixed with "sha256=".
+func verifySignature(secret string, body []byte, signature string) bool {
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// mapPayload builds form data from the payload according to the trigger's mapping.
+// Mapped values that are not strings are formatted with fmt.
+func mapPayload(payload any, mapping map[string]string) map[string]any {
+	formData := make(map[string]any, len(mapping))
+	for field, path := range mapping {
+		v, ok := lookupPath(payload, path)
+		if !ok || v == nil {
+			continue
+		}
+		if s, ok := v.(string); ok {
+			formData[field] = s
+		} else {
+			formData[field] = fmt.Sprint(v)
+		}
+	}
+	return formData
+}
+
+// HandleWebhook starts an execution of the workflow whose start node has a webhook
+// trigger with the given token. The request body may be any JSON document.
+func (s *Service) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	token := mux.Vars(r)["token"]
+	if !webhookTokenPattern.MatchString(token) {
+		writeError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+
+	wf, err := s.repo.FindByWebhookToken(r.Context(), token)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to look up webhook", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if wf == nil {
+		writeError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+	trigger, ok := webhookTrigger(wf)
+	if !ok || trigger.Token != token {
+		writeError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+	ctx := logging.With(r.Context(), "workflowId", wf.ID)
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
+
+	if trigger.Secret != "" {
+		secret, err := s.engine.resolveSecretRef(ctx, wf.TenantID, trigger.Secret)
+		if err != nil {
+			logging.FromContext(ctx).Error("Failed to resolve webhook secret", "error", err)
+			writeError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		header := trigger.SignatureHeader
+		if header == "" {
+			header = defaultSignatureHeader
+		}
+		if !verifySignature(secret, body, r.Header.Get(header)) {
+			writeError(w, http.StatusUnauthorized, "invalid signature")
+			return
+		}
+	}
+
+	var payload any
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &payload); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	unit, ok := ParseTemperatureUnit(trigger.Unit)
+	if !ok {
+		unit = Celsius
+	}
+	state := &ExecutionState{
+		FormData:  mapPayload(payload, trigger.Mapping),
+		Condition: trigger.Condition,
+		Unit:      unit,
+		Variables: map[string]any{"payload": payload},
+	}
+	logging.FromContext(ctx).Debug("Executing workflow from webhook")
+
+	results, err := s.engine.Execute(ctx, wf, state)
+	if err != nil {
+		logging.FromContext(ctx).Error("Workflow execution failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	results.Metadata = ExecutionMetadata(state)
+	results.Metadata["trigger"] = "webhook"
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(results)
+}
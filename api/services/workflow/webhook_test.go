@@ -0,0 +1,161 @@
+package workflow
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"workflow-code-test/api/pkg/auth"
+)
+
+func webhookWorkflow(secret string) *Workflow {
+	wf := testWorkflow()
+	wf.Nodes[0].Data.Metadata = map[string]any{
+		"trigger": map[string]any{
+			"type":   "webhook",
+			"token":  "tok-0123456789abcdef",
+			"secret": secret,
+			"mapping": map[string]any{
+				"name":  "alert.owner",
+				"email": "alert.contact",
+				"city":  "alert.site.city",
+			},
+			"condition": map[string]any{"operator": "greater_than", "threshold": 25},
+		},
+	}
+	return wf
+}
+
+const monitoringPayload = `{"alert": {"owner": "Ops", "contact": "ops@example.com", "site": {"city": "Sydney"}, "severity": 3}}`
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHandleWebhook_MapsPayloadAndExecutes(t *testing.T) {
+	svc := newTestService(webhookWorkflow(""), 30.0)
+	router := setupRouter(svc)
+
+	req := httptest.NewRequest("POST", "/api/v1/hooks/tok-0123456789abcdef", bytes.NewReader([]byte(monitoringPayload)))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var result ExecutionResults
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.Equal(t, "completed", result.Status)
+	assert.Equal(t, "webhook", result.Metadata["trigger"])
+	require.Len(t, result.Steps, 6)
+	assert.Contains(t, result.Steps[1].Output["message"], "Ops")
+}
+
+func TestHandleWebhook_Signature(t *testing.T) {
+	svc := newTestService(webhookWorkflow("{{secret.HOOK_KEY}}"), 30.0)
+	svc.engine.secrets = mapSecrets{"default/HOOK_KEY": "s3cret"}
+	router := setupRouter(svc)
+
+	tests := []struct {
+		name      string
+		signature string
+		want      int
+	}{
+		{"valid", sign("s3cret", monitoringPayload), http.StatusOK},
+		{"wrong secret", sign("other", monitoringPayload), http.StatusUnauthorized},
+		{"missing", "", http.StatusUnauthorized},
+		{"not hex", "sha256=zz", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/hooks/tok-0123456789abcdef", bytes.NewReader([]byte(monitoringPayload)))
+			req.Header.Set("X-Signature-256", tt.signature)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestHandleWebhook_NotFound(t *testing.T) {
+	svc := newTestService(testWorkflow(), 30.0) // start node has no trigger
+	router := setupRouter(svc)
+
+	req := httptest.NewRequest("POST", "/api/v1/hooks/tok-0123456789abcdef", bytes.NewReader([]byte(`{}`)))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandleWebhook_InvalidJSON(t *testing.T) {
+	svc := newTestService(webhookWorkflow(""), 30.0)
+	router := setupRouter(svc)
+
+	req := httptest.NewRequest("POST", "/api/v1/hooks/tok-0123456789abcdef", bytes.NewReader([]byte("not json")))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleUpdateWorkflow_RejectsTakenWebhookToken(t *testing.T) {
+	svc := newTestService(testWorkflow(), 30.0)
+	svc.repo.(*stubRepo).usedTokens = map[string]bool{"tok-0123456789abcdef": true}
+	router := setupRouter(svc)
+	wf := webhookWorkflow("")
+	body, err := json.Marshal(updateWorkflowRequest{Name: "Copy", Nodes: wf.Nodes, Edges: wf.Edges})
+	require.NoError(t, err)
+
+	w := serve(router, "PUT", workflowPath, string(body))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "webhook token is already in use", errorMessage(t, w))
+}
+
+func TestHandleGetWorkflow_RedactsTriggerCredentialsFromViewers(t *testing.T) {
+	bindings := &memBindingStore{}
+	bindings.grant("key:viewer", auth.RoleViewer, "")
+	bindings.grant("key:editor", auth.RoleEditor, "")
+	svc := newTestService(webhookWorkflow("{{secret.HOOK_KEY}}"), 30.0)
+
+	trigger := func(subject string) map[string]any {
+		w := serve(newAccessRouter(svc, bindings, subject), "GET", workflowPath, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var wf Workflow
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &wf))
+		return wf.Nodes[0].Data.Metadata["trigger"].(map[string]any)
+	}
+
+	viewed := trigger("key:viewer")
+	assert.Equal(t, "[redacted]", viewed["token"])
+	assert.Equal(t, "[redacted]", viewed["secret"])
+	assert.Equal(t, "webhook", viewed["type"])
+
+	edited := trigger("key:editor")
+	assert.Equal(t, "tok-0123456789abcdef", edited["token"])
+	assert.Equal(t, "{{secret.HOOK_KEY}}", edited["secret"])
+}
+
+func TestMapPayload(t *testing.T) {
+	var payload any
+	require.NoError(t, json.Unmarshal([]byte(monitoringPayload), &payload))
+
+	formData := mapPayload(payload, map[string]string{
+		"city":     "alert.site.city",
+		"severity": "alert.severity",
+		"missing":  "alert.nope",
+	})
+
+	assert.Equal(t, map[string]any{"city": "Sydney", "severity": "3"}, formData)
+}
@@ -2,11 +2,15 @@ package workflow
 
 import (
 	"encoding/json"
-	"log/slog"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+
+	"workflow-code-test/api/pkg/auth"
+	"workflow-code-test/api/pkg/logging"
 )
 
 // HandleGetWorkflow loads a workflow definition from the database and returns it as JSON.
@@ -16,11 +20,14 @@ func (s *Service) HandleGetWorkflow(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid workflow id")
 		return
 	}
-	slog.Debug("Getting workflow", "id", id)
+	if !s.authorize(w, r, auth.PermView, id) {
+		return
+	}
+	logging.FromContext(r.Context()).Debug("Getting workflow", "id", id)
 
-	wf, err := s.repo.Get(r.Context(), id)
+	wf, err := s.repo.Get(r.Context(), auth.Tenant(r.Context()), id)
 	if err != nil {
-		slog.Error("Failed to get workflow", "id", id, "error", err)
+		logging.FromContext(r.Context()).Error("Failed to get workflow", "id", id, "error", err)
 		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
@@ -28,6 +35,16 @@ func (s *Service) HandleGetWorkflow(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, "workflow not found")
 		return
 	}
+	// The webhook token and secret let anyone start the workflow, so only editors see them
+	canEdit, err := s.allowed(r, auth.PermEdit, id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to check permissions", "id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if !canEdit {
+		wf = withoutTriggerCredentials(wf)
+	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(wf)
@@ -41,7 +58,11 @@ func (s *Service) HandleExecuteWorkflow(w http.ResponseWriter, r *http.Request)
 		writeError(w, http.StatusBadRequest, "invalid workflow id")
 		return
 	}
-	slog.Debug("Executing workflow", "id", id)
+	if !s.authorize(w, r, auth.PermRun, id) {
+		return
+	}
+	ctx := logging.With(r.Context(), "workflowId", id)
+	logging.FromContext(ctx).Debug("Executing workflow")
 
 	var req ExecuteRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -50,14 +71,14 @@ func (s *Service) HandleExecuteWorkflow(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Validate required fields
-	if err := validateExecuteRequest(req); err != nil {
+	if err := ValidateExecuteRequest(req); err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	wf, err := s.repo.Get(r.Context(), id)
+	wf, err := s.repo.Get(ctx, auth.Tenant(ctx), id)
 	if err != nil {
-		slog.Error("Failed to get workflow for execution", "id", id, "error", err)
+		logging.FromContext(ctx).Error("Failed to get workflow for execution", "error", err)
 		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
@@ -65,24 +86,164 @@ func (s *Service) HandleExecuteWorkflow(w http.ResponseWriter, r *http.Request)
 		writeError(w, http.StatusNotFound, "workflow not found")
 		return
 	}
-
-	state := &ExecutionState{
-		FormData:  req.FormData,
-		Condition: req.Condition,
-		Variables: make(map[string]any),
+	if err := ValidateMocks(wf, req.Mocks); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	results, err := s.engine.Execute(r.Context(), wf, state)
+	state := NewExecutionState(req)
+	results, err := s.engine.Execute(ctx, wf, state)
 	if err != nil {
-		slog.Error("Workflow execution failed", "id", id, "error", err)
+		logging.FromContext(ctx).Error("Workflow execution failed", "error", err)
 		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
+	results.Metadata = ExecutionMetadata(state)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(results)
 }
 
+// updateWorkflowRequest is the body of PUT /workflows/{id}. Omitting tests keeps the
+// workflow's current test cases.
+type updateWorkflowRequest struct {
+	Name  string     `json:"name"`
+	Nodes []Node     `json:"nodes"`
+	Edges []Edge     `json:"edges"`
+	Tests []TestCase `json:"tests"`
+}
+
+// HandleUpdateWorkflow replaces a workflow's name and graph, and its test cases if given.
+func (s *Service) HandleUpdateWorkflow(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, err := uuid.Parse(id); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid workflow id")
+		return
+	}
+	if !s.authorize(w, r, auth.PermEdit, id) {
+		return
+	}
+	logging.FromContext(r.Context()).Debug("Updating workflow", "id", id)
+
+	var req updateWorkflowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := validateWorkflowGraph(req.Nodes, req.Edges); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validateTrigger(req.Nodes); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Edges == nil {
+		req.Edges = []Edge{}
+	}
+
+	wf := &Workflow{ID: id, TenantID: auth.Tenant(r.Context()), Name: req.Name, Nodes: req.Nodes, Edges: req.Edges, Tests: req.Tests}
+	if err := ValidateTestCases(wf); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// A webhook trigger lets anyone holding its token run the workflow, so setting one
+	// takes the run permission as well as edit
+	current, err := s.repo.Get(r.Context(), wf.TenantID, id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get workflow for update", "id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if current == nil {
+		writeError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+	if triggerChanged(current.Nodes, wf.Nodes) && !s.authorize(w, r, auth.PermRun, id) {
+		return
+	}
+	found, err := s.repo.Update(r.Context(), wf)
+	if errors.Is(err, ErrWebhookTokenTaken) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to update workflow", "id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(wf)
+}
+
+// triggerChanged reports whether next adds a start node trigger or changes the one in prev.
+func triggerChanged(prev, next []Node) bool {
+	after, ok := triggerMetadata(next)
+	if !ok {
+		return false
+	}
+	before, _ := triggerMetadata(prev)
+	return toJSON(before) != toJSON(after)
+}
+
+// validateWorkflowGraph checks a definition has exactly one start node and that every
+// edge connects nodes that exist.
+func validateWorkflowGraph(nodes []Node, edges []Edge) error {
+	if len(nodes) == 0 {
+		return errMissing("nodes")
+	}
+	ids := make(map[string]bool, len(nodes))
+	starts := 0
+	for _, n := range nodes {
+		if n.ID == "" || ids[n.ID] {
+			return errInvalid("nodes")
+		}
+		ids[n.ID] = true
+		if n.Type == "start" {
+			starts++
+		}
+	}
+	if starts != 1 {
+		return errInvalid("nodes")
+	}
+	for _, e := range edges {
+		if !ids[e.Source] || !ids[e.Target] {
+			return errInvalid("edges")
+		}
+	}
+	return nil
+}
+
+// ValidateDefinition checks a definition and its test cases with the rules
+// PUT /workflows/{id} applies and, unless registry is nil, that registry has an executor
+// for every node type.
+func ValidateDefinition(wf *Workflow, registry Registry) error {
+	if err := validateWorkflowGraph(wf.Nodes, wf.Edges); err != nil {
+		return err
+	}
+	if err := validateTrigger(wf.Nodes); err != nil {
+		return err
+	}
+	if err := ValidateTestCases(wf); err != nil {
+		return err
+	}
+	if registry == nil {
+		return nil
+	}
+	for _, n := range wf.Nodes {
+		if _, ok := registry[n.Type]; !ok {
+			return fmt.Errorf("node %q has unknown type %q", n.ID, n.Type)
+		}
+	}
+	return nil
+}
+
 func writeError(w http.ResponseWriter, status int, message string) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(map[string]string{"message": message})
@@ -96,7 +257,8 @@ var validOperators = map[string]bool{
 	"less_than_or_equal":    true,
 }
 
-func validateExecuteRequest(req ExecuteRequest) error {
+// ValidateExecuteRequest checks the form fields and condition an execution needs.
+func ValidateExecuteRequest(req ExecuteRequest) error {
 	if req.FormData == nil {
 		return errMissing("formData")
 	}
@@ -112,9 +274,35 @@ func validateExecuteRequest(req ExecuteRequest) error {
 	if !validOperators[req.Condition.Operator] {
 		return errInvalid("operator")
 	}
+	if _, ok := ParseTemperatureUnit(req.Unit); req.Unit != "" && !ok {
+		return errInvalid("unit")
+	}
+	if req.Mode != "" && req.Mode != ModeLive && req.Mode != ModeDryRun {
+		return errInvalid("mode")
+	}
 	return nil
 }
 
+// NewExecutionState builds the initial state for running an already validated request.
+func NewExecutionState(req ExecuteRequest) *ExecutionState {
+	return &ExecutionState{
+		FormData:  req.FormData,
+		Condition: req.Condition,
+		Unit:      requestUnit(req),
+		Variables: make(map[string]any),
+		DryRun:    req.Mode == ModeDryRun,
+		Mocks:     req.Mocks,
+	}
+}
+
+// requestUnit resolves the execution's temperature unit from an already validated request.
+func requestUnit(req ExecuteRequest) TemperatureUnit {
+	if unit, ok := ParseTemperatureUnit(req.Unit); ok {
+		return unit
+	}
+	return UnitForLocale(req.Locale)
+}
+
 type validationError struct {
 	field string
 	kind  string
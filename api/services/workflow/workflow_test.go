@@ -4,38 +4,87 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"workflow-code-test/api/pkg/auth"
+	"workflow-code-test/api/pkg/resilience"
 )
 
-// stubRepo implements the Get method for testing without a database.
+// stubRepo implements WorkflowRepo for testing without a database.
 type stubRepo struct {
-	workflow *Workflow
-	err      error
+	workflow   *Workflow
+	err        error
+	usedTokens map[string]bool // Webhook tokens held by other workflows
 }
 
-func (r *stubRepo) Get(_ context.Context, _ string) (*Workflow, error) {
+func (r *stubRepo) Get(_ context.Context, tenant, _ string) (*Workflow, error) {
+	if r.workflow != nil && r.workflow.TenantID != tenant {
+		return nil, r.err
+	}
 	return r.workflow, r.err
 }
 
+func (r *stubRepo) FindByWebhookToken(_ context.Context, _ string) (*Workflow, error) {
+	return r.workflow, r.err
+}
+
+func (r *stubRepo) Update(_ context.Context, wf *Workflow) (bool, error) {
+	if r.err != nil || r.workflow == nil || r.workflow.TenantID != wf.TenantID {
+		return false, r.err
+	}
+	if token := webhookTokenOf(wf); token != nil && r.usedTokens[*token] {
+		return false, ErrWebhookTokenTaken
+	}
+	if wf.Tests == nil {
+		wf.Tests = r.workflow.Tests
+	}
+	r.workflow = wf
+	return true, nil
+}
+
+// newTestService serves wf, which belongs to the default tenant unless it names another.
 func newTestService(wf *Workflow, weatherTemp float64) *Service {
+	if wf != nil && wf.TenantID == "" {
+		wf.TenantID = auth.DefaultTenant
+	}
 	repo := &stubRepo{workflow: wf}
 	client := &mockWeatherClient{temperature: weatherTemp}
-	registry := NewRegistry(client)
+	registry := NewRegistry(client, nil, defaultInlineDelay)
 	engine := NewEngine(registry)
-	return &Service{repo: repo, engine: engine}
+	executions := newMemExecutionStore()
+	engine.suspensions = executions
+	return &Service{
+		repo:           repo,
+		schedules:      newMemScheduleStore(),
+		executions:     executions,
+		engine:         engine,
+		idempotency:    newMemIdempotencyStore(),
+		idempotencyTTL: time.Hour,
+	}
 }
 
 func setupRouter(svc *Service) *mux.Router {
 	router := mux.NewRouter()
 	sub := router.PathPrefix("/api/v1/workflows").Subrouter()
 	sub.HandleFunc("/{id}", svc.HandleGetWorkflow).Methods("GET")
-	sub.HandleFunc("/{id}/execute", svc.HandleExecuteWorkflow).Methods("POST")
+	sub.HandleFunc("/{id}", svc.HandleUpdateWorkflow).Methods("PUT")
+	sub.Handle("/{id}/execute", svc.idempotent(http.HandlerFunc(svc.HandleExecuteWorkflow))).Methods("POST")
+	sub.HandleFunc("/{id}/schedules", svc.HandleListSchedules).Methods("GET")
+	sub.HandleFunc("/{id}/schedules", svc.HandleCreateSchedule).Methods("POST")
+	sub.HandleFunc("/{id}/tests/run", svc.HandleRunTests).Methods("POST")
+	router.HandleFunc("/api/v1/schedules/{scheduleId}", svc.HandleGetSchedule).Methods("GET")
+	router.HandleFunc("/api/v1/schedules/{scheduleId}", svc.HandleUpdateSchedule).Methods("PUT")
+	router.HandleFunc("/api/v1/schedules/{scheduleId}", svc.HandleDeleteSchedule).Methods("DELETE")
+	router.HandleFunc("/api/v1/executions/{executionId}", svc.HandleGetExecution).Methods("GET")
+	router.HandleFunc("/api/v1/hooks/{token}", svc.HandleWebhook).Methods("POST")
 	return router
 }
 
@@ -157,6 +206,107 @@ func TestHandleExecuteWorkflow_InvalidOperator(t *testing.T) {
 	assert.Contains(t, result["message"], "operator")
 }
 
+func TestHandleExecuteWorkflow_InvalidUnit(t *testing.T) {
+	svc := newTestService(testWorkflow(), 30.0)
+	router := setupRouter(svc)
+
+	body, _ := json.Marshal(ExecuteRequest{
+		FormData:  map[string]any{"name": "Alice", "email": "alice@example.com", "city": "Sydney"},
+		Condition: ConditionInput{Operator: "greater_than", Threshold: 25},
+		Unit:      "rankine",
+	})
+
+	req := httptest.NewRequest("POST", "/api/v1/workflows/550e8400-e29b-41d4-a716-446655440000/execute", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var result map[string]string
+	json.NewDecoder(w.Body).Decode(&result)
+	assert.Equal(t, "unit is invalid", result["message"])
+}
+
+func TestHandleExecuteWorkflow_LocaleSelectsFahrenheit(t *testing.T) {
+	svc := newTestService(testWorkflow(), 30.0) // 86°F
+	router := setupRouter(svc)
+
+	body, _ := json.Marshal(ExecuteRequest{
+		FormData:  map[string]any{"name": "Alice", "email": "alice@example.com", "city": "Sydney"},
+		Condition: ConditionInput{Operator: "greater_than", Threshold: 80},
+		Locale:    "en-US",
+	})
+
+	req := httptest.NewRequest("POST", "/api/v1/workflows/550e8400-e29b-41d4-a716-446655440000/execute", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var result ExecutionResults
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.Equal(t, "fahrenheit", result.Metadata["unit"])
+	assert.Len(t, result.Steps, 6, "86°F > 80°F should take the alert branch")
+}
+
+func TestHandleExecuteWorkflow_DryRun(t *testing.T) {
+	svc := newTestService(testWorkflow(), 10.0)
+	router := setupRouter(svc)
+
+	body, _ := json.Marshal(ExecuteRequest{
+		FormData:  map[string]any{"name": "Alice", "email": "alice@example.com", "city": "Sydney"},
+		Condition: ConditionInput{Operator: "greater_than", Threshold: 25},
+		Mode:      ModeDryRun,
+		Mocks:     map[string]map[string]any{"weather-api": {"temperature": 40}},
+	})
+
+	req := httptest.NewRequest("POST", "/api/v1/workflows/550e8400-e29b-41d4-a716-446655440000/execute", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var result ExecutionResults
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.Equal(t, "completed", result.Status)
+	assert.Equal(t, ModeDryRun, result.Metadata["mode"])
+	require.Len(t, result.Steps, 6)
+	assert.True(t, result.Steps[2].Mocked)
+	assert.Equal(t, false, result.Steps[4].Output["emailSent"])
+}
+
+func TestHandleExecuteWorkflow_InvalidSimulation(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  string
+		mocks map[string]map[string]any
+		want  string
+	}{
+		{"unknown mode", "rehearsal", nil, "mode is invalid"},
+		{"unknown node", ModeDryRun, map[string]map[string]any{"sms": {"sent": true}}, `mocks: node "sms" not found`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupRouter(newTestService(testWorkflow(), 30.0))
+			body, _ := json.Marshal(ExecuteRequest{
+				FormData:  map[string]any{"name": "Alice", "email": "alice@example.com", "city": "Sydney"},
+				Condition: ConditionInput{Operator: "greater_than", Threshold: 25},
+				Mode:      tt.mode,
+				Mocks:     tt.mocks,
+			})
+
+			req := httptest.NewRequest("POST", "/api/v1/workflows/550e8400-e29b-41d4-a716-446655440000/execute", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var result map[string]string
+			json.NewDecoder(w.Body).Decode(&result)
+			assert.Equal(t, tt.want, result["message"])
+		})
+	}
+}
+
 func TestHandleExecuteWorkflow_NotFound(t *testing.T) {
 	svc := newTestService(nil, 0)
 	router := setupRouter(svc)
@@ -186,3 +336,39 @@ func TestHandleExecuteWorkflow_InvalidJSON(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
+
+func TestHandleDiagnostics_ReportsBreakerState(t *testing.T) {
+	guard := resilience.NewGuard("open-meteo", resilience.BreakerConfig{FailureThreshold: 1}, resilience.DefaultRateLimitConfig())
+	client := NewGuardedWeatherClient(&mockWeatherClient{err: fmt.Errorf("connection refused")}, guard)
+	_, err := client.GetWeather(context.Background(), 0, 0, 0)
+	require.Error(t, err)
+
+	svc := &Service{guards: []*resilience.Guard{guard}}
+	req := httptest.NewRequest("GET", "/api/v1/diagnostics", nil)
+	w := httptest.NewRecorder()
+	svc.HandleDiagnostics(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result DiagnosticsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	require.Len(t, result.Breakers, 1)
+	assert.Equal(t, "open-meteo", result.Breakers[0].Name)
+	assert.Equal(t, "open", result.Breakers[0].State)
+
+	_, err = client.GetWeather(context.Background(), 0, 0, 0)
+	assert.ErrorIs(t, err, resilience.ErrCircuitOpen)
+}
+
+func TestValidateDefinition(t *testing.T) {
+	registry := NewRegistry(nil, nil, defaultInlineDelay)
+	require.NoError(t, ValidateDefinition(testWorkflow(), registry))
+
+	wf := testWorkflow()
+	wf.Nodes[2].Type = "sms"
+	assert.EqualError(t, ValidateDefinition(wf, registry), `node "weather-api" has unknown type "sms"`)
+	assert.NoError(t, ValidateDefinition(wf, nil), "without a registry only the graph is checked")
+
+	wf.Edges = append(wf.Edges, Edge{ID: "dangling", Source: "start", Target: "nowhere"})
+	assert.EqualError(t, ValidateDefinition(wf, nil), "edges is invalid")
+}